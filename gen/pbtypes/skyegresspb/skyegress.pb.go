@@ -20,20 +20,321 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-// represents an egress session
-type Session struct {
+// lifecycle state of an egress session
+type SessionState int32
+
+const (
+	SessionState_SESSION_STATE_STARTING SessionState = 0
+	SessionState_SESSION_STATE_ACTIVE   SessionState = 1
+	SessionState_SESSION_STATE_FAILED   SessionState = 2
+	SessionState_SESSION_STATE_STOPPED  SessionState = 3
+	// still connected to the room, but the track isn't delivering packets
+	SessionState_SESSION_STATE_SOURCE_LOST SessionState = 4
+)
+
+// Enum value maps for SessionState.
+var (
+	SessionState_name = map[int32]string{
+		0: "SESSION_STATE_STARTING",
+		1: "SESSION_STATE_ACTIVE",
+		2: "SESSION_STATE_FAILED",
+		3: "SESSION_STATE_STOPPED",
+		4: "SESSION_STATE_SOURCE_LOST",
+	}
+	SessionState_value = map[string]int32{
+		"SESSION_STATE_STARTING":    0,
+		"SESSION_STATE_ACTIVE":      1,
+		"SESSION_STATE_FAILED":      2,
+		"SESSION_STATE_STOPPED":     3,
+		"SESSION_STATE_SOURCE_LOST": 4,
+	}
+)
+
+func (x SessionState) Enum() *SessionState {
+	p := new(SessionState)
+	*p = x
+	return p
+}
+
+func (x SessionState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SessionState) Descriptor() protoreflect.EnumDescriptor {
+	return file_skyegress_proto_enumTypes[0].Descriptor()
+}
+
+func (SessionState) Type() protoreflect.EnumType {
+	return &file_skyegress_proto_enumTypes[0]
+}
+
+func (x SessionState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SessionState.Descriptor instead.
+func (SessionState) EnumDescriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{0}
+}
+
+// which way a session carries video
+type SessionKind int32
+
+const (
+	// from a LiveKit room or another source to RTSP readers
+	SessionKind_SESSION_KIND_EGRESS SessionKind = 0
+	// pulled from an RTSP server, such as an IP camera, and published into a LiveKit room
+	SessionKind_SESSION_KIND_INGRESS SessionKind = 1
+	// pushed to the server by an RTSP publisher with ANNOUNCE and RECORD, and published into a LiveKit room
+	SessionKind_SESSION_KIND_PUBLISH SessionKind = 2
+)
+
+// Enum value maps for SessionKind.
+var (
+	SessionKind_name = map[int32]string{
+		0: "SESSION_KIND_EGRESS",
+		1: "SESSION_KIND_INGRESS",
+		2: "SESSION_KIND_PUBLISH",
+	}
+	SessionKind_value = map[string]int32{
+		"SESSION_KIND_EGRESS":  0,
+		"SESSION_KIND_INGRESS": 1,
+		"SESSION_KIND_PUBLISH": 2,
+	}
+)
+
+func (x SessionKind) Enum() *SessionKind {
+	p := new(SessionKind)
+	*p = x
+	return p
+}
+
+func (x SessionKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SessionKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_skyegress_proto_enumTypes[1].Descriptor()
+}
+
+func (SessionKind) Type() protoreflect.EnumType {
+	return &file_skyegress_proto_enumTypes[1]
+}
+
+func (x SessionKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SessionKind.Descriptor instead.
+func (SessionKind) EnumDescriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{1}
+}
+
+// which of a session's tracks is being relayed
+type SessionSource int32
+
+const (
+	SessionSource_SESSION_SOURCE_PRIMARY SessionSource = 0
+	SessionSource_SESSION_SOURCE_BACKUP  SessionSource = 1
+)
+
+// Enum value maps for SessionSource.
+var (
+	SessionSource_name = map[int32]string{
+		0: "SESSION_SOURCE_PRIMARY",
+		1: "SESSION_SOURCE_BACKUP",
+	}
+	SessionSource_value = map[string]int32{
+		"SESSION_SOURCE_PRIMARY": 0,
+		"SESSION_SOURCE_BACKUP":  1,
+	}
+)
+
+func (x SessionSource) Enum() *SessionSource {
+	p := new(SessionSource)
+	*p = x
+	return p
+}
+
+func (x SessionSource) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SessionSource) Descriptor() protoreflect.EnumDescriptor {
+	return file_skyegress_proto_enumTypes[2].Descriptor()
+}
+
+func (SessionSource) Type() protoreflect.EnumType {
+	return &file_skyegress_proto_enumTypes[2]
+}
+
+func (x SessionSource) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SessionSource.Descriptor instead.
+func (SessionSource) EnumDescriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{2}
+}
+
+// which frames sent to readers are preceded by an SEI NAL unit carrying their FrameInfo
+type SeiMode int32
+
+const (
+	SeiMode_SEI_MODE_OFF        SeiMode = 0
+	SeiMode_SEI_MODE_KEYFRAMES  SeiMode = 1
+	SeiMode_SEI_MODE_ALL_FRAMES SeiMode = 2
+)
+
+// Enum value maps for SeiMode.
+var (
+	SeiMode_name = map[int32]string{
+		0: "SEI_MODE_OFF",
+		1: "SEI_MODE_KEYFRAMES",
+		2: "SEI_MODE_ALL_FRAMES",
+	}
+	SeiMode_value = map[string]int32{
+		"SEI_MODE_OFF":        0,
+		"SEI_MODE_KEYFRAMES":  1,
+		"SEI_MODE_ALL_FRAMES": 2,
+	}
+)
+
+func (x SeiMode) Enum() *SeiMode {
+	p := new(SeiMode)
+	*p = x
+	return p
+}
+
+func (x SeiMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SeiMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_skyegress_proto_enumTypes[3].Descriptor()
+}
+
+func (SeiMode) Type() protoreflect.EnumType {
+	return &file_skyegress_proto_enumTypes[3]
+}
+
+func (x SeiMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SeiMode.Descriptor instead.
+func (SeiMode) EnumDescriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{3}
+}
+
+// kind of session lifecycle event
+type SessionEventType int32
+
+const (
+	SessionEventType_SESSION_EVENT_UNSPECIFIED SessionEventType = 0
+	// packets are being relayed for the first time
+	SessionEventType_SESSION_EVENT_STARTED SessionEventType = 1
+	// the source stopped delivering packets
+	SessionEventType_SESSION_EVENT_SOURCE_LOST SessionEventType = 2
+	// packets are being relayed again after the source was lost
+	SessionEventType_SESSION_EVENT_RECONNECTED SessionEventType = 3
+	SessionEventType_SESSION_EVENT_STOPPED     SessionEventType = 4
+	SessionEventType_SESSION_EVENT_FAILED      SessionEventType = 5
+	// the session was added to the server, before it connects to its source
+	SessionEventType_SESSION_EVENT_CREATED SessionEventType = 6
+	// periodic update of the session's stats
+	SessionEventType_SESSION_EVENT_STATS SessionEventType = 7
+	// the session as it was when a watch began
+	SessionEventType_SESSION_EVENT_SNAPSHOT SessionEventType = 8
+	// the backup track is being relayed because the primary stopped delivering packets
+	SessionEventType_SESSION_EVENT_FAILOVER SessionEventType = 9
+	// the primary track is being relayed again
+	SessionEventType_SESSION_EVENT_FAILBACK SessionEventType = 10
+	// no source packets are arriving, so readers are being sent the slate
+	SessionEventType_SESSION_EVENT_SLATE_STARTED SessionEventType = 11
+	// the source replaced the slate at a keyframe
+	SessionEventType_SESSION_EVENT_SLATE_STOPPED SessionEventType = 12
+)
+
+// Enum value maps for SessionEventType.
+var (
+	SessionEventType_name = map[int32]string{
+		0:  "SESSION_EVENT_UNSPECIFIED",
+		1:  "SESSION_EVENT_STARTED",
+		2:  "SESSION_EVENT_SOURCE_LOST",
+		3:  "SESSION_EVENT_RECONNECTED",
+		4:  "SESSION_EVENT_STOPPED",
+		5:  "SESSION_EVENT_FAILED",
+		6:  "SESSION_EVENT_CREATED",
+		7:  "SESSION_EVENT_STATS",
+		8:  "SESSION_EVENT_SNAPSHOT",
+		9:  "SESSION_EVENT_FAILOVER",
+		10: "SESSION_EVENT_FAILBACK",
+		11: "SESSION_EVENT_SLATE_STARTED",
+		12: "SESSION_EVENT_SLATE_STOPPED",
+	}
+	SessionEventType_value = map[string]int32{
+		"SESSION_EVENT_UNSPECIFIED":   0,
+		"SESSION_EVENT_STARTED":       1,
+		"SESSION_EVENT_SOURCE_LOST":   2,
+		"SESSION_EVENT_RECONNECTED":   3,
+		"SESSION_EVENT_STOPPED":       4,
+		"SESSION_EVENT_FAILED":        5,
+		"SESSION_EVENT_CREATED":       6,
+		"SESSION_EVENT_STATS":         7,
+		"SESSION_EVENT_SNAPSHOT":      8,
+		"SESSION_EVENT_FAILOVER":      9,
+		"SESSION_EVENT_FAILBACK":      10,
+		"SESSION_EVENT_SLATE_STARTED": 11,
+		"SESSION_EVENT_SLATE_STOPPED": 12,
+	}
+)
+
+func (x SessionEventType) Enum() *SessionEventType {
+	p := new(SessionEventType)
+	*p = x
+	return p
+}
+
+func (x SessionEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SessionEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_skyegress_proto_enumTypes[4].Descriptor()
+}
+
+func (SessionEventType) Type() protoreflect.EnumType {
+	return &file_skyegress_proto_enumTypes[4]
+}
+
+func (x SessionEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SessionEventType.Descriptor instead.
+func (SessionEventType) EnumDescriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{4}
+}
+
+// an RTSP client reading an egress session
+type ReaderStats struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Sid            string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
-	RoomName       string `protobuf:"bytes,2,opt,name=room_name,json=roomName,proto3" json:"room_name,omitempty"`
-	TrackName      string `protobuf:"bytes,3,opt,name=track_name,json=trackName,proto3" json:"track_name,omitempty"`
-	EgressIdentity string `protobuf:"bytes,4,opt,name=egress_identity,json=egressIdentity,proto3" json:"egress_identity,omitempty"`
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// e.g. UDP, UDP-multicast or TCP
+	Transport string `protobuf:"bytes,2,opt,name=transport,proto3" json:"transport,omitempty"`
+	BytesSent uint64 `protobuf:"varint,3,opt,name=bytes_sent,json=bytesSent,proto3" json:"bytes_sent,omitempty"`
+	// unix time in milliseconds
+	ConnectedAt int64 `protobuf:"varint,4,opt,name=connected_at,json=connectedAt,proto3" json:"connected_at,omitempty"`
+	// identifies the reader when disconnecting it
+	Id string `protobuf:"bytes,5,opt,name=id,proto3" json:"id,omitempty"`
 }
 
-func (x *Session) Reset() {
-	*x = Session{}
+func (x *ReaderStats) Reset() {
+	*x = ReaderStats{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_skyegress_proto_msgTypes[0]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -41,13 +342,13 @@ func (x *Session) Reset() {
 	}
 }
 
-func (x *Session) String() string {
+func (x *ReaderStats) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Session) ProtoMessage() {}
+func (*ReaderStats) ProtoMessage() {}
 
-func (x *Session) ProtoReflect() protoreflect.Message {
+func (x *ReaderStats) ProtoReflect() protoreflect.Message {
 	mi := &file_skyegress_proto_msgTypes[0]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -59,50 +360,65 @@ func (x *Session) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Session.ProtoReflect.Descriptor instead.
-func (*Session) Descriptor() ([]byte, []int) {
+// Deprecated: Use ReaderStats.ProtoReflect.Descriptor instead.
+func (*ReaderStats) Descriptor() ([]byte, []int) {
 	return file_skyegress_proto_rawDescGZIP(), []int{0}
 }
 
-func (x *Session) GetSid() string {
+func (x *ReaderStats) GetAddress() string {
 	if x != nil {
-		return x.Sid
+		return x.Address
 	}
 	return ""
 }
 
-func (x *Session) GetRoomName() string {
+func (x *ReaderStats) GetTransport() string {
 	if x != nil {
-		return x.RoomName
+		return x.Transport
 	}
 	return ""
 }
 
-func (x *Session) GetTrackName() string {
+func (x *ReaderStats) GetBytesSent() uint64 {
 	if x != nil {
-		return x.TrackName
+		return x.BytesSent
 	}
-	return ""
+	return 0
 }
 
-func (x *Session) GetEgressIdentity() string {
+func (x *ReaderStats) GetConnectedAt() int64 {
 	if x != nil {
-		return x.EgressIdentity
+		return x.ConnectedAt
+	}
+	return 0
+}
+
+func (x *ReaderStats) GetId() string {
+	if x != nil {
+		return x.Id
 	}
 	return ""
 }
 
-// represents a list of egress sessions
-type Sessions struct {
+// an output of an egress session
+type SinkStats struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Sessions []*Session `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// packets, frames and RTCP written to the sink, dropped because it fell behind, and that it failed to write
+	Written uint64 `protobuf:"varint,2,opt,name=written,proto3" json:"written,omitempty"`
+	Dropped uint64 `protobuf:"varint,3,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	Errors  uint64 `protobuf:"varint,4,opt,name=errors,proto3" json:"errors,omitempty"`
+	// waiting to be written
+	Buffered uint32 `protobuf:"varint,5,opt,name=buffered,proto3" json:"buffered,omitempty"`
+	// closed after falling behind
+	Closed bool `protobuf:"varint,6,opt,name=closed,proto3" json:"closed,omitempty"`
 }
 
-func (x *Sessions) Reset() {
-	*x = Sessions{}
+func (x *SinkStats) Reset() {
+	*x = SinkStats{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_skyegress_proto_msgTypes[1]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -110,13 +426,13 @@ func (x *Sessions) Reset() {
 	}
 }
 
-func (x *Sessions) String() string {
+func (x *SinkStats) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Sessions) ProtoMessage() {}
+func (*SinkStats) ProtoMessage() {}
 
-func (x *Sessions) ProtoReflect() protoreflect.Message {
+func (x *SinkStats) ProtoReflect() protoreflect.Message {
 	mi := &file_skyegress_proto_msgTypes[1]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -128,30 +444,97 @@ func (x *Sessions) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Sessions.ProtoReflect.Descriptor instead.
-func (*Sessions) Descriptor() ([]byte, []int) {
+// Deprecated: Use SinkStats.ProtoReflect.Descriptor instead.
+func (*SinkStats) Descriptor() ([]byte, []int) {
 	return file_skyegress_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *Sessions) GetSessions() []*Session {
+func (x *SinkStats) GetName() string {
 	if x != nil {
-		return x.Sessions
+		return x.Name
 	}
-	return nil
+	return ""
 }
 
-// request to start an egress session
-type StartSessionRequest struct {
+func (x *SinkStats) GetWritten() uint64 {
+	if x != nil {
+		return x.Written
+	}
+	return 0
+}
+
+func (x *SinkStats) GetDropped() uint64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+func (x *SinkStats) GetErrors() uint64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *SinkStats) GetBuffered() uint32 {
+	if x != nil {
+		return x.Buffered
+	}
+	return 0
+}
+
+func (x *SinkStats) GetClosed() bool {
+	if x != nil {
+		return x.Closed
+	}
+	return false
+}
+
+// live statistics for an egress session
+type SessionStats struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	RoomName  string `protobuf:"bytes,1,opt,name=room_name,json=roomName,proto3" json:"room_name,omitempty"`
-	TrackName string `protobuf:"bytes,2,opt,name=track_name,json=trackName,proto3" json:"track_name,omitempty"`
-}
-
-func (x *StartSessionRequest) Reset() {
-	*x = StartSessionRequest{}
+	// packets and payload bytes written to RTSP readers
+	PacketsRelayed uint64 `protobuf:"varint,1,opt,name=packets_relayed,json=packetsRelayed,proto3" json:"packets_relayed,omitempty"`
+	BytesRelayed   uint64 `protobuf:"varint,2,opt,name=bytes_relayed,json=bytesRelayed,proto3" json:"bytes_relayed,omitempty"`
+	// packets and payload bytes received from LiveKit
+	PacketsReceived uint64 `protobuf:"varint,3,opt,name=packets_received,json=packetsReceived,proto3" json:"packets_received,omitempty"`
+	BytesReceived   uint64 `protobuf:"varint,4,opt,name=bytes_received,json=bytesReceived,proto3" json:"bytes_received,omitempty"`
+	// bits per second received from LiveKit, over the last second
+	InputBitrate uint64 `protobuf:"varint,5,opt,name=input_bitrate,json=inputBitrate,proto3" json:"input_bitrate,omitempty"`
+	// frames per second relayed, over the last second
+	FrameRate float64 `protobuf:"fixed64,6,opt,name=frame_rate,json=frameRate,proto3" json:"frame_rate,omitempty"`
+	// resolution from the most recent SPS
+	Width  uint32 `protobuf:"varint,7,opt,name=width,proto3" json:"width,omitempty"`
+	Height uint32 `protobuf:"varint,8,opt,name=height,proto3" json:"height,omitempty"`
+	// time between the two most recent keyframes
+	KeyframeIntervalMs uint32 `protobuf:"varint,9,opt,name=keyframe_interval_ms,json=keyframeIntervalMs,proto3" json:"keyframe_interval_ms,omitempty"`
+	// gaps and out of order arrivals in the RTP sequence received from LiveKit
+	PacketsLost      uint64 `protobuf:"varint,10,opt,name=packets_lost,json=packetsLost,proto3" json:"packets_lost,omitempty"`
+	PacketsReordered uint64 `protobuf:"varint,11,opt,name=packets_reordered,json=packetsReordered,proto3" json:"packets_reordered,omitempty"`
+	// packets the samplebuilder gave up on, and the PLIs sent to recover from them
+	SamplebuilderDrops uint64 `protobuf:"varint,12,opt,name=samplebuilder_drops,json=samplebuilderDrops,proto3" json:"samplebuilder_drops,omitempty"`
+	PlisSent           uint64 `protobuf:"varint,13,opt,name=plis_sent,json=plisSent,proto3" json:"plis_sent,omitempty"`
+	// time since the last packet was received from LiveKit; -1 before the first packet
+	LastPacketAgeMs int64          `protobuf:"varint,14,opt,name=last_packet_age_ms,json=lastPacketAgeMs,proto3" json:"last_packet_age_ms,omitempty"`
+	Readers         []*ReaderStats `protobuf:"bytes,15,rep,name=readers,proto3" json:"readers,omitempty"`
+	// time since the last RTSP reader disconnected; 0 while any reader is connected
+	IdleMs int64 `protobuf:"varint,16,opt,name=idle_ms,json=idleMs,proto3" json:"idle_ms,omitempty"`
+	// the slate is being sent to readers in place of the missing source
+	SlateActive bool `protobuf:"varint,17,opt,name=slate_active,json=slateActive,proto3" json:"slate_active,omitempty"`
+	// times the RTP sent to readers was carried on from a new SSRC, sequence or timestamp base: a switch of source,
+	// or the subscription to one being rebuilt
+	RtpRebases uint64       `protobuf:"varint,18,opt,name=rtp_rebases,json=rtpRebases,proto3" json:"rtp_rebases,omitempty"`
+	Sinks      []*SinkStats `protobuf:"bytes,19,rep,name=sinks,proto3" json:"sinks,omitempty"`
+	// the session's RTP capture; unset if it has never been captured
+	Capture *CaptureStats `protobuf:"bytes,20,opt,name=capture,proto3" json:"capture,omitempty"`
+}
+
+func (x *SessionStats) Reset() {
+	*x = SessionStats{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_skyegress_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -159,13 +542,13 @@ func (x *StartSessionRequest) Reset() {
 	}
 }
 
-func (x *StartSessionRequest) String() string {
+func (x *SessionStats) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StartSessionRequest) ProtoMessage() {}
+func (*SessionStats) ProtoMessage() {}
 
-func (x *StartSessionRequest) ProtoReflect() protoreflect.Message {
+func (x *SessionStats) ProtoReflect() protoreflect.Message {
 	mi := &file_skyegress_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -177,131 +560,188 @@ func (x *StartSessionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StartSessionRequest.ProtoReflect.Descriptor instead.
-func (*StartSessionRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use SessionStats.ProtoReflect.Descriptor instead.
+func (*SessionStats) Descriptor() ([]byte, []int) {
 	return file_skyegress_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *StartSessionRequest) GetRoomName() string {
+func (x *SessionStats) GetPacketsRelayed() uint64 {
 	if x != nil {
-		return x.RoomName
+		return x.PacketsRelayed
 	}
-	return ""
+	return 0
 }
 
-func (x *StartSessionRequest) GetTrackName() string {
+func (x *SessionStats) GetBytesRelayed() uint64 {
 	if x != nil {
-		return x.TrackName
+		return x.BytesRelayed
 	}
-	return ""
+	return 0
 }
 
-// response to starting an egress session
-type StartSessionResponse struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	// Types that are assignable to Result:
-	//
-	//	*StartSessionResponse_Session
-	//	*StartSessionResponse_Error
-	Result isStartSessionResponse_Result `protobuf_oneof:"result"`
+func (x *SessionStats) GetPacketsReceived() uint64 {
+	if x != nil {
+		return x.PacketsReceived
+	}
+	return 0
 }
 
-func (x *StartSessionResponse) Reset() {
-	*x = StartSessionResponse{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_skyegress_proto_msgTypes[3]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *SessionStats) GetBytesReceived() uint64 {
+	if x != nil {
+		return x.BytesReceived
 	}
+	return 0
 }
 
-func (x *StartSessionResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *SessionStats) GetInputBitrate() uint64 {
+	if x != nil {
+		return x.InputBitrate
+	}
+	return 0
 }
 
-func (*StartSessionResponse) ProtoMessage() {}
+func (x *SessionStats) GetFrameRate() float64 {
+	if x != nil {
+		return x.FrameRate
+	}
+	return 0
+}
 
-func (x *StartSessionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_skyegress_proto_msgTypes[3]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+func (x *SessionStats) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use StartSessionResponse.ProtoReflect.Descriptor instead.
-func (*StartSessionResponse) Descriptor() ([]byte, []int) {
-	return file_skyegress_proto_rawDescGZIP(), []int{3}
+func (x *SessionStats) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
 }
 
-func (m *StartSessionResponse) GetResult() isStartSessionResponse_Result {
-	if m != nil {
-		return m.Result
+func (x *SessionStats) GetKeyframeIntervalMs() uint32 {
+	if x != nil {
+		return x.KeyframeIntervalMs
 	}
-	return nil
+	return 0
 }
 
-func (x *StartSessionResponse) GetSession() *Session {
-	if x, ok := x.GetResult().(*StartSessionResponse_Session); ok {
-		return x.Session
+func (x *SessionStats) GetPacketsLost() uint64 {
+	if x != nil {
+		return x.PacketsLost
 	}
-	return nil
+	return 0
 }
 
-func (x *StartSessionResponse) GetError() string {
-	if x, ok := x.GetResult().(*StartSessionResponse_Error); ok {
-		return x.Error
+func (x *SessionStats) GetPacketsReordered() uint64 {
+	if x != nil {
+		return x.PacketsReordered
 	}
-	return ""
+	return 0
 }
 
-type isStartSessionResponse_Result interface {
-	isStartSessionResponse_Result()
+func (x *SessionStats) GetSamplebuilderDrops() uint64 {
+	if x != nil {
+		return x.SamplebuilderDrops
+	}
+	return 0
 }
 
-type StartSessionResponse_Session struct {
-	Session *Session `protobuf:"bytes,1,opt,name=session,proto3,oneof"`
+func (x *SessionStats) GetPlisSent() uint64 {
+	if x != nil {
+		return x.PlisSent
+	}
+	return 0
 }
 
-type StartSessionResponse_Error struct {
-	Error string `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+func (x *SessionStats) GetLastPacketAgeMs() int64 {
+	if x != nil {
+		return x.LastPacketAgeMs
+	}
+	return 0
 }
 
-func (*StartSessionResponse_Session) isStartSessionResponse_Result() {}
+func (x *SessionStats) GetReaders() []*ReaderStats {
+	if x != nil {
+		return x.Readers
+	}
+	return nil
+}
 
-func (*StartSessionResponse_Error) isStartSessionResponse_Result() {}
+func (x *SessionStats) GetIdleMs() int64 {
+	if x != nil {
+		return x.IdleMs
+	}
+	return 0
+}
 
-// request to list egress sessions
-type ListSessionsRequest struct {
+func (x *SessionStats) GetSlateActive() bool {
+	if x != nil {
+		return x.SlateActive
+	}
+	return false
+}
+
+func (x *SessionStats) GetRtpRebases() uint64 {
+	if x != nil {
+		return x.RtpRebases
+	}
+	return 0
+}
+
+func (x *SessionStats) GetSinks() []*SinkStats {
+	if x != nil {
+		return x.Sinks
+	}
+	return nil
+}
+
+func (x *SessionStats) GetCapture() *CaptureStats {
+	if x != nil {
+		return x.Capture
+	}
+	return nil
+}
+
+// a capture of the RTP and RTCP a session receives from its source, written to a pcapng file as UDP datagrams: RTP to
+// port 5004 and RTCP to port 5005
+type CaptureStats struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
-}
 
-func (x *ListSessionsRequest) Reset() {
-	*x = ListSessionsRequest{}
+	// the file being written, or the last one written
+	Path    string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Active  bool   `protobuf:"varint,2,opt,name=active,proto3" json:"active,omitempty"`
+	Packets uint64 `protobuf:"varint,3,opt,name=packets,proto3" json:"packets,omitempty"`
+	Bytes   uint64 `protobuf:"varint,4,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	// size the capture is stopped at
+	MaxBytes uint64 `protobuf:"varint,5,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`
+	// why the capture stopped; empty while it's active
+	StoppedReason string `protobuf:"bytes,6,opt,name=stopped_reason,json=stoppedReason,proto3" json:"stopped_reason,omitempty"`
+	// packets not captured because the file couldn't be written fast enough
+	Dropped uint64 `protobuf:"varint,7,opt,name=dropped,proto3" json:"dropped,omitempty"`
+}
+
+func (x *CaptureStats) Reset() {
+	*x = CaptureStats{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_skyegress_proto_msgTypes[4]
+		mi := &file_skyegress_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ListSessionsRequest) String() string {
+func (x *CaptureStats) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListSessionsRequest) ProtoMessage() {}
+func (*CaptureStats) ProtoMessage() {}
 
-func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_skyegress_proto_msgTypes[4]
+func (x *CaptureStats) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -312,41 +752,116 @@ func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
-func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
-	return file_skyegress_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use CaptureStats.ProtoReflect.Descriptor instead.
+func (*CaptureStats) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{3}
 }
 
-// response to listing egress sessions
-type ListSessionsResponse struct {
+func (x *CaptureStats) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *CaptureStats) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+func (x *CaptureStats) GetPackets() uint64 {
+	if x != nil {
+		return x.Packets
+	}
+	return 0
+}
+
+func (x *CaptureStats) GetBytes() uint64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *CaptureStats) GetMaxBytes() uint64 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
+}
+
+func (x *CaptureStats) GetStoppedReason() string {
+	if x != nil {
+		return x.StoppedReason
+	}
+	return ""
+}
+
+func (x *CaptureStats) GetDropped() uint64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+// represents an egress session
+type Session struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Types that are assignable to Result:
-	//
-	//	*ListSessionsResponse_Sessions
-	//	*ListSessionsResponse_Error
-	Result isListSessionsResponse_Result `protobuf_oneof:"result"`
+	Sid            string       `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	RoomName       string       `protobuf:"bytes,2,opt,name=room_name,json=roomName,proto3" json:"room_name,omitempty"`
+	TrackName      string       `protobuf:"bytes,3,opt,name=track_name,json=trackName,proto3" json:"track_name,omitempty"`
+	EgressIdentity string       `protobuf:"bytes,4,opt,name=egress_identity,json=egressIdentity,proto3" json:"egress_identity,omitempty"`
+	State          SessionState `protobuf:"varint,5,opt,name=state,proto3,enum=skyegress.SessionState" json:"state,omitempty"`
+	// identity of the participant publishing the track; empty matches any participant
+	ParticipantIdentity string `protobuf:"bytes,6,opt,name=participant_identity,json=participantIdentity,proto3" json:"participant_identity,omitempty"`
+	// identity of the participant whose track is currently being relayed
+	PublisherIdentity string `protobuf:"bytes,7,opt,name=publisher_identity,json=publisherIdentity,proto3" json:"publisher_identity,omitempty"`
+	// URLs lifecycle events for this session are delivered to, in addition to the server-wide ones
+	WebhookUrls []string      `protobuf:"bytes,8,rep,name=webhook_urls,json=webhookUrls,proto3" json:"webhook_urls,omitempty"`
+	Stats       *SessionStats `protobuf:"bytes,9,opt,name=stats,proto3" json:"stats,omitempty"`
+	// ID of the node in the cluster running the session
+	NodeId       string        `protobuf:"bytes,10,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	ActiveSource SessionSource `protobuf:"varint,11,opt,name=active_source,json=activeSource,proto3,enum=skyegress.SessionSource" json:"active_source,omitempty"`
+	// track in the same room relayed while the primary track isn't delivering packets
+	BackupTrackName           string `protobuf:"bytes,12,opt,name=backup_track_name,json=backupTrackName,proto3" json:"backup_track_name,omitempty"`
+	BackupParticipantIdentity string `protobuf:"bytes,13,opt,name=backup_participant_identity,json=backupParticipantIdentity,proto3" json:"backup_participant_identity,omitempty"`
+	// how long the primary track may go without delivering packets before the backup is relayed
+	FailoverTimeoutMs uint32  `protobuf:"varint,14,opt,name=failover_timeout_ms,json=failoverTimeoutMs,proto3" json:"failover_timeout_ms,omitempty"`
+	SeiMode           SeiMode `protobuf:"varint,15,opt,name=sei_mode,json=seiMode,proto3,enum=skyegress.SeiMode" json:"sei_mode,omitempty"`
+	// names of the processors packets pass through on their way to readers, in order
+	Processors []string `protobuf:"bytes,16,rep,name=processors,proto3" json:"processors,omitempty"`
+	// names of the sinks the session is written to besides its RTSP readers
+	Sinks []string `protobuf:"bytes,17,rep,name=sinks,proto3" json:"sinks,omitempty"`
+	// URL of the source the video is played from instead of a LiveKit room, such as file:///clip.mp4,
+	// rtsp://camera/stream or testpattern; for ingress sessions, the RTSP URL published into the room, and for publish
+	// sessions, the address of the RTSP publisher
+	Source string `protobuf:"bytes,18,opt,name=source,proto3" json:"source,omitempty"`
+	// ingress and publish sessions publish track_name into room_name, joining as egress_identity
+	Kind SessionKind `protobuf:"varint,19,opt,name=kind,proto3,enum=skyegress.SessionKind" json:"kind,omitempty"`
 }
 
-func (x *ListSessionsResponse) Reset() {
-	*x = ListSessionsResponse{}
+func (x *Session) Reset() {
+	*x = Session{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_skyegress_proto_msgTypes[5]
+		mi := &file_skyegress_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ListSessionsResponse) String() string {
+func (x *Session) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListSessionsResponse) ProtoMessage() {}
+func (*Session) ProtoMessage() {}
 
-func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_skyegress_proto_msgTypes[5]
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -357,74 +872,170 @@ func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
-func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
-	return file_skyegress_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{4}
 }
 
-func (m *ListSessionsResponse) GetResult() isListSessionsResponse_Result {
-	if m != nil {
-		return m.Result
+func (x *Session) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *Session) GetRoomName() string {
+	if x != nil {
+		return x.RoomName
+	}
+	return ""
+}
+
+func (x *Session) GetTrackName() string {
+	if x != nil {
+		return x.TrackName
+	}
+	return ""
+}
+
+func (x *Session) GetEgressIdentity() string {
+	if x != nil {
+		return x.EgressIdentity
+	}
+	return ""
+}
+
+func (x *Session) GetState() SessionState {
+	if x != nil {
+		return x.State
+	}
+	return SessionState_SESSION_STATE_STARTING
+}
+
+func (x *Session) GetParticipantIdentity() string {
+	if x != nil {
+		return x.ParticipantIdentity
+	}
+	return ""
+}
+
+func (x *Session) GetPublisherIdentity() string {
+	if x != nil {
+		return x.PublisherIdentity
+	}
+	return ""
+}
+
+func (x *Session) GetWebhookUrls() []string {
+	if x != nil {
+		return x.WebhookUrls
 	}
 	return nil
 }
 
-func (x *ListSessionsResponse) GetSessions() *Sessions {
-	if x, ok := x.GetResult().(*ListSessionsResponse_Sessions); ok {
-		return x.Sessions
+func (x *Session) GetStats() *SessionStats {
+	if x != nil {
+		return x.Stats
 	}
 	return nil
 }
 
-func (x *ListSessionsResponse) GetError() string {
-	if x, ok := x.GetResult().(*ListSessionsResponse_Error); ok {
-		return x.Error
+func (x *Session) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
 	}
 	return ""
 }
 
-type isListSessionsResponse_Result interface {
-	isListSessionsResponse_Result()
+func (x *Session) GetActiveSource() SessionSource {
+	if x != nil {
+		return x.ActiveSource
+	}
+	return SessionSource_SESSION_SOURCE_PRIMARY
 }
 
-type ListSessionsResponse_Sessions struct {
-	Sessions *Sessions `protobuf:"bytes,1,opt,name=sessions,proto3,oneof"`
+func (x *Session) GetBackupTrackName() string {
+	if x != nil {
+		return x.BackupTrackName
+	}
+	return ""
 }
 
-type ListSessionsResponse_Error struct {
-	Error string `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+func (x *Session) GetBackupParticipantIdentity() string {
+	if x != nil {
+		return x.BackupParticipantIdentity
+	}
+	return ""
 }
 
-func (*ListSessionsResponse_Sessions) isListSessionsResponse_Result() {}
+func (x *Session) GetFailoverTimeoutMs() uint32 {
+	if x != nil {
+		return x.FailoverTimeoutMs
+	}
+	return 0
+}
 
-func (*ListSessionsResponse_Error) isListSessionsResponse_Result() {}
+func (x *Session) GetSeiMode() SeiMode {
+	if x != nil {
+		return x.SeiMode
+	}
+	return SeiMode_SEI_MODE_OFF
+}
 
-// request to stop an egress session
-type StopSessionRequest struct {
+func (x *Session) GetProcessors() []string {
+	if x != nil {
+		return x.Processors
+	}
+	return nil
+}
+
+func (x *Session) GetSinks() []string {
+	if x != nil {
+		return x.Sinks
+	}
+	return nil
+}
+
+func (x *Session) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Session) GetKind() SessionKind {
+	if x != nil {
+		return x.Kind
+	}
+	return SessionKind_SESSION_KIND_EGRESS
+}
+
+// represents a list of egress sessions
+type Sessions struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Sid string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	Sessions []*Session `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
 }
 
-func (x *StopSessionRequest) Reset() {
-	*x = StopSessionRequest{}
+func (x *Sessions) Reset() {
+	*x = Sessions{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_skyegress_proto_msgTypes[6]
+		mi := &file_skyegress_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *StopSessionRequest) String() string {
+func (x *Sessions) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StopSessionRequest) ProtoMessage() {}
+func (*Sessions) ProtoMessage() {}
 
-func (x *StopSessionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_skyegress_proto_msgTypes[6]
+func (x *Sessions) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -435,48 +1046,70 @@ func (x *StopSessionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StopSessionRequest.ProtoReflect.Descriptor instead.
-func (*StopSessionRequest) Descriptor() ([]byte, []int) {
-	return file_skyegress_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use Sessions.ProtoReflect.Descriptor instead.
+func (*Sessions) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *StopSessionRequest) GetSid() string {
+func (x *Sessions) GetSessions() []*Session {
 	if x != nil {
-		return x.Sid
+		return x.Sessions
 	}
-	return ""
+	return nil
 }
 
-// response to stopping an egress session
-type StopSessionResponse struct {
+// request to start an egress session
+type StartSessionRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Types that are assignable to Result:
-	//
-	//	*StopSessionResponse_Session
-	//	*StopSessionResponse_Error
-	Result isStopSessionResponse_Result `protobuf_oneof:"result"`
+	RoomName  string `protobuf:"bytes,1,opt,name=room_name,json=roomName,proto3" json:"room_name,omitempty"`
+	TrackName string `protobuf:"bytes,2,opt,name=track_name,json=trackName,proto3" json:"track_name,omitempty"`
+	// RTSP path to serve the session on; defaults to <room_name>/<track_name>
+	Path string `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	// only egress the track when published by this participant
+	ParticipantIdentity string `protobuf:"bytes,4,opt,name=participant_identity,json=participantIdentity,proto3" json:"participant_identity,omitempty"`
+	// URLs to deliver lifecycle events for this session to
+	WebhookUrls []string `protobuf:"bytes,5,rep,name=webhook_urls,json=webhookUrls,proto3" json:"webhook_urls,omitempty"`
+	// track in the same room to relay while the primary track isn't delivering packets
+	BackupTrackName string `protobuf:"bytes,6,opt,name=backup_track_name,json=backupTrackName,proto3" json:"backup_track_name,omitempty"`
+	// only use the backup track when published by this participant
+	BackupParticipantIdentity string `protobuf:"bytes,7,opt,name=backup_participant_identity,json=backupParticipantIdentity,proto3" json:"backup_participant_identity,omitempty"`
+	// how long the primary track may go without delivering packets before the backup is relayed; defaults to 3s
+	FailoverTimeoutMs uint32 `protobuf:"varint,8,opt,name=failover_timeout_ms,json=failoverTimeoutMs,proto3" json:"failover_timeout_ms,omitempty"`
+	// frames to insert SEI NAL units with their capture time and the session's details ahead of
+	SeiMode SeiMode `protobuf:"varint,9,opt,name=sei_mode,json=seiMode,proto3,enum=skyegress.SeiMode" json:"sei_mode,omitempty"`
+	// names of processors to pass packets through on their way to readers, in order
+	Processors []string `protobuf:"bytes,10,rep,name=processors,proto3" json:"processors,omitempty"`
+	// names of sinks to write the session to besides its RTSP readers
+	Sinks []string `protobuf:"bytes,11,rep,name=sinks,proto3" json:"sinks,omitempty"`
+	// URL of a source to play instead of a LiveKit room, such as file:///clip.mp4, rtsp://camera/stream or testpattern;
+	// room_name and track_name aren't needed with one, but path is
+	Source string `protobuf:"bytes,12,opt,name=source,proto3" json:"source,omitempty"`
+	// ingress sessions pull source, an rtsp:// or rtsps:// URL, and publish its H264 video and any Opus audio into
+	// room_name: the video as track_name (defaulting to camera) and the audio as <track_name>-audio, joining as
+	// participant_identity (defaulting to skyegress-<sid>). Publish sessions are only started by RTSP publishers.
+	Kind SessionKind `protobuf:"varint,13,opt,name=kind,proto3,enum=skyegress.SessionKind" json:"kind,omitempty"`
 }
 
-func (x *StopSessionResponse) Reset() {
-	*x = StopSessionResponse{}
+func (x *StartSessionRequest) Reset() {
+	*x = StartSessionRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_skyegress_proto_msgTypes[7]
+		mi := &file_skyegress_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *StopSessionResponse) String() string {
+func (x *StartSessionRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StopSessionResponse) ProtoMessage() {}
+func (*StartSessionRequest) ProtoMessage() {}
 
-func (x *StopSessionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_skyegress_proto_msgTypes[7]
+func (x *StartSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -487,98 +1120,2252 @@ func (x *StopSessionResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StopSessionResponse.ProtoReflect.Descriptor instead.
-func (*StopSessionResponse) Descriptor() ([]byte, []int) {
-	return file_skyegress_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use StartSessionRequest.ProtoReflect.Descriptor instead.
+func (*StartSessionRequest) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StartSessionRequest) GetRoomName() string {
+	if x != nil {
+		return x.RoomName
+	}
+	return ""
+}
+
+func (x *StartSessionRequest) GetTrackName() string {
+	if x != nil {
+		return x.TrackName
+	}
+	return ""
+}
+
+func (x *StartSessionRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *StartSessionRequest) GetParticipantIdentity() string {
+	if x != nil {
+		return x.ParticipantIdentity
+	}
+	return ""
+}
+
+func (x *StartSessionRequest) GetWebhookUrls() []string {
+	if x != nil {
+		return x.WebhookUrls
+	}
+	return nil
+}
+
+func (x *StartSessionRequest) GetBackupTrackName() string {
+	if x != nil {
+		return x.BackupTrackName
+	}
+	return ""
+}
+
+func (x *StartSessionRequest) GetBackupParticipantIdentity() string {
+	if x != nil {
+		return x.BackupParticipantIdentity
+	}
+	return ""
+}
+
+func (x *StartSessionRequest) GetFailoverTimeoutMs() uint32 {
+	if x != nil {
+		return x.FailoverTimeoutMs
+	}
+	return 0
+}
+
+func (x *StartSessionRequest) GetSeiMode() SeiMode {
+	if x != nil {
+		return x.SeiMode
+	}
+	return SeiMode_SEI_MODE_OFF
+}
+
+func (x *StartSessionRequest) GetProcessors() []string {
+	if x != nil {
+		return x.Processors
+	}
+	return nil
+}
+
+func (x *StartSessionRequest) GetSinks() []string {
+	if x != nil {
+		return x.Sinks
+	}
+	return nil
+}
+
+func (x *StartSessionRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *StartSessionRequest) GetKind() SessionKind {
+	if x != nil {
+		return x.Kind
+	}
+	return SessionKind_SESSION_KIND_EGRESS
+}
+
+// response to starting an egress session
+type StartSessionResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Result:
+	//
+	//	*StartSessionResponse_Session
+	//	*StartSessionResponse_Error
+	Result isStartSessionResponse_Result `protobuf_oneof:"result"`
+}
+
+func (x *StartSessionResponse) Reset() {
+	*x = StartSessionResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StartSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartSessionResponse) ProtoMessage() {}
+
+func (x *StartSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartSessionResponse.ProtoReflect.Descriptor instead.
+func (*StartSessionResponse) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{7}
+}
+
+func (m *StartSessionResponse) GetResult() isStartSessionResponse_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (x *StartSessionResponse) GetSession() *Session {
+	if x, ok := x.GetResult().(*StartSessionResponse_Session); ok {
+		return x.Session
+	}
+	return nil
+}
+
+func (x *StartSessionResponse) GetError() string {
+	if x, ok := x.GetResult().(*StartSessionResponse_Error); ok {
+		return x.Error
+	}
+	return ""
+}
+
+type isStartSessionResponse_Result interface {
+	isStartSessionResponse_Result()
+}
+
+type StartSessionResponse_Session struct {
+	Session *Session `protobuf:"bytes,1,opt,name=session,proto3,oneof"`
+}
+
+type StartSessionResponse_Error struct {
+	Error string `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*StartSessionResponse_Session) isStartSessionResponse_Result() {}
+
+func (*StartSessionResponse_Error) isStartSessionResponse_Result() {}
+
+// a change in the lifecycle of an egress session
+type SessionEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type    SessionEventType `protobuf:"varint,1,opt,name=type,proto3,enum=skyegress.SessionEventType" json:"type,omitempty"`
+	Session *Session         `protobuf:"bytes,2,opt,name=session,proto3" json:"session,omitempty"`
+	Reason  string           `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	// unix time in milliseconds
+	Timestamp int64 `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+}
+
+func (x *SessionEvent) Reset() {
+	*x = SessionEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SessionEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionEvent) ProtoMessage() {}
+
+func (x *SessionEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionEvent.ProtoReflect.Descriptor instead.
+func (*SessionEvent) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SessionEvent) GetType() SessionEventType {
+	if x != nil {
+		return x.Type
+	}
+	return SessionEventType_SESSION_EVENT_UNSPECIFIED
+}
+
+func (x *SessionEvent) GetSession() *Session {
+	if x != nil {
+		return x.Session
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *SessionEvent) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+// request to switch an egress session to another room or track, keeping its RTSP path and readers
+type RetargetSessionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sid       string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	RoomName  string `protobuf:"bytes,2,opt,name=room_name,json=roomName,proto3" json:"room_name,omitempty"`
+	TrackName string `protobuf:"bytes,3,opt,name=track_name,json=trackName,proto3" json:"track_name,omitempty"`
+	// only egress the track when published by this participant
+	ParticipantIdentity string `protobuf:"bytes,4,opt,name=participant_identity,json=participantIdentity,proto3" json:"participant_identity,omitempty"`
+}
+
+func (x *RetargetSessionRequest) Reset() {
+	*x = RetargetSessionRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RetargetSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetargetSessionRequest) ProtoMessage() {}
+
+func (x *RetargetSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetargetSessionRequest.ProtoReflect.Descriptor instead.
+func (*RetargetSessionRequest) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RetargetSessionRequest) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *RetargetSessionRequest) GetRoomName() string {
+	if x != nil {
+		return x.RoomName
+	}
+	return ""
+}
+
+func (x *RetargetSessionRequest) GetTrackName() string {
+	if x != nil {
+		return x.TrackName
+	}
+	return ""
+}
+
+func (x *RetargetSessionRequest) GetParticipantIdentity() string {
+	if x != nil {
+		return x.ParticipantIdentity
+	}
+	return ""
+}
+
+// response to retargeting an egress session
+type RetargetSessionResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Result:
+	//
+	//	*RetargetSessionResponse_Session
+	//	*RetargetSessionResponse_Error
+	Result isRetargetSessionResponse_Result `protobuf_oneof:"result"`
+}
+
+func (x *RetargetSessionResponse) Reset() {
+	*x = RetargetSessionResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RetargetSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetargetSessionResponse) ProtoMessage() {}
+
+func (x *RetargetSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetargetSessionResponse.ProtoReflect.Descriptor instead.
+func (*RetargetSessionResponse) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{10}
+}
+
+func (m *RetargetSessionResponse) GetResult() isRetargetSessionResponse_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (x *RetargetSessionResponse) GetSession() *Session {
+	if x, ok := x.GetResult().(*RetargetSessionResponse_Session); ok {
+		return x.Session
+	}
+	return nil
+}
+
+func (x *RetargetSessionResponse) GetError() string {
+	if x, ok := x.GetResult().(*RetargetSessionResponse_Error); ok {
+		return x.Error
+	}
+	return ""
+}
+
+type isRetargetSessionResponse_Result interface {
+	isRetargetSessionResponse_Result()
+}
+
+type RetargetSessionResponse_Session struct {
+	Session *Session `protobuf:"bytes,1,opt,name=session,proto3,oneof"`
+}
+
+type RetargetSessionResponse_Error struct {
+	Error string `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*RetargetSessionResponse_Session) isRetargetSessionResponse_Result() {}
+
+func (*RetargetSessionResponse_Error) isRetargetSessionResponse_Result() {}
+
+// request to list egress sessions
+type ListSessionsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListSessionsRequest) Reset() {
+	*x = ListSessionsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListSessionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsRequest) ProtoMessage() {}
+
+func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{11}
+}
+
+// response to listing egress sessions
+type ListSessionsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Result:
+	//
+	//	*ListSessionsResponse_Sessions
+	//	*ListSessionsResponse_Error
+	Result isListSessionsResponse_Result `protobuf_oneof:"result"`
+}
+
+func (x *ListSessionsResponse) Reset() {
+	*x = ListSessionsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListSessionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsResponse) ProtoMessage() {}
+
+func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{12}
+}
+
+func (m *ListSessionsResponse) GetResult() isListSessionsResponse_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (x *ListSessionsResponse) GetSessions() *Sessions {
+	if x, ok := x.GetResult().(*ListSessionsResponse_Sessions); ok {
+		return x.Sessions
+	}
+	return nil
+}
+
+func (x *ListSessionsResponse) GetError() string {
+	if x, ok := x.GetResult().(*ListSessionsResponse_Error); ok {
+		return x.Error
+	}
+	return ""
+}
+
+type isListSessionsResponse_Result interface {
+	isListSessionsResponse_Result()
+}
+
+type ListSessionsResponse_Sessions struct {
+	Sessions *Sessions `protobuf:"bytes,1,opt,name=sessions,proto3,oneof"`
+}
+
+type ListSessionsResponse_Error struct {
+	Error string `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*ListSessionsResponse_Sessions) isListSessionsResponse_Result() {}
+
+func (*ListSessionsResponse_Error) isListSessionsResponse_Result() {}
+
+// request to stop an egress session
+type StopSessionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sid string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	// why the session is being stopped, as its STOPPED event reports; defaults to being stopped through the API
+	Reason string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *StopSessionRequest) Reset() {
+	*x = StopSessionRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StopSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopSessionRequest) ProtoMessage() {}
+
+func (x *StopSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopSessionRequest.ProtoReflect.Descriptor instead.
+func (*StopSessionRequest) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *StopSessionRequest) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *StopSessionRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// response to stopping an egress session
+type StopSessionResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Result:
+	//
+	//	*StopSessionResponse_Session
+	//	*StopSessionResponse_Error
+	Result isStopSessionResponse_Result `protobuf_oneof:"result"`
+}
+
+func (x *StopSessionResponse) Reset() {
+	*x = StopSessionResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StopSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopSessionResponse) ProtoMessage() {}
+
+func (x *StopSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopSessionResponse.ProtoReflect.Descriptor instead.
+func (*StopSessionResponse) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{14}
+}
+
+func (m *StopSessionResponse) GetResult() isStopSessionResponse_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (x *StopSessionResponse) GetSession() *Session {
+	if x, ok := x.GetResult().(*StopSessionResponse_Session); ok {
+		return x.Session
+	}
+	return nil
+}
+
+func (x *StopSessionResponse) GetError() string {
+	if x, ok := x.GetResult().(*StopSessionResponse_Error); ok {
+		return x.Error
+	}
+	return ""
+}
+
+type isStopSessionResponse_Result interface {
+	isStopSessionResponse_Result()
+}
+
+type StopSessionResponse_Session struct {
+	Session *Session `protobuf:"bytes,1,opt,name=session,proto3,oneof"`
+}
+
+type StopSessionResponse_Error struct {
+	Error string `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*StopSessionResponse_Session) isStopSessionResponse_Result() {}
+
+func (*StopSessionResponse_Error) isStopSessionResponse_Result() {}
+
+// represents a list of RTSP readers
+type Viewers struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Viewers []*ReaderStats `protobuf:"bytes,1,rep,name=viewers,proto3" json:"viewers,omitempty"`
+}
+
+func (x *Viewers) Reset() {
+	*x = Viewers{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Viewers) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Viewers) ProtoMessage() {}
+
+func (x *Viewers) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Viewers.ProtoReflect.Descriptor instead.
+func (*Viewers) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Viewers) GetViewers() []*ReaderStats {
+	if x != nil {
+		return x.Viewers
+	}
+	return nil
+}
+
+// request to list the RTSP readers of an egress session
+type ListViewersRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sid string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+}
+
+func (x *ListViewersRequest) Reset() {
+	*x = ListViewersRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListViewersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListViewersRequest) ProtoMessage() {}
+
+func (x *ListViewersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListViewersRequest.ProtoReflect.Descriptor instead.
+func (*ListViewersRequest) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListViewersRequest) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+// response to listing the RTSP readers of an egress session
+type ListViewersResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Result:
+	//
+	//	*ListViewersResponse_Viewers
+	//	*ListViewersResponse_Error
+	Result isListViewersResponse_Result `protobuf_oneof:"result"`
+}
+
+func (x *ListViewersResponse) Reset() {
+	*x = ListViewersResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListViewersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListViewersResponse) ProtoMessage() {}
+
+func (x *ListViewersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListViewersResponse.ProtoReflect.Descriptor instead.
+func (*ListViewersResponse) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{17}
+}
+
+func (m *ListViewersResponse) GetResult() isListViewersResponse_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (x *ListViewersResponse) GetViewers() *Viewers {
+	if x, ok := x.GetResult().(*ListViewersResponse_Viewers); ok {
+		return x.Viewers
+	}
+	return nil
+}
+
+func (x *ListViewersResponse) GetError() string {
+	if x, ok := x.GetResult().(*ListViewersResponse_Error); ok {
+		return x.Error
+	}
+	return ""
+}
+
+type isListViewersResponse_Result interface {
+	isListViewersResponse_Result()
+}
+
+type ListViewersResponse_Viewers struct {
+	Viewers *Viewers `protobuf:"bytes,1,opt,name=viewers,proto3,oneof"`
+}
+
+type ListViewersResponse_Error struct {
+	Error string `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*ListViewersResponse_Viewers) isListViewersResponse_Result() {}
+
+func (*ListViewersResponse_Error) isListViewersResponse_Result() {}
+
+// carried in user data unregistered SEI NAL units ahead of frames sent to readers, identified by the UUID
+// 4efa9cf4-a8b1-495f-84ea-9cb1be7feb8b
+type FrameInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// wall-clock time the frame was captured, in unix nanoseconds
+	CaptureTimeNs int64  `protobuf:"varint,1,opt,name=capture_time_ns,json=captureTimeNs,proto3" json:"capture_time_ns,omitempty"`
+	Sid           string `protobuf:"bytes,2,opt,name=sid,proto3" json:"sid,omitempty"`
+	// the participant whose track the frame is from
+	PublisherIdentity string `protobuf:"bytes,3,opt,name=publisher_identity,json=publisherIdentity,proto3" json:"publisher_identity,omitempty"`
+	PublisherMetadata string `protobuf:"bytes,4,opt,name=publisher_metadata,json=publisherMetadata,proto3" json:"publisher_metadata,omitempty"`
+	// frames sent to readers before this one
+	FrameNumber uint64 `protobuf:"varint,5,opt,name=frame_number,json=frameNumber,proto3" json:"frame_number,omitempty"`
+}
+
+func (x *FrameInfo) Reset() {
+	*x = FrameInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FrameInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FrameInfo) ProtoMessage() {}
+
+func (x *FrameInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FrameInfo.ProtoReflect.Descriptor instead.
+func (*FrameInfo) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *FrameInfo) GetCaptureTimeNs() int64 {
+	if x != nil {
+		return x.CaptureTimeNs
+	}
+	return 0
+}
+
+func (x *FrameInfo) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *FrameInfo) GetPublisherIdentity() string {
+	if x != nil {
+		return x.PublisherIdentity
+	}
+	return ""
+}
+
+func (x *FrameInfo) GetPublisherMetadata() string {
+	if x != nil {
+		return x.PublisherMetadata
+	}
+	return ""
+}
+
+func (x *FrameInfo) GetFrameNumber() uint64 {
+	if x != nil {
+		return x.FrameNumber
+	}
+	return 0
+}
+
+// carried in user data unregistered SEI NAL units ahead of every frame of a testpattern source, identified by the UUID
+// 9c3e8f1a-5d27-4b61-a0f4-7e2b6c91d350. Readers can check from it that no frames were lost or delayed on the way.
+type TestPatternFrame struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// frames the source played before this one, counting on across loops of the pattern
+	FrameNumber uint64 `protobuf:"varint,1,opt,name=frame_number,json=frameNumber,proto3" json:"frame_number,omitempty"`
+	// wall-clock time the frame was due to be shown, in unix nanoseconds
+	CaptureTimeNs int64 `protobuf:"varint,2,opt,name=capture_time_ns,json=captureTimeNs,proto3" json:"capture_time_ns,omitempty"`
+	// the variant playing, e.g. 1080p30
+	Variant   string `protobuf:"bytes,3,opt,name=variant,proto3" json:"variant,omitempty"`
+	Width     uint32 `protobuf:"varint,4,opt,name=width,proto3" json:"width,omitempty"`
+	Height    uint32 `protobuf:"varint,5,opt,name=height,proto3" json:"height,omitempty"`
+	FrameRate uint32 `protobuf:"varint,6,opt,name=frame_rate,json=frameRate,proto3" json:"frame_rate,omitempty"`
+}
+
+func (x *TestPatternFrame) Reset() {
+	*x = TestPatternFrame{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TestPatternFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestPatternFrame) ProtoMessage() {}
+
+func (x *TestPatternFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestPatternFrame.ProtoReflect.Descriptor instead.
+func (*TestPatternFrame) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *TestPatternFrame) GetFrameNumber() uint64 {
+	if x != nil {
+		return x.FrameNumber
+	}
+	return 0
+}
+
+func (x *TestPatternFrame) GetCaptureTimeNs() int64 {
+	if x != nil {
+		return x.CaptureTimeNs
+	}
+	return 0
+}
+
+func (x *TestPatternFrame) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
+func (x *TestPatternFrame) GetWidth() uint32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *TestPatternFrame) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *TestPatternFrame) GetFrameRate() uint32 {
+	if x != nil {
+		return x.FrameRate
+	}
+	return 0
+}
+
+// maps the RTP timestamps sent to a session's readers to the wall-clock time their frames were captured
+type ClockMapping struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// RTP timestamp and SSRC of the most recent packet sent to readers
+	RtpTimestamp uint32 `protobuf:"varint,1,opt,name=rtp_timestamp,json=rtpTimestamp,proto3" json:"rtp_timestamp,omitempty"`
+	Ssrc         uint32 `protobuf:"varint,2,opt,name=ssrc,proto3" json:"ssrc,omitempty"`
+	ClockRate    uint32 `protobuf:"varint,3,opt,name=clock_rate,json=clockRate,proto3" json:"clock_rate,omitempty"`
+	// capture time of the frame with that timestamp, in unix nanoseconds
+	WallClockNs int64 `protobuf:"varint,4,opt,name=wall_clock_ns,json=wallClockNs,proto3" json:"wall_clock_ns,omitempty"`
+	// whether the capture time comes from the publisher's RTCP sender reports; otherwise it's when the packet arrived,
+	// unless from_source is set
+	FromSenderReport bool `protobuf:"varint,5,opt,name=from_sender_report,json=fromSenderReport,proto3" json:"from_sender_report,omitempty"`
+	// when the last sender report was received from the publisher; 0 before the first
+	SenderReportAt int64 `protobuf:"varint,6,opt,name=sender_report_at,json=senderReportAt,proto3" json:"sender_report_at,omitempty"`
+	// whether the capture time is the one a source replaying or generating video gave the frame, without RTCP
+	FromSource bool `protobuf:"varint,7,opt,name=from_source,json=fromSource,proto3" json:"from_source,omitempty"`
+}
+
+func (x *ClockMapping) Reset() {
+	*x = ClockMapping{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClockMapping) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClockMapping) ProtoMessage() {}
+
+func (x *ClockMapping) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClockMapping.ProtoReflect.Descriptor instead.
+func (*ClockMapping) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ClockMapping) GetRtpTimestamp() uint32 {
+	if x != nil {
+		return x.RtpTimestamp
+	}
+	return 0
+}
+
+func (x *ClockMapping) GetSsrc() uint32 {
+	if x != nil {
+		return x.Ssrc
+	}
+	return 0
+}
+
+func (x *ClockMapping) GetClockRate() uint32 {
+	if x != nil {
+		return x.ClockRate
+	}
+	return 0
+}
+
+func (x *ClockMapping) GetWallClockNs() int64 {
+	if x != nil {
+		return x.WallClockNs
+	}
+	return 0
+}
+
+func (x *ClockMapping) GetFromSenderReport() bool {
+	if x != nil {
+		return x.FromSenderReport
+	}
+	return false
+}
+
+func (x *ClockMapping) GetSenderReportAt() int64 {
+	if x != nil {
+		return x.SenderReportAt
+	}
+	return 0
+}
+
+func (x *ClockMapping) GetFromSource() bool {
+	if x != nil {
+		return x.FromSource
+	}
+	return false
+}
+
+// request for the wall-clock mapping of an egress session
+type GetClockRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sid string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+}
+
+func (x *GetClockRequest) Reset() {
+	*x = GetClockRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetClockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetClockRequest) ProtoMessage() {}
+
+func (x *GetClockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetClockRequest.ProtoReflect.Descriptor instead.
+func (*GetClockRequest) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetClockRequest) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+// response to getting the wall-clock mapping of an egress session
+type GetClockResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Result:
+	//
+	//	*GetClockResponse_Clock
+	//	*GetClockResponse_Error
+	Result isGetClockResponse_Result `protobuf_oneof:"result"`
+}
+
+func (x *GetClockResponse) Reset() {
+	*x = GetClockResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetClockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetClockResponse) ProtoMessage() {}
+
+func (x *GetClockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetClockResponse.ProtoReflect.Descriptor instead.
+func (*GetClockResponse) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{22}
+}
+
+func (m *GetClockResponse) GetResult() isGetClockResponse_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (x *GetClockResponse) GetClock() *ClockMapping {
+	if x, ok := x.GetResult().(*GetClockResponse_Clock); ok {
+		return x.Clock
+	}
+	return nil
+}
+
+func (x *GetClockResponse) GetError() string {
+	if x, ok := x.GetResult().(*GetClockResponse_Error); ok {
+		return x.Error
+	}
+	return ""
+}
+
+type isGetClockResponse_Result interface {
+	isGetClockResponse_Result()
+}
+
+type GetClockResponse_Clock struct {
+	Clock *ClockMapping `protobuf:"bytes,1,opt,name=clock,proto3,oneof"`
+}
+
+type GetClockResponse_Error struct {
+	Error string `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*GetClockResponse_Clock) isGetClockResponse_Result() {}
+
+func (*GetClockResponse_Error) isGetClockResponse_Result() {}
+
+// request to start or stop capturing the packets an egress session receives
+type CaptureSessionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sid string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	// starts a new capture if set, otherwise stops the current one
+	Enabled bool `protobuf:"varint,2,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	// size the capture file is stopped at, capped at the server's limit; 0 uses the limit
+	MaxBytes uint64 `protobuf:"varint,3,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`
+}
+
+func (x *CaptureSessionRequest) Reset() {
+	*x = CaptureSessionRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CaptureSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CaptureSessionRequest) ProtoMessage() {}
+
+func (x *CaptureSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CaptureSessionRequest.ProtoReflect.Descriptor instead.
+func (*CaptureSessionRequest) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CaptureSessionRequest) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *CaptureSessionRequest) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *CaptureSessionRequest) GetMaxBytes() uint64 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
+}
+
+// response to starting or stopping a capture
+type CaptureSessionResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Result:
+	//
+	//	*CaptureSessionResponse_Capture
+	//	*CaptureSessionResponse_Error
+	Result isCaptureSessionResponse_Result `protobuf_oneof:"result"`
+}
+
+func (x *CaptureSessionResponse) Reset() {
+	*x = CaptureSessionResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CaptureSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CaptureSessionResponse) ProtoMessage() {}
+
+func (x *CaptureSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CaptureSessionResponse.ProtoReflect.Descriptor instead.
+func (*CaptureSessionResponse) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{24}
+}
+
+func (m *CaptureSessionResponse) GetResult() isCaptureSessionResponse_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (x *CaptureSessionResponse) GetCapture() *CaptureStats {
+	if x, ok := x.GetResult().(*CaptureSessionResponse_Capture); ok {
+		return x.Capture
+	}
+	return nil
+}
+
+func (x *CaptureSessionResponse) GetError() string {
+	if x, ok := x.GetResult().(*CaptureSessionResponse_Error); ok {
+		return x.Error
+	}
+	return ""
+}
+
+type isCaptureSessionResponse_Result interface {
+	isCaptureSessionResponse_Result()
+}
+
+type CaptureSessionResponse_Capture struct {
+	Capture *CaptureStats `protobuf:"bytes,1,opt,name=capture,proto3,oneof"`
+}
+
+type CaptureSessionResponse_Error struct {
+	Error string `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*CaptureSessionResponse_Capture) isCaptureSessionResponse_Result() {}
+
+func (*CaptureSessionResponse_Error) isCaptureSessionResponse_Result() {}
+
+// request to disconnect an RTSP reader from an egress session
+type KickViewerRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sid      string `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	ViewerId string `protobuf:"bytes,2,opt,name=viewer_id,json=viewerId,proto3" json:"viewer_id,omitempty"`
+}
+
+func (x *KickViewerRequest) Reset() {
+	*x = KickViewerRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KickViewerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KickViewerRequest) ProtoMessage() {}
+
+func (x *KickViewerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-func (m *StopSessionResponse) GetResult() isStopSessionResponse_Result {
+// Deprecated: Use KickViewerRequest.ProtoReflect.Descriptor instead.
+func (*KickViewerRequest) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *KickViewerRequest) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *KickViewerRequest) GetViewerId() string {
+	if x != nil {
+		return x.ViewerId
+	}
+	return ""
+}
+
+// response to disconnecting an RTSP reader
+type KickViewerResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Result:
+	//
+	//	*KickViewerResponse_Viewer
+	//	*KickViewerResponse_Error
+	Result isKickViewerResponse_Result `protobuf_oneof:"result"`
+}
+
+func (x *KickViewerResponse) Reset() {
+	*x = KickViewerResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KickViewerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KickViewerResponse) ProtoMessage() {}
+
+func (x *KickViewerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KickViewerResponse.ProtoReflect.Descriptor instead.
+func (*KickViewerResponse) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{26}
+}
+
+func (m *KickViewerResponse) GetResult() isKickViewerResponse_Result {
 	if m != nil {
 		return m.Result
 	}
 	return nil
 }
 
-func (x *StopSessionResponse) GetSession() *Session {
-	if x, ok := x.GetResult().(*StopSessionResponse_Session); ok {
-		return x.Session
+func (x *KickViewerResponse) GetViewer() *ReaderStats {
+	if x, ok := x.GetResult().(*KickViewerResponse_Viewer); ok {
+		return x.Viewer
 	}
 	return nil
 }
 
-func (x *StopSessionResponse) GetError() string {
-	if x, ok := x.GetResult().(*StopSessionResponse_Error); ok {
+func (x *KickViewerResponse) GetError() string {
+	if x, ok := x.GetResult().(*KickViewerResponse_Error); ok {
 		return x.Error
 	}
 	return ""
 }
 
-type isStopSessionResponse_Result interface {
-	isStopSessionResponse_Result()
+type isKickViewerResponse_Result interface {
+	isKickViewerResponse_Result()
 }
 
-type StopSessionResponse_Session struct {
-	Session *Session `protobuf:"bytes,1,opt,name=session,proto3,oneof"`
+type KickViewerResponse_Viewer struct {
+	Viewer *ReaderStats `protobuf:"bytes,1,opt,name=viewer,proto3,oneof"`
 }
 
-type StopSessionResponse_Error struct {
+type KickViewerResponse_Error struct {
 	Error string `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
 }
 
-func (*StopSessionResponse_Session) isStopSessionResponse_Result() {}
+func (*KickViewerResponse_Viewer) isKickViewerResponse_Result() {}
 
-func (*StopSessionResponse_Error) isStopSessionResponse_Result() {}
+func (*KickViewerResponse_Error) isKickViewerResponse_Result() {}
+
+// drain state of a skyegress node
+type NodeStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Draining bool `protobuf:"varint,1,opt,name=draining,proto3" json:"draining,omitempty"`
+	// unix time in milliseconds after which remaining sessions are stopped; 0 when not draining
+	DrainDeadline int64  `protobuf:"varint,2,opt,name=drain_deadline,json=drainDeadline,proto3" json:"drain_deadline,omitempty"`
+	Sessions      uint32 `protobuf:"varint,3,opt,name=sessions,proto3" json:"sessions,omitempty"`
+}
+
+func (x *NodeStatus) Reset() {
+	*x = NodeStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NodeStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeStatus) ProtoMessage() {}
+
+func (x *NodeStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeStatus.ProtoReflect.Descriptor instead.
+func (*NodeStatus) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *NodeStatus) GetDraining() bool {
+	if x != nil {
+		return x.Draining
+	}
+	return false
+}
+
+func (x *NodeStatus) GetDrainDeadline() int64 {
+	if x != nil {
+		return x.DrainDeadline
+	}
+	return 0
+}
+
+func (x *NodeStatus) GetSessions() uint32 {
+	if x != nil {
+		return x.Sessions
+	}
+	return 0
+}
+
+// request to drain a node: refuse new sessions, and shut down once the existing ones end
+type DrainNodeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// how long existing sessions may keep running; 0 uses the server's default
+	TimeoutMs int64 `protobuf:"varint,1,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
+}
+
+func (x *DrainNodeRequest) Reset() {
+	*x = DrainNodeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[28]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DrainNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DrainNodeRequest) ProtoMessage() {}
+
+func (x *DrainNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[28]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DrainNodeRequest.ProtoReflect.Descriptor instead.
+func (*DrainNodeRequest) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *DrainNodeRequest) GetTimeoutMs() int64 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
+// response to draining a node
+type DrainNodeResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Result:
+	//
+	//	*DrainNodeResponse_Status
+	//	*DrainNodeResponse_Error
+	Result isDrainNodeResponse_Result `protobuf_oneof:"result"`
+}
+
+func (x *DrainNodeResponse) Reset() {
+	*x = DrainNodeResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[29]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DrainNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DrainNodeResponse) ProtoMessage() {}
+
+func (x *DrainNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[29]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DrainNodeResponse.ProtoReflect.Descriptor instead.
+func (*DrainNodeResponse) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{29}
+}
+
+func (m *DrainNodeResponse) GetResult() isDrainNodeResponse_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (x *DrainNodeResponse) GetStatus() *NodeStatus {
+	if x, ok := x.GetResult().(*DrainNodeResponse_Status); ok {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *DrainNodeResponse) GetError() string {
+	if x, ok := x.GetResult().(*DrainNodeResponse_Error); ok {
+		return x.Error
+	}
+	return ""
+}
+
+type isDrainNodeResponse_Result interface {
+	isDrainNodeResponse_Result()
+}
+
+type DrainNodeResponse_Status struct {
+	Status *NodeStatus `protobuf:"bytes,1,opt,name=status,proto3,oneof"`
+}
+
+type DrainNodeResponse_Error struct {
+	Error string `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*DrainNodeResponse_Status) isDrainNodeResponse_Result() {}
+
+func (*DrainNodeResponse_Error) isDrainNodeResponse_Result() {}
+
+// a skyegress node in a cluster, and its load
+type NodeInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// base URL of the node's HTTP API
+	Url      string `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Sessions uint32 `protobuf:"varint,3,opt,name=sessions,proto3" json:"sessions,omitempty"`
+	Readers  uint32 `protobuf:"varint,4,opt,name=readers,proto3" json:"readers,omitempty"`
+	// bits per second sent to RTSP readers
+	Bitrate    uint64 `protobuf:"varint,5,opt,name=bitrate,proto3" json:"bitrate,omitempty"`
+	Draining   bool   `protobuf:"varint,6,opt,name=draining,proto3" json:"draining,omitempty"`
+	AtCapacity bool   `protobuf:"varint,7,opt,name=at_capacity,json=atCapacity,proto3" json:"at_capacity,omitempty"`
+	// unix time in milliseconds
+	UpdatedAt int64 `protobuf:"varint,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// base URL RTSP readers reach the node at
+	RtspUrl string `protobuf:"bytes,9,opt,name=rtsp_url,json=rtspUrl,proto3" json:"rtsp_url,omitempty"`
+}
+
+func (x *NodeInfo) Reset() {
+	*x = NodeInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_skyegress_proto_msgTypes[30]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NodeInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeInfo) ProtoMessage() {}
+
+func (x *NodeInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_skyegress_proto_msgTypes[30]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeInfo.ProtoReflect.Descriptor instead.
+func (*NodeInfo) Descriptor() ([]byte, []int) {
+	return file_skyegress_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *NodeInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *NodeInfo) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *NodeInfo) GetSessions() uint32 {
+	if x != nil {
+		return x.Sessions
+	}
+	return 0
+}
+
+func (x *NodeInfo) GetReaders() uint32 {
+	if x != nil {
+		return x.Readers
+	}
+	return 0
+}
+
+func (x *NodeInfo) GetBitrate() uint64 {
+	if x != nil {
+		return x.Bitrate
+	}
+	return 0
+}
+
+func (x *NodeInfo) GetDraining() bool {
+	if x != nil {
+		return x.Draining
+	}
+	return false
+}
+
+func (x *NodeInfo) GetAtCapacity() bool {
+	if x != nil {
+		return x.AtCapacity
+	}
+	return false
+}
+
+func (x *NodeInfo) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *NodeInfo) GetRtspUrl() string {
+	if x != nil {
+		return x.RtspUrl
+	}
+	return ""
+}
 
 var File_skyegress_proto protoreflect.FileDescriptor
 
 var file_skyegress_proto_rawDesc = []byte{
 	0x0a, 0x0f, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x12, 0x09, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x22, 0x80, 0x01, 0x0a,
-	0x07, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x64, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x72, 0x6f,
-	0x6f, 0x6d, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72,
-	0x6f, 0x6f, 0x6d, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x72, 0x61, 0x63, 0x6b,
-	0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x74, 0x72, 0x61,
-	0x63, 0x6b, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73,
-	0x5f, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x0e, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x22,
-	0x3a, 0x0a, 0x08, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x2e, 0x0a, 0x08, 0x73,
-	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e,
-	0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f,
-	0x6e, 0x52, 0x08, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x51, 0x0a, 0x13, 0x53,
-	0x74, 0x61, 0x72, 0x74, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x72, 0x6f, 0x6f, 0x6d, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x6f, 0x6f, 0x6d, 0x4e, 0x61, 0x6d, 0x65, 0x12,
-	0x1d, 0x0a, 0x0a, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x09, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x68,
-	0x0a, 0x14, 0x53, 0x74, 0x61, 0x72, 0x74, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2e, 0x0a, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f,
-	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72,
-	0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x07, 0x73,
-	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x42, 0x08,
-	0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x15, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74,
-	0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22,
-	0x6b, 0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x31, 0x0a, 0x08, 0x73, 0x65, 0x73, 0x73, 0x69,
-	0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x73, 0x6b, 0x79, 0x65,
-	0x67, 0x72, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x48, 0x00,
-	0x52, 0x08, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x16, 0x0a, 0x05, 0x65, 0x72,
-	0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72,
-	0x6f, 0x72, 0x42, 0x08, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x26, 0x0a, 0x12,
-	0x53, 0x74, 0x6f, 0x70, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x03, 0x73, 0x69, 0x64, 0x22, 0x67, 0x0a, 0x13, 0x53, 0x74, 0x6f, 0x70, 0x53, 0x65, 0x73, 0x73,
+	0x6f, 0x12, 0x09, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x22, 0x97, 0x01, 0x0a,
+	0x0b, 0x52, 0x65, 0x61, 0x64, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x18, 0x0a, 0x07,
+	0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70,
+	0x6f, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x74, 0x72, 0x61, 0x6e, 0x73,
+	0x70, 0x6f, 0x72, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x79, 0x74, 0x65, 0x73, 0x5f, 0x73, 0x65,
+	0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x62, 0x79, 0x74, 0x65, 0x73, 0x53,
+	0x65, 0x6e, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x65, 0x64,
+	0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x63, 0x6f, 0x6e, 0x6e, 0x65,
+	0x63, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x22, 0x9f, 0x01, 0x0a, 0x09, 0x53, 0x69, 0x6e, 0x6b, 0x53,
+	0x74, 0x61, 0x74, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x77, 0x72, 0x69, 0x74,
+	0x74, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x77, 0x72, 0x69, 0x74, 0x74,
+	0x65, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x07, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x62, 0x75, 0x66, 0x66, 0x65, 0x72, 0x65, 0x64,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x62, 0x75, 0x66, 0x66, 0x65, 0x72, 0x65, 0x64,
+	0x12, 0x16, 0x0a, 0x06, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x06, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x22, 0x8b, 0x06, 0x0a, 0x0c, 0x53, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x70, 0x61, 0x63,
+	0x6b, 0x65, 0x74, 0x73, 0x5f, 0x72, 0x65, 0x6c, 0x61, 0x79, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x0e, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x52, 0x65, 0x6c, 0x61, 0x79,
+	0x65, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x62, 0x79, 0x74, 0x65, 0x73, 0x5f, 0x72, 0x65, 0x6c, 0x61,
+	0x79, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x52, 0x65, 0x6c, 0x61, 0x79, 0x65, 0x64, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x61, 0x63, 0x6b, 0x65,
+	0x74, 0x73, 0x5f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x0f, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76,
+	0x65, 0x64, 0x12, 0x25, 0x0a, 0x0e, 0x62, 0x79, 0x74, 0x65, 0x73, 0x5f, 0x72, 0x65, 0x63, 0x65,
+	0x69, 0x76, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d, 0x62, 0x79, 0x74, 0x65,
+	0x73, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x12, 0x23, 0x0a, 0x0d, 0x69, 0x6e, 0x70,
+	0x75, 0x74, 0x5f, 0x62, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x0c, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x42, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65, 0x12, 0x1d,
+	0x0a, 0x0a, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x09, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x52, 0x61, 0x74, 0x65, 0x12, 0x14, 0x0a,
+	0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x77, 0x69,
+	0x64, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x30, 0x0a, 0x14, 0x6b,
+	0x65, 0x79, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c,
+	0x5f, 0x6d, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x12, 0x6b, 0x65, 0x79, 0x66, 0x72,
+	0x61, 0x6d, 0x65, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x4d, 0x73, 0x12, 0x21, 0x0a,
+	0x0c, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x5f, 0x6c, 0x6f, 0x73, 0x74, 0x18, 0x0a, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0b, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x4c, 0x6f, 0x73, 0x74,
+	0x12, 0x2b, 0x0a, 0x11, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x5f, 0x72, 0x65, 0x6f, 0x72,
+	0x64, 0x65, 0x72, 0x65, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x04, 0x52, 0x10, 0x70, 0x61, 0x63,
+	0x6b, 0x65, 0x74, 0x73, 0x52, 0x65, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x65, 0x64, 0x12, 0x2f, 0x0a,
+	0x13, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x65, 0x72, 0x5f, 0x64,
+	0x72, 0x6f, 0x70, 0x73, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x04, 0x52, 0x12, 0x73, 0x61, 0x6d, 0x70,
+	0x6c, 0x65, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x65, 0x72, 0x44, 0x72, 0x6f, 0x70, 0x73, 0x12, 0x1b,
+	0x0a, 0x09, 0x70, 0x6c, 0x69, 0x73, 0x5f, 0x73, 0x65, 0x6e, 0x74, 0x18, 0x0d, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x08, 0x70, 0x6c, 0x69, 0x73, 0x53, 0x65, 0x6e, 0x74, 0x12, 0x2b, 0x0a, 0x12, 0x6c,
+	0x61, 0x73, 0x74, 0x5f, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x5f, 0x61, 0x67, 0x65, 0x5f, 0x6d,
+	0x73, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x6c, 0x61, 0x73, 0x74, 0x50, 0x61, 0x63,
+	0x6b, 0x65, 0x74, 0x41, 0x67, 0x65, 0x4d, 0x73, 0x12, 0x30, 0x0a, 0x07, 0x72, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x73, 0x18, 0x0f, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x73, 0x6b, 0x79, 0x65,
+	0x67, 0x72, 0x65, 0x73, 0x73, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74,
+	0x73, 0x52, 0x07, 0x72, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x12, 0x17, 0x0a, 0x07, 0x69, 0x64,
+	0x6c, 0x65, 0x5f, 0x6d, 0x73, 0x18, 0x10, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x69, 0x64, 0x6c,
+	0x65, 0x4d, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x6c, 0x61, 0x74, 0x65, 0x5f, 0x61, 0x63, 0x74,
+	0x69, 0x76, 0x65, 0x18, 0x11, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x73, 0x6c, 0x61, 0x74, 0x65,
+	0x41, 0x63, 0x74, 0x69, 0x76, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x72, 0x74, 0x70, 0x5f, 0x72, 0x65,
+	0x62, 0x61, 0x73, 0x65, 0x73, 0x18, 0x12, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x72, 0x74, 0x70,
+	0x52, 0x65, 0x62, 0x61, 0x73, 0x65, 0x73, 0x12, 0x2a, 0x0a, 0x05, 0x73, 0x69, 0x6e, 0x6b, 0x73,
+	0x18, 0x13, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65,
+	0x73, 0x73, 0x2e, 0x53, 0x69, 0x6e, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x05, 0x73, 0x69,
+	0x6e, 0x6b, 0x73, 0x12, 0x31, 0x0a, 0x07, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x18, 0x14,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73,
+	0x2e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x07, 0x63,
+	0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x22, 0xc8, 0x01, 0x0a, 0x0c, 0x43, 0x61, 0x70, 0x74, 0x75,
+	0x72, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x61,
+	0x63, 0x74, 0x69, 0x76, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x61, 0x63, 0x74,
+	0x69, 0x76, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x14, 0x0a,
+	0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x62, 0x79,
+	0x74, 0x65, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x61, 0x78, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x6d, 0x61, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73,
+	0x12, 0x25, 0x0a, 0x0e, 0x73, 0x74, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x72, 0x65, 0x61, 0x73,
+	0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x73, 0x74, 0x6f, 0x70, 0x70, 0x65,
+	0x64, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x72, 0x6f, 0x70, 0x70,
+	0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65,
+	0x64, 0x22, 0x80, 0x06, 0x0a, 0x07, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a,
+	0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64, 0x12,
+	0x1b, 0x0a, 0x09, 0x72, 0x6f, 0x6f, 0x6d, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x72, 0x6f, 0x6f, 0x6d, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a,
+	0x74, 0x72, 0x61, 0x63, 0x6b, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x65,
+	0x67, 0x72, 0x65, 0x73, 0x73, 0x5f, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x49, 0x64, 0x65, 0x6e,
+	0x74, 0x69, 0x74, 0x79, 0x12, 0x2d, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x2e,
+	0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74,
+	0x61, 0x74, 0x65, 0x12, 0x31, 0x0a, 0x14, 0x70, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61,
+	0x6e, 0x74, 0x5f, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x13, 0x70, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x49, 0x64,
+	0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x2d, 0x0a, 0x12, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x73,
+	0x68, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x11, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x65, 0x72, 0x49, 0x64, 0x65,
+	0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x21, 0x0a, 0x0c, 0x77, 0x65, 0x62, 0x68, 0x6f, 0x6f, 0x6b,
+	0x5f, 0x75, 0x72, 0x6c, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x77, 0x65, 0x62,
+	0x68, 0x6f, 0x6f, 0x6b, 0x55, 0x72, 0x6c, 0x73, 0x12, 0x2d, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74,
+	0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72,
+	0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x73,
+	0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x73, 0x12, 0x17, 0x0a, 0x07, 0x6e, 0x6f, 0x64, 0x65, 0x5f,
+	0x69, 0x64, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x6f, 0x64, 0x65, 0x49, 0x64,
+	0x12, 0x3d, 0x0a, 0x0d, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63,
+	0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x18, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72,
+	0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x53, 0x6f, 0x75, 0x72, 0x63,
+	0x65, 0x52, 0x0c, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12,
+	0x2a, 0x0a, 0x11, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x5f, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x5f,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x62, 0x61, 0x63, 0x6b,
+	0x75, 0x70, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x3e, 0x0a, 0x1b, 0x62,
+	0x61, 0x63, 0x6b, 0x75, 0x70, 0x5f, 0x70, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e,
+	0x74, 0x5f, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x19, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x50, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70,
+	0x61, 0x6e, 0x74, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x2e, 0x0a, 0x13, 0x66,
+	0x61, 0x69, 0x6c, 0x6f, 0x76, 0x65, 0x72, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f,
+	0x6d, 0x73, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x11, 0x66, 0x61, 0x69, 0x6c, 0x6f, 0x76,
+	0x65, 0x72, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4d, 0x73, 0x12, 0x2d, 0x0a, 0x08, 0x73,
+	0x65, 0x69, 0x5f, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e,
+	0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x69, 0x4d, 0x6f, 0x64,
+	0x65, 0x52, 0x07, 0x73, 0x65, 0x69, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x72,
+	0x6f, 0x63, 0x65, 0x73, 0x73, 0x6f, 0x72, 0x73, 0x18, 0x10, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a,
+	0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x6f, 0x72, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x69,
+	0x6e, 0x6b, 0x73, 0x18, 0x11, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x73, 0x69, 0x6e, 0x6b, 0x73,
+	0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x12, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x2a, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64,
+	0x18, 0x13, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x16, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65,
+	0x73, 0x73, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04,
+	0x6b, 0x69, 0x6e, 0x64, 0x22, 0x3a, 0x0a, 0x08, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73,
+	0x12, 0x2e, 0x0a, 0x08, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x2e, 0x53,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73,
+	0x22, 0x80, 0x04, 0x0a, 0x13, 0x53, 0x74, 0x61, 0x72, 0x74, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x72, 0x6f, 0x6f, 0x6d,
+	0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x6f, 0x6f,
+	0x6d, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x5f, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x74, 0x72, 0x61, 0x63, 0x6b,
+	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x31, 0x0a, 0x14, 0x70, 0x61, 0x72, 0x74,
+	0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x13, 0x70, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70,
+	0x61, 0x6e, 0x74, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x21, 0x0a, 0x0c, 0x77,
+	0x65, 0x62, 0x68, 0x6f, 0x6f, 0x6b, 0x5f, 0x75, 0x72, 0x6c, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x0b, 0x77, 0x65, 0x62, 0x68, 0x6f, 0x6f, 0x6b, 0x55, 0x72, 0x6c, 0x73, 0x12, 0x2a,
+	0x0a, 0x11, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x5f, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x5f, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x62, 0x61, 0x63, 0x6b, 0x75,
+	0x70, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x3e, 0x0a, 0x1b, 0x62, 0x61,
+	0x63, 0x6b, 0x75, 0x70, 0x5f, 0x70, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74,
+	0x5f, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x19, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x50, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61,
+	0x6e, 0x74, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x2e, 0x0a, 0x13, 0x66, 0x61,
+	0x69, 0x6c, 0x6f, 0x76, 0x65, 0x72, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6d,
+	0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x11, 0x66, 0x61, 0x69, 0x6c, 0x6f, 0x76, 0x65,
+	0x72, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4d, 0x73, 0x12, 0x2d, 0x0a, 0x08, 0x73, 0x65,
+	0x69, 0x5f, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x73,
+	0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x69, 0x4d, 0x6f, 0x64, 0x65,
+	0x52, 0x07, 0x73, 0x65, 0x69, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x72, 0x6f,
+	0x63, 0x65, 0x73, 0x73, 0x6f, 0x72, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x70,
+	0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x6f, 0x72, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x69, 0x6e,
+	0x6b, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x73, 0x69, 0x6e, 0x6b, 0x73, 0x12,
+	0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x2a, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18,
+	0x0d, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x16, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73,
+	0x73, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b,
+	0x69, 0x6e, 0x64, 0x22, 0x68, 0x0a, 0x14, 0x53, 0x74, 0x61, 0x72, 0x74, 0x53, 0x65, 0x73, 0x73,
 	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2e, 0x0a, 0x07, 0x73,
 	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73,
 	0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
 	0x48, 0x00, 0x52, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x05, 0x65,
 	0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72,
-	0x72, 0x6f, 0x72, 0x42, 0x08, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x42, 0x37, 0x5a,
-	0x35, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x72, 0x65, 0x79,
-	0x68, 0x61, 0x6b, 0x61, 0x6e, 0x73, 0x6f, 0x6e, 0x2f, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65,
-	0x73, 0x73, 0x2f, 0x70, 0x62, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2f, 0x73, 0x6b, 0x79, 0x65, 0x67,
-	0x72, 0x65, 0x73, 0x73, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x72, 0x6f, 0x72, 0x42, 0x08, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0xa3, 0x01,
+	0x0a, 0x0c, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x2f,
+	0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1b, 0x2e, 0x73,
+	0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12,
+	0x2c, 0x0a, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x12, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a,
+	0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72,
+	0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x22, 0x99, 0x01, 0x0a, 0x16, 0x52, 0x65, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74,
+	0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10,
+	0x0a, 0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64,
+	0x12, 0x1b, 0x0a, 0x09, 0x72, 0x6f, 0x6f, 0x6d, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x6f, 0x6f, 0x6d, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a,
+	0x0a, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x31, 0x0a, 0x14,
+	0x70, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x65, 0x6e,
+	0x74, 0x69, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x13, 0x70, 0x61, 0x72, 0x74,
+	0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x22,
+	0x6b, 0x0a, 0x17, 0x52, 0x65, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x53, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2e, 0x0a, 0x07, 0x73, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73, 0x6b,
+	0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x48,
+	0x00, 0x52, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x42, 0x08, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x15, 0x0a, 0x13,
+	0x4c, 0x69, 0x73, 0x74, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x6b, 0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x31, 0x0a, 0x08, 0x73,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e,
+	0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x73, 0x48, 0x00, 0x52, 0x08, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x16,
+	0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52,
+	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x42, 0x08, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x22, 0x3e, 0x0a, 0x12, 0x53, 0x74, 0x6f, 0x70, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73,
+	0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e,
+	0x22, 0x67, 0x0a, 0x13, 0x53, 0x74, 0x6f, 0x70, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2e, 0x0a, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67,
+	0x72, 0x65, 0x73, 0x73, 0x2e, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x07,
+	0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x42,
+	0x08, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x3b, 0x0a, 0x07, 0x56, 0x69, 0x65,
+	0x77, 0x65, 0x72, 0x73, 0x12, 0x30, 0x0a, 0x07, 0x76, 0x69, 0x65, 0x77, 0x65, 0x72, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73,
+	0x73, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x07, 0x76,
+	0x69, 0x65, 0x77, 0x65, 0x72, 0x73, 0x22, 0x26, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x69,
+	0x65, 0x77, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03,
+	0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64, 0x22, 0x67,
+	0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x69, 0x65, 0x77, 0x65, 0x72, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2e, 0x0a, 0x07, 0x76, 0x69, 0x65, 0x77, 0x65, 0x72, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65,
+	0x73, 0x73, 0x2e, 0x56, 0x69, 0x65, 0x77, 0x65, 0x72, 0x73, 0x48, 0x00, 0x52, 0x07, 0x76, 0x69,
+	0x65, 0x77, 0x65, 0x72, 0x73, 0x12, 0x16, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x42, 0x08, 0x0a,
+	0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0xc6, 0x01, 0x0a, 0x09, 0x46, 0x72, 0x61, 0x6d,
+	0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x26, 0x0a, 0x0f, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65,
+	0x5f, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d,
+	0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x4e, 0x73, 0x12, 0x10, 0x0a,
+	0x03, 0x73, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x69, 0x64, 0x12,
+	0x2d, 0x0a, 0x12, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x65,
+	0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x70, 0x75, 0x62,
+	0x6c, 0x69, 0x73, 0x68, 0x65, 0x72, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x2d,
+	0x0a, 0x12, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x65, 0x72, 0x5f, 0x6d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x70, 0x75, 0x62, 0x6c,
+	0x69, 0x73, 0x68, 0x65, 0x72, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x21, 0x0a,
+	0x0c, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0b, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72,
+	0x22, 0xc4, 0x01, 0x0a, 0x10, 0x54, 0x65, 0x73, 0x74, 0x50, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e,
+	0x46, 0x72, 0x61, 0x6d, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x66, 0x72, 0x61, 0x6d, 0x65, 0x5f, 0x6e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x66, 0x72, 0x61,
+	0x6d, 0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x26, 0x0a, 0x0f, 0x63, 0x61, 0x70, 0x74,
+	0x75, 0x72, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0d, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x4e, 0x73,
+	0x12, 0x18, 0x0a, 0x07, 0x76, 0x61, 0x72, 0x69, 0x61, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x76, 0x61, 0x72, 0x69, 0x61, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69,
+	0x64, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68,
+	0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x72, 0x61, 0x6d,
+	0x65, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x66, 0x72,
+	0x61, 0x6d, 0x65, 0x52, 0x61, 0x74, 0x65, 0x22, 0x83, 0x02, 0x0a, 0x0c, 0x43, 0x6c, 0x6f, 0x63,
+	0x6b, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x12, 0x23, 0x0a, 0x0d, 0x72, 0x74, 0x70, 0x5f,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x0c, 0x72, 0x74, 0x70, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x12, 0x0a,
+	0x04, 0x73, 0x73, 0x72, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x73, 0x73, 0x72,
+	0x63, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x63, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x61, 0x74, 0x65,
+	0x12, 0x22, 0x0a, 0x0d, 0x77, 0x61, 0x6c, 0x6c, 0x5f, 0x63, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x6e,
+	0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x77, 0x61, 0x6c, 0x6c, 0x43, 0x6c, 0x6f,
+	0x63, 0x6b, 0x4e, 0x73, 0x12, 0x2c, 0x0a, 0x12, 0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x73, 0x65, 0x6e,
+	0x64, 0x65, 0x72, 0x5f, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x10, 0x66, 0x72, 0x6f, 0x6d, 0x53, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6f,
+	0x72, 0x74, 0x12, 0x28, 0x0a, 0x10, 0x73, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x5f, 0x72, 0x65, 0x70,
+	0x6f, 0x72, 0x74, 0x5f, 0x61, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x73, 0x65,
+	0x6e, 0x64, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x41, 0x74, 0x12, 0x1f, 0x0a, 0x0b,
+	0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0a, 0x66, 0x72, 0x6f, 0x6d, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x22, 0x23, 0x0a,
+	0x0f, 0x47, 0x65, 0x74, 0x43, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73,
+	0x69, 0x64, 0x22, 0x65, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x43, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2f, 0x0a, 0x05, 0x63, 0x6c, 0x6f, 0x63, 0x6b, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73,
+	0x73, 0x2e, 0x43, 0x6c, 0x6f, 0x63, 0x6b, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x48, 0x00,
+	0x52, 0x05, 0x63, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x16, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x42,
+	0x08, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x60, 0x0a, 0x15, 0x43, 0x61, 0x70,
+	0x74, 0x75, 0x72, 0x65, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x73, 0x69, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x12, 0x1b,
+	0x0a, 0x09, 0x6d, 0x61, 0x78, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x08, 0x6d, 0x61, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x22, 0x6f, 0x0a, 0x16, 0x43,
+	0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x33, 0x0a, 0x07, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65,
+	0x73, 0x73, 0x2e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x48,
+	0x00, 0x52, 0x07, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x12, 0x16, 0x0a, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x42, 0x08, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x42, 0x0a, 0x11,
+	0x4b, 0x69, 0x63, 0x6b, 0x56, 0x69, 0x65, 0x77, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x73, 0x69, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x76, 0x69, 0x65, 0x77, 0x65, 0x72, 0x5f, 0x69, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x76, 0x69, 0x65, 0x77, 0x65, 0x72, 0x49, 0x64,
+	0x22, 0x68, 0x0a, 0x12, 0x4b, 0x69, 0x63, 0x6b, 0x56, 0x69, 0x65, 0x77, 0x65, 0x72, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x30, 0x0a, 0x06, 0x76, 0x69, 0x65, 0x77, 0x65, 0x72,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65,
+	0x73, 0x73, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x73, 0x48, 0x00,
+	0x52, 0x06, 0x76, 0x69, 0x65, 0x77, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x42, 0x08, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x22, 0x6b, 0x0a, 0x0a, 0x4e, 0x6f,
+	0x64, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x72, 0x61, 0x69,
+	0x6e, 0x69, 0x6e, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x64, 0x72, 0x61, 0x69,
+	0x6e, 0x69, 0x6e, 0x67, 0x12, 0x25, 0x0a, 0x0e, 0x64, 0x72, 0x61, 0x69, 0x6e, 0x5f, 0x64, 0x65,
+	0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x64, 0x72,
+	0x61, 0x69, 0x6e, 0x44, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x73,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x31, 0x0a, 0x10, 0x44, 0x72, 0x61, 0x69, 0x6e,
+	0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x74,
+	0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x09, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4d, 0x73, 0x22, 0x66, 0x0a, 0x11, 0x44, 0x72,
+	0x61, 0x69, 0x6e, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x2f, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x15, 0x2e, 0x73, 0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x2e, 0x4e, 0x6f, 0x64, 0x65,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x48, 0x00, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x12, 0x16, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48,
+	0x00, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x42, 0x08, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x22, 0xf3, 0x01, 0x0a, 0x08, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12,
+	0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12,
+	0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72,
+	0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x08, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x18, 0x0a,
+	0x07, 0x72, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07,
+	0x72, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x62, 0x69, 0x74, 0x72, 0x61,
+	0x74, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x62, 0x69, 0x74, 0x72, 0x61, 0x74,
+	0x65, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x72, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x08, 0x64, 0x72, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x1f, 0x0a,
+	0x0b, 0x61, 0x74, 0x5f, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0a, 0x61, 0x74, 0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x12, 0x1d,
+	0x0a, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x19, 0x0a,
+	0x08, 0x72, 0x74, 0x73, 0x70, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x72, 0x74, 0x73, 0x70, 0x55, 0x72, 0x6c, 0x2a, 0x98, 0x01, 0x0a, 0x0c, 0x53, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x16, 0x53, 0x45, 0x53,
+	0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x52, 0x54,
+	0x49, 0x4e, 0x47, 0x10, 0x00, 0x12, 0x18, 0x0a, 0x14, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e,
+	0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x56, 0x45, 0x10, 0x01, 0x12,
+	0x18, 0x0a, 0x14, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45,
+	0x5f, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x02, 0x12, 0x19, 0x0a, 0x15, 0x53, 0x45, 0x53,
+	0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x53, 0x54, 0x4f, 0x50, 0x50,
+	0x45, 0x44, 0x10, 0x03, 0x12, 0x1d, 0x0a, 0x19, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f,
+	0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x4c, 0x4f, 0x53,
+	0x54, 0x10, 0x04, 0x2a, 0x5a, 0x0a, 0x0b, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x4b, 0x69,
+	0x6e, 0x64, 0x12, 0x17, 0x0a, 0x13, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x4b, 0x49,
+	0x4e, 0x44, 0x5f, 0x45, 0x47, 0x52, 0x45, 0x53, 0x53, 0x10, 0x00, 0x12, 0x18, 0x0a, 0x14, 0x53,
+	0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x49, 0x4e, 0x47, 0x52,
+	0x45, 0x53, 0x53, 0x10, 0x01, 0x12, 0x18, 0x0a, 0x14, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e,
+	0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x50, 0x55, 0x42, 0x4c, 0x49, 0x53, 0x48, 0x10, 0x02, 0x2a,
+	0x46, 0x0a, 0x0d, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x12, 0x1a, 0x0a, 0x16, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x53, 0x4f, 0x55, 0x52,
+	0x43, 0x45, 0x5f, 0x50, 0x52, 0x49, 0x4d, 0x41, 0x52, 0x59, 0x10, 0x00, 0x12, 0x19, 0x0a, 0x15,
+	0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x42,
+	0x41, 0x43, 0x4b, 0x55, 0x50, 0x10, 0x01, 0x2a, 0x4c, 0x0a, 0x07, 0x53, 0x65, 0x69, 0x4d, 0x6f,
+	0x64, 0x65, 0x12, 0x10, 0x0a, 0x0c, 0x53, 0x45, 0x49, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x4f,
+	0x46, 0x46, 0x10, 0x00, 0x12, 0x16, 0x0a, 0x12, 0x53, 0x45, 0x49, 0x5f, 0x4d, 0x4f, 0x44, 0x45,
+	0x5f, 0x4b, 0x45, 0x59, 0x46, 0x52, 0x41, 0x4d, 0x45, 0x53, 0x10, 0x01, 0x12, 0x17, 0x0a, 0x13,
+	0x53, 0x45, 0x49, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x41, 0x4c, 0x4c, 0x5f, 0x46, 0x52, 0x41,
+	0x4d, 0x45, 0x53, 0x10, 0x02, 0x2a, 0x89, 0x03, 0x0a, 0x10, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1d, 0x0a, 0x19, 0x53, 0x45,
+	0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x55, 0x4e, 0x53, 0x50,
+	0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x19, 0x0a, 0x15, 0x53, 0x45, 0x53,
+	0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x53, 0x54, 0x41, 0x52, 0x54,
+	0x45, 0x44, 0x10, 0x01, 0x12, 0x1d, 0x0a, 0x19, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f,
+	0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x53, 0x4f, 0x55, 0x52, 0x43, 0x45, 0x5f, 0x4c, 0x4f, 0x53,
+	0x54, 0x10, 0x02, 0x12, 0x1d, 0x0a, 0x19, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x45,
+	0x56, 0x45, 0x4e, 0x54, 0x5f, 0x52, 0x45, 0x43, 0x4f, 0x4e, 0x4e, 0x45, 0x43, 0x54, 0x45, 0x44,
+	0x10, 0x03, 0x12, 0x19, 0x0a, 0x15, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x45, 0x56,
+	0x45, 0x4e, 0x54, 0x5f, 0x53, 0x54, 0x4f, 0x50, 0x50, 0x45, 0x44, 0x10, 0x04, 0x12, 0x18, 0x0a,
+	0x14, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x46,
+	0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x05, 0x12, 0x19, 0x0a, 0x15, 0x53, 0x45, 0x53, 0x53, 0x49,
+	0x4f, 0x4e, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x43, 0x52, 0x45, 0x41, 0x54, 0x45, 0x44,
+	0x10, 0x06, 0x12, 0x17, 0x0a, 0x13, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x45, 0x56,
+	0x45, 0x4e, 0x54, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x53, 0x10, 0x07, 0x12, 0x1a, 0x0a, 0x16, 0x53,
+	0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x53, 0x4e, 0x41,
+	0x50, 0x53, 0x48, 0x4f, 0x54, 0x10, 0x08, 0x12, 0x1a, 0x0a, 0x16, 0x53, 0x45, 0x53, 0x53, 0x49,
+	0x4f, 0x4e, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x46, 0x41, 0x49, 0x4c, 0x4f, 0x56, 0x45,
+	0x52, 0x10, 0x09, 0x12, 0x1a, 0x0a, 0x16, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x45,
+	0x56, 0x45, 0x4e, 0x54, 0x5f, 0x46, 0x41, 0x49, 0x4c, 0x42, 0x41, 0x43, 0x4b, 0x10, 0x0a, 0x12,
+	0x1f, 0x0a, 0x1b, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54,
+	0x5f, 0x53, 0x4c, 0x41, 0x54, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x52, 0x54, 0x45, 0x44, 0x10, 0x0b,
+	0x12, 0x1f, 0x0a, 0x1b, 0x53, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x5f, 0x45, 0x56, 0x45, 0x4e,
+	0x54, 0x5f, 0x53, 0x4c, 0x41, 0x54, 0x45, 0x5f, 0x53, 0x54, 0x4f, 0x50, 0x50, 0x45, 0x44, 0x10,
+	0x0c, 0x42, 0x37, 0x5a, 0x35, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x74, 0x72, 0x65, 0x79, 0x68, 0x61, 0x6b, 0x61, 0x6e, 0x73, 0x6f, 0x6e, 0x2f, 0x73, 0x6b, 0x79,
+	0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x2f, 0x70, 0x62, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2f, 0x73,
+	0x6b, 0x79, 0x65, 0x67, 0x72, 0x65, 0x73, 0x73, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -593,27 +3380,75 @@ func file_skyegress_proto_rawDescGZIP() []byte {
 	return file_skyegress_proto_rawDescData
 }
 
-var file_skyegress_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_skyegress_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_skyegress_proto_msgTypes = make([]protoimpl.MessageInfo, 31)
 var file_skyegress_proto_goTypes = []interface{}{
-	(*Session)(nil),              // 0: skyegress.Session
-	(*Sessions)(nil),             // 1: skyegress.Sessions
-	(*StartSessionRequest)(nil),  // 2: skyegress.StartSessionRequest
-	(*StartSessionResponse)(nil), // 3: skyegress.StartSessionResponse
-	(*ListSessionsRequest)(nil),  // 4: skyegress.ListSessionsRequest
-	(*ListSessionsResponse)(nil), // 5: skyegress.ListSessionsResponse
-	(*StopSessionRequest)(nil),   // 6: skyegress.StopSessionRequest
-	(*StopSessionResponse)(nil),  // 7: skyegress.StopSessionResponse
+	(SessionState)(0),               // 0: skyegress.SessionState
+	(SessionKind)(0),                // 1: skyegress.SessionKind
+	(SessionSource)(0),              // 2: skyegress.SessionSource
+	(SeiMode)(0),                    // 3: skyegress.SeiMode
+	(SessionEventType)(0),           // 4: skyegress.SessionEventType
+	(*ReaderStats)(nil),             // 5: skyegress.ReaderStats
+	(*SinkStats)(nil),               // 6: skyegress.SinkStats
+	(*SessionStats)(nil),            // 7: skyegress.SessionStats
+	(*CaptureStats)(nil),            // 8: skyegress.CaptureStats
+	(*Session)(nil),                 // 9: skyegress.Session
+	(*Sessions)(nil),                // 10: skyegress.Sessions
+	(*StartSessionRequest)(nil),     // 11: skyegress.StartSessionRequest
+	(*StartSessionResponse)(nil),    // 12: skyegress.StartSessionResponse
+	(*SessionEvent)(nil),            // 13: skyegress.SessionEvent
+	(*RetargetSessionRequest)(nil),  // 14: skyegress.RetargetSessionRequest
+	(*RetargetSessionResponse)(nil), // 15: skyegress.RetargetSessionResponse
+	(*ListSessionsRequest)(nil),     // 16: skyegress.ListSessionsRequest
+	(*ListSessionsResponse)(nil),    // 17: skyegress.ListSessionsResponse
+	(*StopSessionRequest)(nil),      // 18: skyegress.StopSessionRequest
+	(*StopSessionResponse)(nil),     // 19: skyegress.StopSessionResponse
+	(*Viewers)(nil),                 // 20: skyegress.Viewers
+	(*ListViewersRequest)(nil),      // 21: skyegress.ListViewersRequest
+	(*ListViewersResponse)(nil),     // 22: skyegress.ListViewersResponse
+	(*FrameInfo)(nil),               // 23: skyegress.FrameInfo
+	(*TestPatternFrame)(nil),        // 24: skyegress.TestPatternFrame
+	(*ClockMapping)(nil),            // 25: skyegress.ClockMapping
+	(*GetClockRequest)(nil),         // 26: skyegress.GetClockRequest
+	(*GetClockResponse)(nil),        // 27: skyegress.GetClockResponse
+	(*CaptureSessionRequest)(nil),   // 28: skyegress.CaptureSessionRequest
+	(*CaptureSessionResponse)(nil),  // 29: skyegress.CaptureSessionResponse
+	(*KickViewerRequest)(nil),       // 30: skyegress.KickViewerRequest
+	(*KickViewerResponse)(nil),      // 31: skyegress.KickViewerResponse
+	(*NodeStatus)(nil),              // 32: skyegress.NodeStatus
+	(*DrainNodeRequest)(nil),        // 33: skyegress.DrainNodeRequest
+	(*DrainNodeResponse)(nil),       // 34: skyegress.DrainNodeResponse
+	(*NodeInfo)(nil),                // 35: skyegress.NodeInfo
 }
 var file_skyegress_proto_depIdxs = []int32{
-	0, // 0: skyegress.Sessions.sessions:type_name -> skyegress.Session
-	0, // 1: skyegress.StartSessionResponse.session:type_name -> skyegress.Session
-	1, // 2: skyegress.ListSessionsResponse.sessions:type_name -> skyegress.Sessions
-	0, // 3: skyegress.StopSessionResponse.session:type_name -> skyegress.Session
-	4, // [4:4] is the sub-list for method output_type
-	4, // [4:4] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	5,  // 0: skyegress.SessionStats.readers:type_name -> skyegress.ReaderStats
+	6,  // 1: skyegress.SessionStats.sinks:type_name -> skyegress.SinkStats
+	8,  // 2: skyegress.SessionStats.capture:type_name -> skyegress.CaptureStats
+	0,  // 3: skyegress.Session.state:type_name -> skyegress.SessionState
+	7,  // 4: skyegress.Session.stats:type_name -> skyegress.SessionStats
+	2,  // 5: skyegress.Session.active_source:type_name -> skyegress.SessionSource
+	3,  // 6: skyegress.Session.sei_mode:type_name -> skyegress.SeiMode
+	1,  // 7: skyegress.Session.kind:type_name -> skyegress.SessionKind
+	9,  // 8: skyegress.Sessions.sessions:type_name -> skyegress.Session
+	3,  // 9: skyegress.StartSessionRequest.sei_mode:type_name -> skyegress.SeiMode
+	1,  // 10: skyegress.StartSessionRequest.kind:type_name -> skyegress.SessionKind
+	9,  // 11: skyegress.StartSessionResponse.session:type_name -> skyegress.Session
+	4,  // 12: skyegress.SessionEvent.type:type_name -> skyegress.SessionEventType
+	9,  // 13: skyegress.SessionEvent.session:type_name -> skyegress.Session
+	9,  // 14: skyegress.RetargetSessionResponse.session:type_name -> skyegress.Session
+	10, // 15: skyegress.ListSessionsResponse.sessions:type_name -> skyegress.Sessions
+	9,  // 16: skyegress.StopSessionResponse.session:type_name -> skyegress.Session
+	5,  // 17: skyegress.Viewers.viewers:type_name -> skyegress.ReaderStats
+	20, // 18: skyegress.ListViewersResponse.viewers:type_name -> skyegress.Viewers
+	25, // 19: skyegress.GetClockResponse.clock:type_name -> skyegress.ClockMapping
+	8,  // 20: skyegress.CaptureSessionResponse.capture:type_name -> skyegress.CaptureStats
+	5,  // 21: skyegress.KickViewerResponse.viewer:type_name -> skyegress.ReaderStats
+	32, // 22: skyegress.DrainNodeResponse.status:type_name -> skyegress.NodeStatus
+	23, // [23:23] is the sub-list for method output_type
+	23, // [23:23] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
 }
 
 func init() { file_skyegress_proto_init() }
@@ -623,7 +3458,7 @@ func file_skyegress_proto_init() {
 	}
 	if !protoimpl.UnsafeEnabled {
 		file_skyegress_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Session); i {
+			switch v := v.(*ReaderStats); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -635,7 +3470,7 @@ func file_skyegress_proto_init() {
 			}
 		}
 		file_skyegress_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Sessions); i {
+			switch v := v.(*SinkStats); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -647,7 +3482,7 @@ func file_skyegress_proto_init() {
 			}
 		}
 		file_skyegress_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StartSessionRequest); i {
+			switch v := v.(*SessionStats); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -659,7 +3494,7 @@ func file_skyegress_proto_init() {
 			}
 		}
 		file_skyegress_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StartSessionResponse); i {
+			switch v := v.(*CaptureStats); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -671,7 +3506,7 @@ func file_skyegress_proto_init() {
 			}
 		}
 		file_skyegress_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ListSessionsRequest); i {
+			switch v := v.(*Session); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -683,7 +3518,7 @@ func file_skyegress_proto_init() {
 			}
 		}
 		file_skyegress_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ListSessionsResponse); i {
+			switch v := v.(*Sessions); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -695,7 +3530,7 @@ func file_skyegress_proto_init() {
 			}
 		}
 		file_skyegress_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StopSessionRequest); i {
+			switch v := v.(*StartSessionRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -707,6 +3542,90 @@ func file_skyegress_proto_init() {
 			}
 		}
 		file_skyegress_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StartSessionResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SessionEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RetargetSessionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RetargetSessionResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListSessionsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListSessionsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StopSessionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*StopSessionResponse); i {
 			case 0:
 				return &v.state
@@ -718,31 +3637,248 @@ func file_skyegress_proto_init() {
 				return nil
 			}
 		}
+		file_skyegress_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Viewers); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListViewersRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListViewersResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FrameInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TestPatternFrame); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ClockMapping); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetClockRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetClockResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CaptureSessionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CaptureSessionResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KickViewerRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KickViewerResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NodeStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DrainNodeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DrainNodeResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_skyegress_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NodeInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
-	file_skyegress_proto_msgTypes[3].OneofWrappers = []interface{}{
+	file_skyegress_proto_msgTypes[7].OneofWrappers = []interface{}{
 		(*StartSessionResponse_Session)(nil),
 		(*StartSessionResponse_Error)(nil),
 	}
-	file_skyegress_proto_msgTypes[5].OneofWrappers = []interface{}{
+	file_skyegress_proto_msgTypes[10].OneofWrappers = []interface{}{
+		(*RetargetSessionResponse_Session)(nil),
+		(*RetargetSessionResponse_Error)(nil),
+	}
+	file_skyegress_proto_msgTypes[12].OneofWrappers = []interface{}{
 		(*ListSessionsResponse_Sessions)(nil),
 		(*ListSessionsResponse_Error)(nil),
 	}
-	file_skyegress_proto_msgTypes[7].OneofWrappers = []interface{}{
+	file_skyegress_proto_msgTypes[14].OneofWrappers = []interface{}{
 		(*StopSessionResponse_Session)(nil),
 		(*StopSessionResponse_Error)(nil),
 	}
+	file_skyegress_proto_msgTypes[17].OneofWrappers = []interface{}{
+		(*ListViewersResponse_Viewers)(nil),
+		(*ListViewersResponse_Error)(nil),
+	}
+	file_skyegress_proto_msgTypes[22].OneofWrappers = []interface{}{
+		(*GetClockResponse_Clock)(nil),
+		(*GetClockResponse_Error)(nil),
+	}
+	file_skyegress_proto_msgTypes[24].OneofWrappers = []interface{}{
+		(*CaptureSessionResponse_Capture)(nil),
+		(*CaptureSessionResponse_Error)(nil),
+	}
+	file_skyegress_proto_msgTypes[26].OneofWrappers = []interface{}{
+		(*KickViewerResponse_Viewer)(nil),
+		(*KickViewerResponse_Error)(nil),
+	}
+	file_skyegress_proto_msgTypes[29].OneofWrappers = []interface{}{
+		(*DrainNodeResponse_Status)(nil),
+		(*DrainNodeResponse_Error)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_skyegress_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   8,
+			NumEnums:      5,
+			NumMessages:   31,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_skyegress_proto_goTypes,
 		DependencyIndexes: file_skyegress_proto_depIdxs,
+		EnumInfos:         file_skyegress_proto_enumTypes,
 		MessageInfos:      file_skyegress_proto_msgTypes,
 	}.Build()
 	File_skyegress_proto = out.File
@@ -0,0 +1,282 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/config"
+	"github.com/treyhaknson/skyegress/pkg/stream"
+)
+
+// returned when no node in the cluster can take on another session
+var ErrNoNodeAvailable = fmt.Errorf("%w: no node in the cluster is accepting sessions", stream.ErrResourceExhausted)
+
+// a node's view of the cluster it belongs to. It publishes its own load and sessions to the registry, and reads
+// everyone else's from it to place and route sessions.
+type Cluster struct {
+	cfg      config.ClusterConfig
+	registry Registry
+	manager  *stream.SkyEgressStreamManager
+	changed  chan struct{}
+}
+
+func NewCluster(cfg config.ClusterConfig, registry Registry, manager *stream.SkyEgressStreamManager) *Cluster {
+	return &Cluster{
+		cfg:      cfg,
+		registry: registry,
+		manager:  manager,
+		changed:  make(chan struct{}, 1),
+	}
+}
+
+// publishes the node every heartbeat, and whenever its sessions change, until the context is cancelled; the node
+// is then removed from the registry
+func (c *Cluster) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Heartbeat)
+	defer ticker.Stop()
+
+	c.publish(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			// the context is done, so removing needs its own
+			removeCtx, cancel := context.WithTimeout(context.Background(), c.cfg.Heartbeat)
+			defer cancel()
+			if err := c.registry.Remove(removeCtx, c.manager.NodeID()); err != nil {
+				fmt.Println("unable to remove node from the cluster registry", err)
+			}
+			return
+		case <-ticker.C:
+		case <-c.changed:
+		}
+		c.publish(ctx)
+	}
+}
+
+// publishes the node early when a session changes, rather than waiting for the next heartbeat, and gives up the
+// claim on a session once it stops so it can be started again straight away
+func (c *Cluster) OnEvent(event *skyegresspb.SessionEvent) {
+	switch event.Type {
+	case skyegresspb.SessionEventType_SESSION_EVENT_STATS:
+		return
+	case skyegresspb.SessionEventType_SESSION_EVENT_STOPPED:
+		ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Heartbeat)
+		defer cancel()
+		if err := c.registry.Release(ctx, event.Session.GetSid(), c.NodeID()); err != nil {
+			fmt.Println("unable to release claim on session", event.Session.GetSid(), err)
+		}
+	}
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Cluster) publish(ctx context.Context) {
+	sessions := c.manager.Sessions()
+	err := c.registry.Publish(ctx, c.localNode(), sessions, c.ttl())
+	if err != nil && ctx.Err() == nil {
+		fmt.Println("unable to publish node to the cluster registry", err)
+	}
+
+	// the claims on running sessions last as long as the node does
+	for _, session := range sessions {
+		holder, err := c.registry.Claim(ctx, session.Sid, c.NodeID(), c.ttl())
+		if err != nil && ctx.Err() == nil {
+			fmt.Println("unable to renew claim on session", session.Sid, err)
+		} else if err == nil && holder != c.NodeID() {
+			fmt.Printf("session %s runs here but is claimed by node %s\n", session.Sid, holder)
+		}
+	}
+}
+
+// how long a node's publish and claims last; a node that misses a few heartbeats is presumed dead
+func (c *Cluster) ttl() time.Duration {
+	return 3 * c.cfg.Heartbeat
+}
+
+func (c *Cluster) localNode() *skyegresspb.NodeInfo {
+	usage := c.manager.Usage()
+	draining, _ := c.manager.DrainStatus()
+	return &skyegresspb.NodeInfo{
+		Id:         c.manager.NodeID(),
+		Url:        c.cfg.AdvertiseURL,
+		RtspUrl:    c.cfg.AdvertiseRTSPURL,
+		Sessions:   uint32(usage.Sessions),
+		Readers:    uint32(usage.Readers),
+		Bitrate:    usage.Bitrate,
+		Draining:   draining,
+		AtCapacity: errors.Is(c.manager.AtCapacity(), stream.ErrResourceExhausted),
+		UpdatedAt:  time.Now().UnixMilli(),
+	}
+}
+
+func (c *Cluster) NodeID() string {
+	return c.manager.NodeID()
+}
+
+func (c *Cluster) IsLocal(node *skyegresspb.NodeInfo) bool {
+	return node.Id == c.manager.NodeID()
+}
+
+// the node a new session should run on: the one accepting sessions with the fewest running, then the least
+// bitrate
+func (c *Cluster) Place(ctx context.Context) (*skyegresspb.NodeInfo, error) {
+	nodes, err := c.nodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []*skyegresspb.NodeInfo{}
+	for _, node := range nodes {
+		if !node.Draining && !node.AtCapacity {
+			candidates = append(candidates, node)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoNodeAvailable
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Sessions != b.Sessions {
+			return a.Sessions < b.Sessions
+		}
+		if a.Bitrate != b.Bitrate {
+			return a.Bitrate < b.Bitrate
+		}
+		return a.Id < b.Id
+	})
+	return candidates[0], nil
+}
+
+// claims the SID for the node a session is about to be started on, so no other node starts it at the same time;
+// returns the ID of the node holding the claim, which is the given node's if it claimed it. The claim lasts until the
+// session stops, or the node stops renewing it.
+func (c *Cluster) Claim(ctx context.Context, sid string, nodeID string) (string, error) {
+	return c.registry.Claim(ctx, sid, nodeID, c.ttl())
+}
+
+// gives up the node's claim on the SID, after failing to start the session there
+func (c *Cluster) Release(ctx context.Context, sid string, nodeID string) {
+	if err := c.registry.Release(ctx, sid, nodeID); err != nil {
+		fmt.Println("unable to release claim on session", sid, err)
+	}
+}
+
+// the node running the session, if any node is
+func (c *Cluster) Owner(ctx context.Context, sid string) (*skyegresspb.NodeInfo, bool, error) {
+	if c.manager.HasSession(sid) {
+		return c.localNode(), true, nil
+	}
+
+	nodes, err := c.nodes(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, node := range nodes {
+		if c.IsLocal(node) {
+			continue
+		}
+		sessions, err := c.registry.Sessions(ctx, node.Id)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, session := range sessions {
+			if session.Sid == sid {
+				return node, true, nil
+			}
+		}
+	}
+	return nil, false, nil
+}
+
+// whether this node should run a session any node could start, such as one declared to every node: it should if no
+// other node runs the session and this is where it would be placed, in which case it claims the session, or it
+// already runs it. Of several nodes running the same session, the one with the lowest ID keeps it.
+func (c *Cluster) ShouldRun(ctx context.Context, sid string) (bool, error) {
+	running := c.manager.HasSession(sid)
+
+	nodes, err := c.nodes(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, node := range nodes {
+		if c.IsLocal(node) {
+			continue
+		}
+		sessions, err := c.registry.Sessions(ctx, node.Id)
+		if err != nil {
+			return false, err
+		}
+		for _, session := range sessions {
+			if session.Sid == sid && (!running || node.Id < c.NodeID()) {
+				return false, nil
+			}
+		}
+	}
+	if running {
+		return true, nil
+	}
+
+	node, err := c.Place(ctx)
+	if err != nil || !c.IsLocal(node) {
+		return false, err
+	}
+	holder, err := c.Claim(ctx, sid, node.Id)
+	if err != nil {
+		return false, err
+	}
+	return holder == node.Id, nil
+}
+
+// the RTSP URL of another node serving the stream, for redirecting readers to
+func (c *Cluster) Locate(ctx context.Context, sid string) (string, bool, error) {
+	owner, ok, err := c.Owner(ctx, sid)
+	if err != nil || !ok || c.IsLocal(owner) {
+		return "", false, err
+	}
+	return owner.RtspUrl, true, nil
+}
+
+// sessions running anywhere in the cluster; those on this node are live, the rest are as of their node's last
+// publish
+func (c *Cluster) Sessions(ctx context.Context) ([]*skyegresspb.Session, error) {
+	sessions := c.manager.Sessions()
+
+	nodes, err := c.nodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		if c.IsLocal(node) {
+			continue
+		}
+		remote, err := c.registry.Sessions(ctx, node.Id)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, remote...)
+	}
+	return sessions, nil
+}
+
+// every live node, with this one as it is now rather than as last published
+func (c *Cluster) nodes(ctx context.Context) ([]*skyegresspb.NodeInfo, error) {
+	registered, err := c.registry.Nodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []*skyegresspb.NodeInfo{c.localNode()}
+	for _, node := range registered {
+		if !c.IsLocal(node) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
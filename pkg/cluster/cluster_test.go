@@ -0,0 +1,377 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/config"
+	"github.com/treyhaknson/skyegress/pkg/stream"
+)
+
+const testHeartbeat = time.Minute
+
+// a node of a cluster sharing the registry with nodes that only exist in it
+type testCluster struct {
+	*Cluster
+	manager  *stream.SkyEgressStreamManager
+	registry *memoryRegistry
+}
+
+func newTestCluster(t *testing.T, nodeID string) *testCluster {
+	t.Helper()
+	manager := stream.NewSkyEgressStreamManager(nodeID, config.LiveKitConfig{}, config.CapacityConfig{}, config.CaptureConfig{}, nil)
+	registry := NewMemoryRegistry()
+	cfg := config.ClusterConfig{NodeID: nodeID, Heartbeat: testHeartbeat}
+	tc := &testCluster{
+		Cluster:  NewCluster(cfg, registry, &manager),
+		manager:  &manager,
+		registry: registry,
+	}
+	manager.AddListener(tc.OnEvent)
+	t.Cleanup(func() { manager.StopAll(context.Background(), "test over") })
+	return tc
+}
+
+// runs sessions with the SIDs on the local node
+func (tc *testCluster) run(t *testing.T, sids ...string) {
+	t.Helper()
+	for _, sid := range sids {
+		session := stream.NewSession(&skyegresspb.StartSessionRequest{Path: sid, RoomName: "room", TrackName: sid})
+		if _, err := tc.manager.AddStream(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// publishes another node to the registry, running sessions with the SIDs, for as long as the ttl
+func (tc *testCluster) publish(t *testing.T, node *skyegresspb.NodeInfo, ttl time.Duration, sids ...string) {
+	t.Helper()
+	if len(node.RtspUrl) == 0 {
+		node.RtspUrl = fmt.Sprintf("rtsp://%s:8554", node.Id)
+	}
+	sessions := []*skyegresspb.Session{}
+	for _, sid := range sids {
+		sessions = append(sessions, &skyegresspb.Session{Sid: sid, NodeId: node.Id})
+	}
+	if err := tc.registry.Publish(context.Background(), node, sessions, ttl); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlace(t *testing.T) {
+	tests := []struct {
+		name string
+		// sessions running on the local node, b
+		local    int
+		draining bool
+		nodes    []*skyegresspb.NodeInfo
+		// published so long ago that they've expired
+		stale []*skyegresspb.NodeInfo
+		want  string
+	}{
+		{
+			name: "on its own",
+			want: "b",
+		},
+		{
+			name:  "fewest sessions",
+			local: 2,
+			nodes: []*skyegresspb.NodeInfo{{Id: "a", Sessions: 3}, {Id: "c", Sessions: 1}},
+			want:  "c",
+		},
+		{
+			name:  "least bitrate between equally loaded nodes",
+			local: 1,
+			nodes: []*skyegresspb.NodeInfo{{Id: "a", Sessions: 1, Bitrate: 4000}, {Id: "c", Sessions: 1, Bitrate: 2000}},
+			want:  "b",
+		},
+		{
+			name:  "lowest ID between identical nodes",
+			nodes: []*skyegresspb.NodeInfo{{Id: "c"}, {Id: "a"}},
+			want:  "a",
+		},
+		{
+			name:  "skips draining nodes",
+			local: 3,
+			nodes: []*skyegresspb.NodeInfo{{Id: "a", Draining: true}, {Id: "c", Sessions: 2}},
+			want:  "c",
+		},
+		{
+			name:  "skips nodes at capacity",
+			local: 3,
+			nodes: []*skyegresspb.NodeInfo{{Id: "a", AtCapacity: true}, {Id: "c", Sessions: 2}},
+			want:  "c",
+		},
+		{
+			name:  "skips stale nodes",
+			local: 3,
+			stale: []*skyegresspb.NodeInfo{{Id: "a"}},
+			want:  "b",
+		},
+		{
+			name:     "skips this node while draining",
+			draining: true,
+			nodes:    []*skyegresspb.NodeInfo{{Id: "a", Sessions: 10}},
+			want:     "a",
+		},
+		{
+			name:     "no node available",
+			draining: true,
+			nodes:    []*skyegresspb.NodeInfo{{Id: "a", Draining: true}, {Id: "c", AtCapacity: true}},
+			stale:    []*skyegresspb.NodeInfo{{Id: "d"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tc := newTestCluster(t, "b")
+			for i := 0; i < test.local; i++ {
+				tc.run(t, fmt.Sprintf("local/%d", i))
+			}
+			if test.draining {
+				tc.manager.Drain()
+			}
+			for _, node := range test.nodes {
+				tc.publish(t, node, testHeartbeat)
+			}
+			for _, node := range test.stale {
+				tc.publish(t, node, -time.Second)
+			}
+
+			node, err := tc.Place(context.Background())
+			if len(test.want) == 0 {
+				if !errors.Is(err, ErrNoNodeAvailable) || !errors.Is(err, stream.ErrResourceExhausted) {
+					t.Fatalf("got node %v, error %v, want no node available", node, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if node.Id != test.want {
+				t.Errorf("placed on %s, want %s", node.Id, test.want)
+			}
+		})
+	}
+}
+
+func TestOwnerAndLocate(t *testing.T) {
+	ctx := context.Background()
+	tc := newTestCluster(t, "b")
+	tc.run(t, "here/cam")
+	tc.publish(t, &skyegresspb.NodeInfo{Id: "a"}, testHeartbeat, "there/cam")
+	tc.publish(t, &skyegresspb.NodeInfo{Id: "c"}, -time.Second, "gone/cam")
+
+	tests := []struct {
+		sid   string
+		owner string
+		// the RTSP URL readers are redirected to
+		redirect string
+	}{
+		{sid: "here/cam", owner: "b"},
+		{sid: "there/cam", owner: "a", redirect: "rtsp://a:8554"},
+		{sid: "gone/cam"},
+		{sid: "nowhere/cam"},
+	}
+	for _, test := range tests {
+		owner, ok, err := tc.Owner(ctx, test.sid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != (len(test.owner) > 0) || (ok && owner.Id != test.owner) {
+			t.Errorf("%s: got owner %v %t, want %q", test.sid, owner.GetId(), ok, test.owner)
+		}
+
+		url, ok, err := tc.Locate(ctx, test.sid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != (len(test.redirect) > 0) || url != test.redirect {
+			t.Errorf("%s: located at %q %t, want %q", test.sid, url, ok, test.redirect)
+		}
+	}
+
+	sessions, err := tc.Sessions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sids := map[string]string{}
+	for _, session := range sessions {
+		sids[session.Sid] = session.NodeId
+	}
+	if len(sids) != 2 || sids["here/cam"] != "b" || sids["there/cam"] != "a" {
+		t.Errorf("got sessions %v", sids)
+	}
+}
+
+func TestNodeExpires(t *testing.T) {
+	ctx := context.Background()
+	tc := newTestCluster(t, "b")
+	tc.run(t, "here/cam")
+	tc.publish(t, &skyegresspb.NodeInfo{Id: "a"}, 50*time.Millisecond, "there/cam")
+
+	if owner, ok, _ := tc.Owner(ctx, "there/cam"); !ok || owner.Id != "a" {
+		t.Fatalf("session isn't owned by the published node")
+	}
+	if node, _ := tc.Place(ctx); node.Id != "a" {
+		t.Fatalf("placed on %s before the node expired, want a", node.Id)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, ok, _ := tc.Owner(ctx, "there/cam"); ok {
+		t.Error("session still owned by a node that stopped publishing")
+	}
+	nodes, err := tc.registry.Nodes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 0 {
+		t.Errorf("got %d nodes after the only other one expired", len(nodes))
+	}
+	if node, _ := tc.Place(ctx); node.Id != "b" {
+		t.Errorf("placed on %s after the other node expired, want b", node.Id)
+	}
+}
+
+func TestShouldRun(t *testing.T) {
+	tests := []struct {
+		name  string
+		local []string
+		nodes []*skyegresspb.NodeInfo
+		// SIDs of the sessions each node runs
+		running map[string][]string
+		// node that claimed the SID, if any
+		claimedBy string
+		want      bool
+	}{
+		{
+			name: "placed here",
+			want: true,
+		},
+		{
+			name:  "placed on another node",
+			local: []string{"other/1", "other/2"},
+			nodes: []*skyegresspb.NodeInfo{{Id: "c"}},
+			want:  false,
+		},
+		{
+			name:    "running on another node",
+			nodes:   []*skyegresspb.NodeInfo{{Id: "c", Sessions: 5}},
+			running: map[string][]string{"c": {"room/cam"}},
+			want:    false,
+		},
+		{
+			name:      "claimed by another node starting it",
+			nodes:     []*skyegresspb.NodeInfo{{Id: "c", Sessions: 5}},
+			claimedBy: "c",
+			want:      false,
+		},
+		{
+			name:  "already running here",
+			local: []string{"room/cam", "other/1"},
+			nodes: []*skyegresspb.NodeInfo{{Id: "c"}},
+			want:  true,
+		},
+		{
+			name:    "also running on a node with a lower ID",
+			local:   []string{"room/cam"},
+			nodes:   []*skyegresspb.NodeInfo{{Id: "a", Sessions: 1}},
+			running: map[string][]string{"a": {"room/cam"}},
+			want:    false,
+		},
+		{
+			name:    "also running on a node with a higher ID",
+			local:   []string{"room/cam"},
+			nodes:   []*skyegresspb.NodeInfo{{Id: "c", Sessions: 1}},
+			running: map[string][]string{"c": {"room/cam"}},
+			want:    true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			tc := newTestCluster(t, "b")
+			tc.run(t, test.local...)
+			for _, node := range test.nodes {
+				tc.publish(t, node, testHeartbeat, test.running[node.Id]...)
+			}
+			if len(test.claimedBy) > 0 {
+				tc.registry.Claim(ctx, "room/cam", test.claimedBy, testHeartbeat)
+			}
+
+			run, err := tc.ShouldRun(ctx, "room/cam")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if run != test.want {
+				t.Fatalf("got should run %t, want %t", run, test.want)
+			}
+			// a node about to start the session holds it, so no other does too
+			if run && len(test.local) == 0 {
+				if holder, _ := tc.registry.Claim(ctx, "room/cam", "c", testHeartbeat); holder != "b" {
+					t.Errorf("session claimed by %s, want b", holder)
+				}
+			}
+		})
+	}
+}
+
+func TestClaim(t *testing.T) {
+	ctx := context.Background()
+	tc := newTestCluster(t, "b")
+
+	claims := []struct {
+		node string
+		want string
+	}{
+		{"a", "a"},
+		// another node handling the same start
+		{"b", "a"},
+		// claiming again keeps it
+		{"a", "a"},
+	}
+	for _, claim := range claims {
+		holder, err := tc.Claim(ctx, "room/cam", claim.node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if holder != claim.want {
+			t.Errorf("%s claiming got holder %s, want %s", claim.node, holder, claim.want)
+		}
+	}
+
+	// only the holder gives it up
+	tc.Release(ctx, "room/cam", "b")
+	if holder, _ := tc.Claim(ctx, "room/cam", "b"); holder != "a" {
+		t.Errorf("claim released by a node not holding it")
+	}
+	tc.Release(ctx, "room/cam", "a")
+	if holder, _ := tc.Claim(ctx, "room/cam", "b"); holder != "b" {
+		t.Errorf("claim not released by the node holding it")
+	}
+
+	// a claim lasts as long as the node's publish
+	tc.registry.Claim(ctx, "expiring/cam", "a", 50*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	if holder, _ := tc.Claim(ctx, "expiring/cam", "b"); holder != "b" {
+		t.Errorf("claim by %s didn't expire", holder)
+	}
+}
+
+func TestClaimReleasedOnStop(t *testing.T) {
+	ctx := context.Background()
+	tc := newTestCluster(t, "b")
+
+	if holder, _ := tc.Claim(ctx, "room/cam", "b"); holder != "b" {
+		t.Fatalf("session claimed by %s", holder)
+	}
+	tc.run(t, "room/cam")
+	tc.manager.RemoveStream("room/cam", "stopped")
+
+	if holder, _ := tc.Claim(ctx, "room/cam", "a"); holder != "a" {
+		t.Errorf("session still claimed by %s after it stopped", holder)
+	}
+}
@@ -0,0 +1,193 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// set of the IDs of every node that has published; expired nodes are pruned when listed
+	nodesKey = "skyegress:nodes"
+)
+
+func nodeKey(nodeID string) string {
+	return "skyegress:node:" + nodeID
+}
+
+// hash of SID to session
+func sessionsKey(nodeID string) string {
+	return "skyegress:node:" + nodeID + ":sessions"
+}
+
+// ID of the node that claimed the SID to start it
+func claimKey(sid string) string {
+	return "skyegress:claim:" + sid
+}
+
+var (
+	// extends the claim if the node still holds it
+	renewClaim = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	// deletes the claim if the node still holds it
+	releaseClaim = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// registry shared by the nodes of a cluster through Redis
+type redisRegistry struct {
+	client *redis.Client
+}
+
+func NewRedisRegistry(url string) (*redisRegistry, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	return &redisRegistry{client: redis.NewClient(opts)}, nil
+}
+
+func (rr *redisRegistry) Publish(
+	ctx context.Context,
+	node *skyegresspb.NodeInfo,
+	sessions []*skyegresspb.Session,
+	ttl time.Duration,
+) error {
+	nodeb, err := proto.Marshal(node)
+	if err != nil {
+		return err
+	}
+	fields := make([]interface{}, 0, 2*len(sessions))
+	for _, session := range sessions {
+		sessionb, err := proto.Marshal(session)
+		if err != nil {
+			return err
+		}
+		fields = append(fields, session.Sid, sessionb)
+	}
+
+	// readers never see the sessions half replaced
+	_, err = rr.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, nodeKey(node.Id), nodeb, ttl)
+		pipe.SAdd(ctx, nodesKey, node.Id)
+		pipe.Del(ctx, sessionsKey(node.Id))
+		if len(fields) > 0 {
+			pipe.HSet(ctx, sessionsKey(node.Id), fields...)
+			pipe.Expire(ctx, sessionsKey(node.Id), ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+func (rr *redisRegistry) Remove(ctx context.Context, nodeID string) error {
+	_, err := rr.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, nodeKey(nodeID), sessionsKey(nodeID))
+		pipe.SRem(ctx, nodesKey, nodeID)
+		return nil
+	})
+	return err
+}
+
+func (rr *redisRegistry) Nodes(ctx context.Context) ([]*skyegresspb.NodeInfo, error) {
+	ids, err := rr.client.SMembers(ctx, nodesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, nodeKey(id))
+	}
+	values, err := rr.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*skyegresspb.NodeInfo, 0, len(ids))
+	expired := []interface{}{}
+	for i, value := range values {
+		nodeb, ok := value.(string)
+		if !ok {
+			expired = append(expired, ids[i])
+			continue
+		}
+		node := &skyegresspb.NodeInfo{}
+		if err := proto.Unmarshal([]byte(nodeb), node); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(expired) > 0 {
+		rr.client.SRem(ctx, nodesKey, expired...)
+	}
+	return nodes, nil
+}
+
+func (rr *redisRegistry) Sessions(ctx context.Context, nodeID string) ([]*skyegresspb.Session, error) {
+	values, err := rr.client.HGetAll(ctx, sessionsKey(nodeID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*skyegresspb.Session, 0, len(values))
+	for _, sessionb := range values {
+		session := &skyegresspb.Session{}
+		if err := proto.Unmarshal([]byte(sessionb), session); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func (rr *redisRegistry) Claim(ctx context.Context, sid string, nodeID string, ttl time.Duration) (string, error) {
+	key := claimKey(sid)
+	// the claim can expire between the steps, so try again if it's gone
+	for i := 0; i < 2; i++ {
+		claimed, err := rr.client.SetNX(ctx, key, nodeID, ttl).Result()
+		if err != nil {
+			return "", err
+		}
+		if claimed {
+			return nodeID, nil
+		}
+
+		holder, err := rr.client.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if holder != nodeID {
+			return holder, nil
+		}
+		renewed, err := renewClaim.Run(ctx, rr.client, []string{key}, nodeID, ttl.Milliseconds()).Int()
+		if err != nil {
+			return "", err
+		}
+		if renewed == 1 {
+			return nodeID, nil
+		}
+	}
+	return "", fmt.Errorf("unable to claim session %s", sid)
+}
+
+func (rr *redisRegistry) Release(ctx context.Context, sid string, nodeID string) error {
+	return releaseClaim.Run(ctx, rr.client, []string{claimKey(sid)}, nodeID).Err()
+}
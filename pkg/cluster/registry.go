@@ -0,0 +1,134 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"google.golang.org/protobuf/proto"
+)
+
+// where the nodes of a cluster publish their load and sessions for each other
+type Registry interface {
+	// records the node and its sessions, replacing whatever it published before; they expire unless published again
+	// within the ttl
+	Publish(ctx context.Context, node *skyegresspb.NodeInfo, sessions []*skyegresspb.Session, ttl time.Duration) error
+	// removes the node and its sessions straight away
+	Remove(ctx context.Context, nodeID string) error
+	// nodes that have published within their ttl
+	Nodes(ctx context.Context) ([]*skyegresspb.NodeInfo, error)
+	// sessions last published by the node
+	Sessions(ctx context.Context, nodeID string) ([]*skyegresspb.Session, error)
+	// claims the SID for the node ahead of it starting the session, unless another node holds a claim on it that
+	// hasn't expired; returns the node holding the claim. A node claiming a SID again keeps it for another ttl.
+	Claim(ctx context.Context, sid string, nodeID string, ttl time.Duration) (string, error)
+	// gives up the node's claim on the SID, if it still holds it
+	Release(ctx context.Context, sid string, nodeID string) error
+}
+
+type memoryEntry struct {
+	node      *skyegresspb.NodeInfo
+	sessions  []*skyegresspb.Session
+	expiresAt time.Time
+}
+
+type memoryClaim struct {
+	nodeID    string
+	expiresAt time.Time
+}
+
+// registry for a node running on its own, or nodes sharing a process
+type memoryRegistry struct {
+	lock   sync.Mutex
+	nodes  map[string]*memoryEntry
+	claims map[string]memoryClaim
+}
+
+func NewMemoryRegistry() *memoryRegistry {
+	return &memoryRegistry{
+		nodes:  make(map[string]*memoryEntry),
+		claims: make(map[string]memoryClaim),
+	}
+}
+
+func (mr *memoryRegistry) Publish(
+	ctx context.Context,
+	node *skyegresspb.NodeInfo,
+	sessions []*skyegresspb.Session,
+	ttl time.Duration,
+) error {
+	entry := &memoryEntry{
+		node:      proto.Clone(node).(*skyegresspb.NodeInfo),
+		sessions:  make([]*skyegresspb.Session, 0, len(sessions)),
+		expiresAt: time.Now().Add(ttl),
+	}
+	for _, session := range sessions {
+		entry.sessions = append(entry.sessions, proto.Clone(session).(*skyegresspb.Session))
+	}
+
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	mr.nodes[node.Id] = entry
+	return nil
+}
+
+func (mr *memoryRegistry) Remove(ctx context.Context, nodeID string) error {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+	delete(mr.nodes, nodeID)
+	return nil
+}
+
+func (mr *memoryRegistry) Nodes(ctx context.Context) ([]*skyegresspb.NodeInfo, error) {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+
+	now := time.Now()
+	nodes := make([]*skyegresspb.NodeInfo, 0, len(mr.nodes))
+	for id, entry := range mr.nodes {
+		if now.After(entry.expiresAt) {
+			delete(mr.nodes, id)
+			continue
+		}
+		nodes = append(nodes, proto.Clone(entry.node).(*skyegresspb.NodeInfo))
+	}
+	return nodes, nil
+}
+
+func (mr *memoryRegistry) Sessions(ctx context.Context, nodeID string) ([]*skyegresspb.Session, error) {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+
+	entry, ok := mr.nodes[nodeID]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, nil
+	}
+	sessions := make([]*skyegresspb.Session, 0, len(entry.sessions))
+	for _, session := range entry.sessions {
+		sessions = append(sessions, proto.Clone(session).(*skyegresspb.Session))
+	}
+	return sessions, nil
+}
+
+func (mr *memoryRegistry) Claim(ctx context.Context, sid string, nodeID string, ttl time.Duration) (string, error) {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+
+	now := time.Now()
+	if claim, ok := mr.claims[sid]; ok && claim.nodeID != nodeID && now.Before(claim.expiresAt) {
+		return claim.nodeID, nil
+	}
+	mr.claims[sid] = memoryClaim{nodeID: nodeID, expiresAt: now.Add(ttl)}
+	return nodeID, nil
+}
+
+func (mr *memoryRegistry) Release(ctx context.Context, sid string, nodeID string) error {
+	mr.lock.Lock()
+	defer mr.lock.Unlock()
+
+	if claim, ok := mr.claims[sid]; ok && claim.nodeID == nodeID {
+		delete(mr.claims, sid)
+	}
+	return nil
+}
@@ -1,11 +1,23 @@
 package cmd
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
+	"github.com/livekit/protocol/auth"
+	lkproto "github.com/livekit/protocol/livekit"
 	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/config"
 	"github.com/treyhaknson/skyegress/pkg/util"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 type ClientCmd struct {
@@ -14,15 +26,71 @@ type ClientCmd struct {
 	Start ClientStartCmd `kong:"cmd,help='Start an egress session'"`
 	List  ClientListCmd  `kong:"cmd,help='List active egress sessions'"`
 	Stop  ClientStopCmd  `kong:"cmd,help='Start an egress session'"`
+	Watch ClientWatchCmd `kong:"cmd,help='Watch egress sessions change live'"`
+
+	Retarget ClientRetargetCmd `kong:"cmd,help='Switch an egress session to another room or track without dropping readers'"`
+
+	Viewers ClientViewersCmd `kong:"cmd,help='List the RTSP readers of an egress session'"`
+	Kick    ClientKickCmd    `kong:"cmd,help='Disconnect an RTSP reader from an egress session'"`
+	Clock   ClientClockCmd   `kong:"cmd,help='Show the wall-clock capture time of the RTP an egress session is sending'"`
+	Capture ClientCaptureCmd `kong:"cmd,help='Capture the RTP and RTCP an egress session receives to a pcapng file on the server'"`
+	Drain   ClientDrainCmd   `kong:"cmd,help='Stop the server accepting sessions, and shut it down once its sessions end'"`
+
+	Webhook ClientWebhookCmd `kong:"cmd,help='Send a signed sample LiveKit webhook to the server'"`
 }
 
 type ClientStartCmd struct {
-	RoomName  string `kong:"help='Name of the LiveKit room to join'"`
-	TrackName string `kong:"help='Name of the track in the LiveKit room to egress'"`
+	RoomName  string   `kong:"help='Name of the LiveKit room to join'"`
+	TrackName string   `kong:"help='Name of the track in the LiveKit room to egress'"`
+	Path      string   `kong:"help='RTSP path to serve the session on (defaults to <room-name>/<track-name>, or testpattern/<variant>; required with other sources)'"`
+	Identity  string   `kong:"help='Only egress the track when published by this participant'"`
+	Webhooks  []string `kong:"help='URLs to deliver lifecycle events for the session to'"`
+
+	BackupTrackName string        `kong:"help='Track in the same room to relay while the primary track is not delivering packets'"`
+	BackupIdentity  string        `kong:"help='Only use the backup track when published by this participant'"`
+	FailoverTimeout time.Duration `kong:"help='How long the primary track may go without packets before the backup is relayed (defaults to 3s)'"`
+
+	SEI        string   `kong:"name='sei',enum='off,keyframes,all',default='off',help='Frames to precede with an SEI carrying their capture time and the session details (off, keyframes or all)'"`
+	Processors []string `kong:"name='processor',help='Processor to pass packets through on their way to readers, such as strip-sei; repeat for several, in order'"`
+	Sinks      []string `kong:"name='sink',help='Sink to send the session to alongside RTSP readers, such as annexb; repeat for several'"`
+
+	Source string `kong:"help='Play this source instead of a LiveKit room: file:///clip.mp4 (or .h264, .pcap), rtsp://camera/stream, or testpattern:1080p30 (240p15, 360p30, 480p30, 720p30, 720p60, 1080p25, 1080p30, 1080p60, 2160p30)'"`
+	Replay string `kong:"help='Replay a capture taken with client capture once, from its path on the server; short for --source file://<path>?loop=false'"`
+
+	Ingress bool `kong:"help='Pull --source, an RTSP URL, and publish its H264 video and any Opus audio into --room-name as --track-name (defaults to camera), joining as --identity'"`
+}
+
+var seiModes = map[string]skyegresspb.SeiMode{
+	"off":       skyegresspb.SeiMode_SEI_MODE_OFF,
+	"keyframes": skyegresspb.SeiMode_SEI_MODE_KEYFRAMES,
+	"all":       skyegresspb.SeiMode_SEI_MODE_ALL_FRAMES,
 }
 
 func (cs *ClientStartCmd) Run(cmn *ClientCmd) error {
-	req := &skyegresspb.StartSessionRequest{RoomName: cs.RoomName, TrackName: cs.TrackName}
+	if len(cs.Replay) > 0 {
+		replay := url.URL{Scheme: "file", Path: cs.Replay, RawQuery: "loop=false"}
+		cs.Source = replay.String()
+	}
+	req := &skyegresspb.StartSessionRequest{
+		RoomName:            cs.RoomName,
+		TrackName:           cs.TrackName,
+		Path:                cs.Path,
+		ParticipantIdentity: cs.Identity,
+		WebhookUrls:         cs.Webhooks,
+
+		BackupTrackName:           cs.BackupTrackName,
+		BackupParticipantIdentity: cs.BackupIdentity,
+		FailoverTimeoutMs:         uint32(cs.FailoverTimeout.Milliseconds()),
+
+		SeiMode:    seiModes[cs.SEI],
+		Processors: cs.Processors,
+		Sinks:      cs.Sinks,
+
+		Source: cs.Source,
+	}
+	if cs.Ingress {
+		req.Kind = skyegresspb.SessionKind_SESSION_KIND_INGRESS
+	}
 	res := &skyegresspb.StartSessionResponse{}
 	pc := util.NewProtoClient(cmn.URL)
 	err := pc.Request(util.POST, "/session/start", req, res)
@@ -74,8 +142,270 @@ func (cl *ClientListCmd) Run(cmn *ClientCmd) error {
 		panic(errors.New(res.GetError()))
 	case *skyegresspb.ListSessionsResponse_Sessions:
 		for i, session := range res.GetSessions().Sessions {
-			fmt.Printf("%d\t%s\t%s\t%s\n", i, session.RoomName, session.TrackName, session.EgressIdentity)
+			fmt.Printf("%d\t%s\t%s\t%s\t%s\t%s\n", i, session.Sid, session.RoomName, session.TrackName, session.EgressIdentity, session.State)
+			switch {
+			case session.Kind == skyegresspb.SessionKind_SESSION_KIND_INGRESS:
+				fmt.Printf("\tingress from %s, publishing as %s\n", session.Source, session.EgressIdentity)
+			case session.Kind == skyegresspb.SessionKind_SESSION_KIND_PUBLISH:
+				fmt.Printf("\tpushed by RTSP publisher %s, publishing as %s\n", session.Source, session.EgressIdentity)
+			case len(session.Source) > 0:
+				fmt.Printf("\tsource %s\n", session.Source)
+			}
+			if len(session.BackupTrackName) > 0 {
+				fmt.Printf("\tbackup %s, relaying %s\n", session.BackupTrackName, session.ActiveSource)
+			}
+			if len(session.Processors) > 0 {
+				fmt.Printf("\tprocessors %s\n", strings.Join(session.Processors, " -> "))
+			}
+			if stats := session.Stats; stats != nil {
+				fmt.Printf(
+					"\t%dx%d %.1ffps %dkbps, keyframe every %dms, %d packets lost, %d PLIs sent\n",
+					stats.Width, stats.Height, stats.FrameRate, stats.InputBitrate/1000,
+					stats.KeyframeIntervalMs, stats.PacketsLost, stats.PlisSent,
+				)
+				if stats.SlateActive {
+					fmt.Println("\tsource missing, sending slate")
+				}
+				for _, reader := range stats.Readers {
+					fmt.Printf("\treader %s %s over %s, %d bytes sent\n", reader.Id, reader.Address, reader.Transport, reader.BytesSent)
+				}
+				for _, sink := range stats.Sinks {
+					state := "open"
+					if sink.Closed {
+						state = "closed"
+					}
+					fmt.Printf(
+						"\tsink %s %s, %d written, %d dropped, %d errors, %d buffered\n",
+						sink.Name, state, sink.Written, sink.Dropped, sink.Errors, sink.Buffered,
+					)
+				}
+				if capture := stats.Capture; capture != nil {
+					printCapture("\t", capture)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type ClientRetargetCmd struct {
+	Sid       string `kong:"required,help='SID of the session to switch'"`
+	RoomName  string `kong:"help='Name of the LiveKit room to switch to'"`
+	TrackName string `kong:"help='Name of the track in the LiveKit room to switch to'"`
+	Identity  string `kong:"help='Only egress the track when published by this participant'"`
+}
+
+func (cr *ClientRetargetCmd) Run(cmn *ClientCmd) error {
+	req := &skyegresspb.RetargetSessionRequest{
+		Sid:                 cr.Sid,
+		RoomName:            cr.RoomName,
+		TrackName:           cr.TrackName,
+		ParticipantIdentity: cr.Identity,
+	}
+	res := &skyegresspb.RetargetSessionResponse{}
+	pc := util.NewProtoClient(cmn.URL)
+	err := pc.Request(util.POST, "/session/retarget", req, res)
+	if err != nil {
+		panic(err)
+	}
+	switch res.Result.(type) {
+	case *skyegresspb.RetargetSessionResponse_Error:
+		panic(errors.New(res.GetError()))
+	case *skyegresspb.RetargetSessionResponse_Session:
+		fmt.Printf("Successfully retargeted session %+v", res.GetSession())
+	}
+	return nil
+}
+
+type ClientViewersCmd struct {
+	Sid string `kong:"required,help='SID of the session to list readers for'"`
+}
+
+func (cv *ClientViewersCmd) Run(cmn *ClientCmd) error {
+	req := &skyegresspb.ListViewersRequest{Sid: cv.Sid}
+	res := &skyegresspb.ListViewersResponse{}
+	pc := util.NewProtoClient(cmn.URL)
+	err := pc.Request(util.POST, "/session/viewers", req, res)
+	if err != nil {
+		panic(err)
+	}
+	switch res.Result.(type) {
+	case *skyegresspb.ListViewersResponse_Error:
+		panic(errors.New(res.GetError()))
+	case *skyegresspb.ListViewersResponse_Viewers:
+		for i, viewer := range res.GetViewers().Viewers {
+			connectedAt := time.UnixMilli(viewer.ConnectedAt).Format(time.RFC3339)
+			fmt.Printf("%d\t%s\t%s\t%s\t%d\t%s\n", i, viewer.Id, viewer.Address, viewer.Transport, viewer.BytesSent, connectedAt)
+		}
+	}
+	return nil
+}
+
+type ClientClockCmd struct {
+	Sid string `kong:"required,help='SID of the session to show the clock of'"`
+}
+
+func (cc *ClientClockCmd) Run(cmn *ClientCmd) error {
+	req := &skyegresspb.GetClockRequest{Sid: cc.Sid}
+	res := &skyegresspb.GetClockResponse{}
+	pc := util.NewProtoClient(cmn.URL)
+	err := pc.Request(util.POST, "/session/clock", req, res)
+	if err != nil {
+		panic(err)
+	}
+	switch res.Result.(type) {
+	case *skyegresspb.GetClockResponse_Error:
+		panic(errors.New(res.GetError()))
+	case *skyegresspb.GetClockResponse_Clock:
+		clock := res.GetClock()
+		origin := "packet arrival"
+		if clock.FromSenderReport {
+			origin = "sender report from " + time.UnixMilli(clock.SenderReportAt).Format(time.RFC3339)
+		} else if clock.FromSource {
+			origin = "the source's own timing"
 		}
+		wallClock := time.Unix(0, clock.WallClockNs).Format(time.RFC3339Nano)
+		fmt.Printf("rtp %d (ssrc %d, %dHz) = %s, from %s\n", clock.RtpTimestamp, clock.Ssrc, clock.ClockRate, wallClock, origin)
 	}
 	return nil
 }
+
+type ClientKickCmd struct {
+	Sid      string `kong:"required,help='SID of the session the reader is connected to'"`
+	ViewerId string `kong:"required,help='ID of the reader to disconnect, as shown by viewers'"`
+}
+
+func (ck *ClientKickCmd) Run(cmn *ClientCmd) error {
+	req := &skyegresspb.KickViewerRequest{Sid: ck.Sid, ViewerId: ck.ViewerId}
+	res := &skyegresspb.KickViewerResponse{}
+	pc := util.NewProtoClient(cmn.URL)
+	err := pc.Request(util.POST, "/session/viewers/kick", req, res)
+	if err != nil {
+		panic(err)
+	}
+	switch res.Result.(type) {
+	case *skyegresspb.KickViewerResponse_Error:
+		panic(errors.New(res.GetError()))
+	case *skyegresspb.KickViewerResponse_Viewer:
+		fmt.Printf("Successfully disconnected viewer %+v", res.GetViewer())
+	}
+	return nil
+}
+
+type ClientCaptureCmd struct {
+	Sid     string `kong:"required,help='SID of the session to capture'"`
+	Stop    bool   `kong:"help='Stop the current capture rather than starting one'"`
+	MaxSize uint64 `kong:"help='Size in bytes the capture is stopped at (at most, and by default, --capture-max-size of the server)'"`
+}
+
+func (cc *ClientCaptureCmd) Run(cmn *ClientCmd) error {
+	req := &skyegresspb.CaptureSessionRequest{Sid: cc.Sid, Enabled: !cc.Stop, MaxBytes: cc.MaxSize}
+	res := &skyegresspb.CaptureSessionResponse{}
+	pc := util.NewProtoClient(cmn.URL)
+	err := pc.Request(util.POST, "/session/capture", req, res)
+	if err != nil {
+		panic(err)
+	}
+	switch res.Result.(type) {
+	case *skyegresspb.CaptureSessionResponse_Error:
+		panic(errors.New(res.GetError()))
+	case *skyegresspb.CaptureSessionResponse_Capture:
+		printCapture("", res.GetCapture())
+	}
+	return nil
+}
+
+func printCapture(indent string, capture *skyegresspb.CaptureStats) {
+	state := "capturing"
+	if !capture.Active {
+		state = "stopped (" + capture.StoppedReason + ")"
+	}
+	fmt.Printf(
+		"%scapture %s %s, %d packets, %d dropped, %d of %d bytes\n",
+		indent, capture.Path, state, capture.Packets, capture.Dropped, capture.Bytes, capture.MaxBytes,
+	)
+}
+
+type ClientDrainCmd struct {
+	Timeout time.Duration `kong:"help='How long existing sessions may keep running before they are stopped (defaults to the server setting)'"`
+}
+
+func (cd *ClientDrainCmd) Run(cmn *ClientCmd) error {
+	req := &skyegresspb.DrainNodeRequest{TimeoutMs: cd.Timeout.Milliseconds()}
+	res := &skyegresspb.DrainNodeResponse{}
+	pc := util.NewProtoClient(cmn.URL)
+	err := pc.Request(util.POST, "/node/drain", req, res)
+	if err != nil {
+		panic(err)
+	}
+	switch res.Result.(type) {
+	case *skyegresspb.DrainNodeResponse_Error:
+		panic(errors.New(res.GetError()))
+	case *skyegresspb.DrainNodeResponse_Status:
+		status := res.GetStatus()
+		deadline := time.UnixMilli(status.DrainDeadline).Format(time.RFC3339)
+		fmt.Printf("Draining; %d sessions will be stopped by %s\n", status.Sessions, deadline)
+	}
+	return nil
+}
+
+type ClientWebhookCmd struct {
+	Event     string `kong:"required,enum='room_finished,participant_left,track_published,track_unpublished',help='LiveKit webhook event to send'"`
+	RoomName  string `kong:"required,help='Name of the LiveKit room the event is for'"`
+	Identity  string `kong:"help='Identity of the participant the event is for'"`
+	Metadata  string `kong:"help='Metadata of the participant the event is for'"`
+	TrackName string `kong:"help='Name of the video track the event is for'"`
+	Source    string `kong:"default='CAMERA',help='Source of the video track the event is for'"`
+}
+
+func (cw *ClientWebhookCmd) Run(cmn *ClientCmd, cfg *config.Config) error {
+	event := &lkproto.WebhookEvent{
+		Event: cw.Event,
+		Room:  &lkproto.Room{Name: cw.RoomName},
+	}
+	if len(cw.Identity) > 0 {
+		event.Participant = &lkproto.ParticipantInfo{Identity: cw.Identity, Metadata: cw.Metadata}
+	}
+	if len(cw.TrackName) > 0 {
+		event.Track = &lkproto.TrackInfo{
+			Name:   cw.TrackName,
+			Type:   lkproto.TrackType_VIDEO,
+			Source: lkproto.TrackSource(lkproto.TrackSource_value[strings.ToUpper(cw.Source)]),
+		}
+	}
+
+	body, err := protojson.Marshal(event)
+	if err != nil {
+		panic(err)
+	}
+
+	// signed the same way the LiveKit server signs its webhooks
+	sum := sha256.Sum256(body)
+	token, err := auth.NewAccessToken(cfg.LiveKitConfig.ApiKey, cfg.LiveKitConfig.ApiSecret).
+		SetValidFor(5 * time.Minute).
+		SetSha256(base64.StdEncoding.EncodeToString(sum[:])).
+		ToJWT()
+	if err != nil {
+		panic(err)
+	}
+
+	endpoint := fmt.Sprintf("%s/webhook/livekit", cmn.URL)
+	req, err := http.NewRequest(string(util.POST), endpoint, bytes.NewReader(body))
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set("Authorization", token)
+	req.Header.Set("Content-Type", "application/webhook+json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		panic(fmt.Errorf("webhook rejected with status %d: %s", resp.StatusCode, msg))
+	}
+
+	fmt.Printf("Sent %s webhook for room %s\n", cw.Event, cw.RoomName)
+	return nil
+}
@@ -6,31 +6,120 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/aler9/gortsplib/v2"
+	"github.com/livekit/protocol/utils"
 
+	"github.com/treyhaknson/skyegress/pkg/cluster"
 	"github.com/treyhaknson/skyegress/pkg/config"
+	"github.com/treyhaknson/skyegress/pkg/events"
+	"github.com/treyhaknson/skyegress/pkg/notify"
+	"github.com/treyhaknson/skyegress/pkg/reconcile"
+	"github.com/treyhaknson/skyegress/pkg/rules"
 	"github.com/treyhaknson/skyegress/pkg/service"
 	"github.com/treyhaknson/skyegress/pkg/stream"
 )
 
 type ServeCmd struct {
-	HTTPConfig config.HTTPConfig `kong:"embed,prefix='http-'"`
-	RTSPConfig config.RTSPConfig `kong:"embed,prefix='rtsp-'"`
+	HTTPConfig     config.HTTPConfig     `kong:"embed,prefix='http-'"`
+	RTSPConfig     config.RTSPConfig     `kong:"embed,prefix='rtsp-'"`
+	SessionsConfig config.SessionsConfig `kong:"embed,prefix='sessions-'"`
+	RulesConfig    config.RulesConfig    `kong:"embed,prefix='rules-'"`
+	WebhookConfig  config.WebhookConfig  `kong:"embed,prefix='webhook-'"`
+	EventsConfig   config.EventsConfig   `kong:"embed,prefix='events-'"`
+	CapacityConfig config.CapacityConfig `kong:"embed,prefix='max-'"`
+	DrainConfig    config.DrainConfig    `kong:"embed,prefix='drain-'"`
+	ClusterConfig  config.ClusterConfig  `kong:"embed,prefix='cluster-'"`
+	SlateConfig    config.SlateConfig    `kong:"embed,prefix='slate-'"`
+	SinksConfig    config.SinksConfig    `kong:"embed,prefix='sinks-'"`
+	CaptureConfig  config.CaptureConfig  `kong:"embed,prefix='capture-'"`
+	MediaConfig    config.MediaConfig    `kong:"embed,prefix='media-'"`
+	PublishConfig  config.PublishConfig  `kong:"embed,prefix='publish-'"`
 }
 
 func (sc *ServeCmd) Run(cfg *config.Config) error {
+	var autoRules []*rules.Rule
+	if len(sc.RulesConfig.File) > 0 {
+		var err error
+		autoRules, err = rules.LoadRules(sc.RulesConfig.File)
+		if err != nil {
+			return err
+		}
+	}
+
+	// anyone able to reach the RTSP port could otherwise join rooms as whoever they liked
+	if len(sc.PublishConfig.Rooms) > 0 && len(sc.PublishConfig.User) == 0 {
+		return errors.New("--publish-rooms requires --publish-user and --publish-pass")
+	}
+
+	if len(sc.ClusterConfig.NodeID) == 0 {
+		sc.ClusterConfig.NodeID = utils.NewGuid("ND_")
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	if len(sc.ClusterConfig.AdvertiseURL) == 0 {
+		sc.ClusterConfig.AdvertiseURL = fmt.Sprintf("http://%s:%d", hostname, sc.HTTPConfig.Port)
+	}
+	if len(sc.ClusterConfig.AdvertiseRTSPURL) == 0 {
+		sc.ClusterConfig.AdvertiseRTSPURL = fmt.Sprintf("rtsp://%s:%d", hostname, sc.RTSPConfig.Port)
+	}
+
+	// a node on its own is a cluster of one, with a registry nobody else reads
+	var registry cluster.Registry = cluster.NewMemoryRegistry()
+	if len(sc.ClusterConfig.RedisURL) > 0 {
+		registry, err = cluster.NewRedisRegistry(sc.ClusterConfig.RedisURL)
+		if err != nil {
+			return err
+		}
+	}
+
+	slate, err := stream.NewSlate(sc.SlateConfig)
+	if err != nil {
+		return err
+	}
+	stream.RegisterSink("annexb", stream.NewAnnexBSinkFactory(sc.SinksConfig.Dir))
+	stream.RegisterSource("file", stream.NewFileSourceFactory(sc.MediaConfig.Root, sc.CaptureConfig.Dir))
+
 	ctx, cancelCtx := context.WithCancel(context.Background())
 
 	mux := http.NewServeMux()
 
-	manager := stream.NewSkyEgressStreamManager()
-	sh := service.NewSessionHandler(cfg, &manager)
+	manager := stream.NewSkyEgressStreamManager(sc.ClusterConfig.NodeID, cfg.LiveKitConfig, sc.CapacityConfig, sc.CaptureConfig, slate)
+
+	notifier := notify.NewWebhookNotifier(sc.WebhookConfig)
+	manager.AddListener(notifier.OnEvent)
+
+	bus := events.NewBus()
+	manager.AddListener(bus.Publish)
+	go manager.EmitStats(ctx, sc.EventsConfig.StatsInterval)
+	if sc.SessionsConfig.IdleTimeout > 0 {
+		go manager.StopIdle(ctx, sc.SessionsConfig.IdleTimeout)
+	}
+
+	nodes := cluster.NewCluster(sc.ClusterConfig, registry, &manager)
+	manager.AddListener(nodes.OnEvent)
+	go nodes.Run(ctx)
+
+	sh := service.NewSessionHandler(&manager, nodes)
 	sh.Mount(mux)
 
-	hh := service.NewHealthHandler(cfg)
+	hh := service.NewHealthHandler(cfg, &manager)
 	hh.Mount(mux)
 
+	wh := service.NewWebhookHandler(cfg, &manager, nodes, autoRules)
+	wh.Mount(mux)
+
+	eh := service.NewEventsHandler(bus, &manager)
+	eh.Mount(mux)
+
+	nh := service.NewNodeHandler(sc.DrainConfig, &manager)
+	nh.Mount(mux)
+
 	httpServer := &http.Server{
 		Addr:    fmt.Sprintf(":%d", sc.HTTPConfig.Port),
 		Handler: mux,
@@ -46,8 +135,10 @@ func (sc *ServeCmd) Run(cfg *config.Config) error {
 		MulticastIPRange:  sc.RTSPConfig.MulticastIPRange,
 		MulticastRTPPort:  sc.RTSPConfig.MulticastRTPPort,
 		MulticastRTCPPort: sc.RTSPConfig.MulticastRTCPPort,
+		// streams send their own, mapped to the capture time from the publisher's
+		DisableRTCPSenderReports: true,
 	}
-	rtspHandler := service.NewRTSPHandler(&manager)
+	rtspHandler := service.NewRTSPHandler(&manager, nodes, sc.PublishConfig)
 	rtspHandler.Mount(rtspServer)
 
 	go func() {
@@ -70,7 +161,60 @@ func (sc *ServeCmd) Run(cfg *config.Config) error {
 		cancelCtx()
 	}()
 
-	<-ctx.Done()
+	if len(sc.SessionsConfig.File) > 0 {
+		sessionsFile := reconcile.NewSessionsFile(sc.SessionsConfig.File)
+		reconciler := reconcile.NewReconciler(sessionsFile, sc.SessionsConfig.Interval, &manager, nodes)
+		go reconciler.Run(ctx)
+	}
+
+	if len(autoRules) > 0 {
+		roomWatcher := rules.NewRoomWatcher(cfg.LiveKitConfig, autoRules)
+		reconciler := reconcile.NewReconciler(roomWatcher, sc.RulesConfig.Interval, &manager, nodes)
+		go reconciler.Run(ctx)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case <-ctx.Done():
+		fmt.Println("server stopped, draining")
+	case sig := <-signals:
+		fmt.Printf("received %s, draining\n", sig)
+	case <-manager.Drained():
+		fmt.Println("drain complete, shutting down")
+	}
+
+	// another signal while draining gives up on it
+	go func() {
+		sig := <-signals
+		fmt.Printf("received %s while draining, exiting\n", sig)
+		os.Exit(1)
+	}()
+
+	// refuse new work first, so nothing starts while the existing sessions are being stopped
+	manager.Drain()
+	cancelCtx()
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), sc.DrainConfig.ShutdownTimeout)
+	defer cancelShutdown()
+
+	// tear down the readers' RTSP sessions while the server is still up to do it, then stop the streams
+	readers := manager.CloseReaders(shutdownCtx)
+	stopped := manager.StopAll(shutdownCtx, "server shutting down")
+	rtspServer.Close()
+
+	err = httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		fmt.Println("http server did not shut down cleanly", err)
+	}
+
+	// deliver the events of the sessions just stopped
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), sc.WebhookConfig.FlushTimeout)
+	defer cancelFlush()
+	notifier.Close(flushCtx)
 
+	fmt.Printf("drained: stopped %d sessions %v, disconnected %d RTSP readers\n", len(stopped), stopped, readers)
 	return nil
 }
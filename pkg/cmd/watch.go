@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+type ClientWatchCmd struct {
+	RoomName string `kong:"help='Only watch sessions in this LiveKit room'"`
+	Sid      string `kong:"help='Only watch the session with this SID'"`
+}
+
+func (cw *ClientWatchCmd) Run(cmn *ClientCmd) error {
+	query := url.Values{}
+	if len(cw.RoomName) > 0 {
+		query.Set("room_name", cw.RoomName)
+	}
+	if len(cw.Sid) > 0 {
+		query.Set("sid", cw.Sid)
+	}
+
+	resp, err := http.Get(fmt.Sprintf("%s/session/events?%s", cmn.URL, query.Encode()))
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		panic(fmt.Errorf("unable to watch sessions, status %d", resp.StatusCode))
+	}
+
+	sessions := make(map[string]*skyegresspb.Session)
+	lastEvent := "waiting for events"
+	render(sessions, lastEvent)
+
+	// server-sent events are blocks of "field: value" lines separated by a blank line; we only need the data
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	data := strings.Builder{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) > 0 {
+			if strings.HasPrefix(line, "data:") {
+				data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+			}
+			continue
+		}
+		if data.Len() == 0 {
+			continue
+		}
+
+		event := &skyegresspb.SessionEvent{}
+		err := protojson.Unmarshal([]byte(data.String()), event)
+		data.Reset()
+		if err != nil {
+			fmt.Println("unable to parse event", err)
+			continue
+		}
+
+		switch event.Type {
+		case skyegresspb.SessionEventType_SESSION_EVENT_STOPPED:
+			delete(sessions, event.Session.Sid)
+		default:
+			sessions[event.Session.Sid] = event.Session
+		}
+		if event.Type != skyegresspb.SessionEventType_SESSION_EVENT_STATS {
+			lastEvent = fmt.Sprintf("%s %s %s", event.Type, event.Session.Sid, event.Reason)
+		}
+		render(sessions, lastEvent)
+	}
+
+	return scanner.Err()
+}
+
+// redraws the table of sessions in place
+func render(sessions map[string]*skyegresspb.Session, lastEvent string) {
+	sids := make([]string, 0, len(sessions))
+	for sid := range sessions {
+		sids = append(sids, sid)
+	}
+	sort.Strings(sids)
+
+	// clear the screen and move the cursor home
+	fmt.Print("\033[H\033[2J")
+	fmt.Printf("%s\t%d sessions\n\n", time.Now().Format("15:04:05"), len(sessions))
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SID\tROOM\tTRACK\tPUBLISHER\tSTATE\tKBPS\tFPS\tLOST\tREADERS")
+	for _, sid := range sids {
+		session := sessions[sid]
+		state := strings.TrimPrefix(session.State.String(), "SESSION_STATE_")
+		fmt.Fprintf(
+			tw, "%s\t%s\t%s\t%s\t%s\t%d\t%.1f\t%d\t%d\n",
+			session.Sid,
+			session.RoomName,
+			session.TrackName,
+			session.PublisherIdentity,
+			state,
+			session.Stats.GetInputBitrate()/1000,
+			session.Stats.GetFrameRate(),
+			session.Stats.GetPacketsLost(),
+			len(session.Stats.GetReaders()),
+		)
+	}
+	tw.Flush()
+
+	fmt.Printf("\nlast event: %s\n", lastEvent)
+}
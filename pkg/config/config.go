@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 type Config struct {
 	LiveKitConfig LiveKitConfig `kong:"embed"`
 }
@@ -17,6 +19,83 @@ type RTSPConfig struct {
 	MulticastRTCPPort int    `kong:"default=8003"`
 }
 
+type SessionsConfig struct {
+	File        string        `kong:"help='YAML file declaring sessions the server should keep running; re-read every reconcile interval, and applied when its contents change',type='path'"`
+	Interval    time.Duration `kong:"default='5s',help='How often declared sessions are reconciled, and the sessions file checked for changes'"`
+	IdleTimeout time.Duration `kong:"default='0s',help='Stop sessions that have had no RTSP readers for this long; 0 never stops them'"`
+}
+
+type RulesConfig struct {
+	File     string        `kong:"help='YAML file of rules for automatically egressing published tracks',type='path'"`
+	Interval time.Duration `kong:"default='10s',help='How often LiveKit rooms are checked for tracks matching the rules'"`
+}
+
+type WebhookConfig struct {
+	URLs         []string      `kong:"name='urls',help='URLs every session lifecycle event is delivered to'"`
+	Secret       string        `kong:"help='Secret used to HMAC-SHA256 sign webhook payloads',env=SKYEGRESS_WEBHOOK_SECRET"`
+	Format       string        `kong:"enum='json,protobuf',default='json',help='Encoding of webhook payloads (json or protobuf)'"`
+	MaxAttempts  int           `kong:"default=5,help='Delivery attempts before an event is dropped'"`
+	Backoff      time.Duration `kong:"default='1s',help='Delay before retrying a failed delivery; doubles with every attempt'"`
+	Timeout      time.Duration `kong:"default='10s',help='Timeout for a single delivery attempt'"`
+	FlushTimeout time.Duration `kong:"default='5s',help='How long events still queued when shutting down get to be delivered'"`
+}
+
+type EventsConfig struct {
+	StatsInterval time.Duration `kong:"default='5s',help='How often stats events are emitted for each session'"`
+}
+
+type DrainConfig struct {
+	Timeout         time.Duration `kong:"default='5m',help='How long sessions may keep running when a drain is requested without a timeout'"`
+	ShutdownTimeout time.Duration `kong:"default='10s',help='How long shutting down may take to tear down RTSP readers, stop sessions and finish in-flight HTTP requests'"`
+}
+
+// limits on the work a single server takes on; 0 leaves a limit unset
+type CapacityConfig struct {
+	Sessions         int    `kong:"default=0,help='Maximum concurrent egress sessions'"`
+	ReadersPerStream int    `kong:"default=0,help='Maximum RTSP readers of a single session'"`
+	Readers          int    `kong:"default=0,help='Maximum RTSP readers across all sessions'"`
+	Bitrate          uint64 `kong:"default=0,help='Maximum aggregate bits per second sent to RTSP readers'"`
+}
+
+// video sent to RTSP readers in place of a session's source while it is missing
+type SlateConfig struct {
+	Source    string        `kong:"help='Slate sent to readers while no source packets arrive: testpattern, or the path of an Annex B H264 clip starting with an SPS and IDR, sent to sources of the same profile and resolution; empty disables it'"`
+	Timeout   time.Duration `kong:"default='1s',help='How long without source packets before the slate is sent'"`
+	FrameRate int           `kong:"default=10,help='Frame rate the slate is sent at'"`
+	Width     int           `kong:"default=1280,help='Width of the test pattern for sessions whose source resolution is not yet known'"`
+	Height    int           `kong:"default=720,help='Height of the test pattern for sessions whose source resolution is not yet known'"`
+}
+
+type SinksConfig struct {
+	Dir string `kong:"default='.',help='Directory the annexb sink records sessions to'"`
+}
+
+// files sessions can be played from with file:// sources
+type MediaConfig struct {
+	Root string `kong:"default='.',help='Directory file:// sources may play files from; paths outside it are refused, and empty disables file sources'"`
+}
+
+// captures of the packets sessions receive, started and stopped per session
+type CaptureConfig struct {
+	Dir     string `kong:"default='.',help='Directory session captures are written to, and can be replayed from even if it is outside the media root'"`
+	MaxSize uint64 `kong:"default=104857600,help='Size in bytes a capture is stopped at; requests can only give a smaller one'"`
+}
+
+// RTSP publishers pushing to /<room>/<identity> with ANNOUNCE and RECORD, forwarded into LiveKit
+type PublishConfig struct {
+	Rooms []string `kong:"help='Rooms RTSP publishers may push into, as glob patterns such as site-*; none disables publishing'"`
+	User  string   `kong:"help='User RTSP publishers must authenticate as; required to enable publishing',env=SKYEGRESS_PUBLISH_USER"`
+	Pass  string   `kong:"help='Password RTSP publishers must authenticate with',env=SKYEGRESS_PUBLISH_PASS"`
+}
+
+type ClusterConfig struct {
+	RedisURL         string        `kong:"name='redis-url',help='Redis URL of the session registry shared by the nodes of a cluster; without one the node runs on its own',env=SKYEGRESS_REDIS_URL"`
+	NodeID           string        `kong:"help='ID of this node in the cluster (defaults to a random ID)'"`
+	AdvertiseURL     string        `kong:"name='advertise-url',help='URL other nodes reach the HTTP API of this node at (defaults to http://<hostname>:<http-port>)'"`
+	AdvertiseRTSPURL string        `kong:"name='advertise-rtsp-url',help='URL RTSP readers are redirected to for streams on this node (defaults to rtsp://<hostname>:<rtsp-port>)'"`
+	Heartbeat        time.Duration `kong:"default='2s',help='How often the load and sessions of this node are published to the registry'"`
+}
+
 type LiveKitConfig struct {
 	Host      string `kong:"required,help='LiveKit host',env=LIVEKIT_URL"`
 	ApiKey    string `kong:"required,help='LiveKit server API key',env=LIVEKIT_API_KEY"`
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+)
+
+// events buffered for a subscriber before new ones are dropped
+const subscriberBuffer = 64
+
+// selects the events a subscriber receives; empty fields match every session
+type Filter struct {
+	RoomName string
+	Sid      string
+}
+
+func (f Filter) Match(event *skyegresspb.SessionEvent) bool {
+	if len(f.RoomName) > 0 && event.Session.GetRoomName() != f.RoomName {
+		return false
+	}
+	if len(f.Sid) > 0 && event.Session.GetSid() != f.Sid {
+		return false
+	}
+	return true
+}
+
+type subscription struct {
+	filter Filter
+	events chan *skyegresspb.SessionEvent
+}
+
+// fans session events out to any number of subscribers. Publishing never blocks; a subscriber that falls
+// behind misses events rather than holding up the streams that produce them.
+type Bus struct {
+	subsLock sync.RWMutex
+	subs     map[*subscription]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*subscription]struct{})}
+}
+
+func (b *Bus) Publish(event *skyegresspb.SessionEvent) {
+	b.subsLock.RLock()
+	defer b.subsLock.RUnlock()
+
+	for sub := range b.subs {
+		if !sub.filter.Match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			fmt.Printf("event subscriber is falling behind, dropping %s event\n", event.Type)
+		}
+	}
+}
+
+// returns a channel of the events matching the filter, and a function that ends the subscription
+func (b *Bus) Subscribe(filter Filter) (<-chan *skyegresspb.SessionEvent, func()) {
+	sub := &subscription{
+		filter: filter,
+		events: make(chan *skyegresspb.SessionEvent, subscriberBuffer),
+	}
+
+	b.subsLock.Lock()
+	b.subs[sub] = struct{}{}
+	b.subsLock.Unlock()
+
+	unsubscribe := func() {
+		b.subsLock.Lock()
+		delete(b.subs, sub)
+		b.subsLock.Unlock()
+	}
+	return sub.events, unsubscribe
+}
@@ -0,0 +1,470 @@
+// Package mp4 reads the H264 video track of MP4 files, for replaying recordings without decoding them
+package mp4
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// the moov box is read into memory whole; larger ones are taken to be corrupt
+	maxMoovSize = 64 << 20
+	// over 38 hours at 30fps; tracks claiming more are taken to be corrupt rather than allocated
+	maxSamples = 1 << 22
+)
+
+// a sample of the track: one access unit, as NAL units prefixed with their size
+type Sample struct {
+	Offset int64
+	Size   uint32
+	// decode time, and the offset of the presentation time from it, in the track's timescale
+	DTS       uint64
+	CTSOffset int32
+	// an IDR sample, which the track's SPS and PPS are sent ahead of
+	Keyframe bool
+}
+
+// the first H264 video track of an MP4 file. Fragmented files aren't supported.
+type Track struct {
+	Width     uint16
+	Height    uint16
+	Timescale uint32
+	SPS       [][]byte
+	PPS       [][]byte
+	Samples   []Sample
+	// duration of the samples, in the timescale
+	Duration uint64
+
+	naluLengthSize int
+}
+
+// reads the sample tables of the file's first H264 video track
+func ReadH264(r io.ReadSeeker) (*Track, error) {
+	fileSize, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	moov, err := findMoov(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, trak := range children(moov, "trak") {
+		mdia, ok := child(trak, "mdia")
+		if !ok {
+			continue
+		}
+		hdlr, ok := child(mdia, "hdlr")
+		if !ok || len(hdlr) < 12 || string(hdlr[8:12]) != "vide" {
+			continue
+		}
+		track, err := readTrack(mdia, fileSize)
+		if errors.Is(err, errNotH264) {
+			continue
+		}
+		return track, err
+	}
+	return nil, errors.New("no H264 video track")
+}
+
+// the NAL units of the sample, preceded by the SPS and PPS if it's a keyframe
+func (t *Track) AccessUnit(r io.ReaderAt, sample Sample) ([][]byte, error) {
+	data := make([]byte, sample.Size)
+	if _, err := r.ReadAt(data, sample.Offset); err != nil {
+		return nil, fmt.Errorf("unable to read sample: %w", err)
+	}
+
+	nalus := [][]byte{}
+	if sample.Keyframe {
+		nalus = append(nalus, t.SPS...)
+		nalus = append(nalus, t.PPS...)
+	}
+	for len(data) > 0 {
+		if len(data) < t.naluLengthSize {
+			return nil, errors.New("sample is truncated")
+		}
+		size := 0
+		for _, b := range data[:t.naluLengthSize] {
+			size = size<<8 | int(b)
+		}
+		data = data[t.naluLengthSize:]
+		if size > len(data) {
+			return nil, errors.New("sample is truncated")
+		}
+		if size > 0 {
+			nalus = append(nalus, data[:size])
+		}
+		data = data[size:]
+	}
+	return nalus, nil
+}
+
+// converts a time in the track's timescale
+func (t *Track) Time(units uint64) time.Duration {
+	return time.Duration(units) * time.Second / time.Duration(t.Timescale)
+}
+
+var errNotH264 = errors.New("track is not H264")
+
+// reads the track's tables; every sample must lie within the file's size
+func readTrack(mdia []byte, fileSize int64) (*Track, error) {
+	mdhd, ok := child(mdia, "mdhd")
+	if !ok || len(mdhd) < 24 {
+		return nil, errors.New("track has no media header")
+	}
+	track := &Track{}
+	if mdhd[0] == 1 {
+		if len(mdhd) < 36 {
+			return nil, errors.New("media header is truncated")
+		}
+		track.Timescale = binary.BigEndian.Uint32(mdhd[20:24])
+	} else {
+		track.Timescale = binary.BigEndian.Uint32(mdhd[12:16])
+	}
+	if track.Timescale == 0 {
+		return nil, errors.New("track has no timescale")
+	}
+
+	stbl, ok := descend(mdia, "minf", "stbl")
+	if !ok {
+		return nil, errors.New("track has no sample table")
+	}
+	if err := track.readSampleDescription(stbl); err != nil {
+		return nil, err
+	}
+	if err := track.readSamples(stbl, fileSize); err != nil {
+		return nil, err
+	}
+	return track, nil
+}
+
+// the parameter sets and NAL unit length size from the avc1 sample entry
+func (t *Track) readSampleDescription(stbl []byte) error {
+	stsd, ok := child(stbl, "stsd")
+	if !ok || len(stsd) < 8 {
+		return errors.New("track has no sample description")
+	}
+	entries := stsd[8:]
+	if len(entries) < 8 {
+		return errors.New("track has no sample description")
+	}
+	size := int(binary.BigEndian.Uint32(entries[0:4]))
+	typ := string(entries[4:8])
+	if typ != "avc1" && typ != "avc3" {
+		return errNotH264
+	}
+	// the visual sample entry is 78 bytes long ahead of its child boxes
+	if size > len(entries) || size < 8+78 {
+		return errors.New("sample description is truncated")
+	}
+	entry := entries[8:size]
+	t.Width = binary.BigEndian.Uint16(entry[24:26])
+	t.Height = binary.BigEndian.Uint16(entry[26:28])
+
+	avcC, ok := child(entry[78:], "avcC")
+	if !ok || len(avcC) < 7 {
+		return errors.New("track has no AVC configuration")
+	}
+	t.naluLengthSize = int(avcC[4]&0x03) + 1
+
+	rest := avcC[5:]
+	readSets := func(count int) ([][]byte, error) {
+		sets := [][]byte{}
+		for i := 0; i < count; i++ {
+			if len(rest) < 2 {
+				return nil, errors.New("AVC configuration is truncated")
+			}
+			size := int(binary.BigEndian.Uint16(rest[0:2]))
+			if 2+size > len(rest) {
+				return nil, errors.New("AVC configuration is truncated")
+			}
+			sets = append(sets, rest[2:2+size])
+			rest = rest[2+size:]
+		}
+		return sets, nil
+	}
+	var err error
+	count := int(rest[0] & 0x1f)
+	rest = rest[1:]
+	if t.SPS, err = readSets(count); err != nil {
+		return err
+	}
+	if len(rest) < 1 {
+		return errors.New("AVC configuration is truncated")
+	}
+	count = int(rest[0])
+	rest = rest[1:]
+	t.PPS, err = readSets(count)
+	return err
+}
+
+// builds the sample list from the sample size, time, sync, and chunk tables
+func (t *Track) readSamples(stbl []byte, fileSize int64) error {
+	stsz, ok := child(stbl, "stsz")
+	if !ok || len(stsz) < 12 {
+		return errors.New("track has no sample sizes")
+	}
+	fixedSize := binary.BigEndian.Uint32(stsz[4:8])
+	count := int(binary.BigEndian.Uint32(stsz[8:12]))
+	if fixedSize == 0 && len(stsz) < 12+4*count {
+		return errors.New("sample sizes are truncated")
+	}
+	if count == 0 {
+		return errors.New("track has no samples")
+	}
+	if count > maxSamples {
+		return fmt.Errorf("track has %d samples, more than the %d supported", count, maxSamples)
+	}
+	// every sample is stored in the file, so a fixed size limits how many there can be
+	if fixedSize > 0 && uint64(count)*uint64(fixedSize) > uint64(fileSize) {
+		return errors.New("sample sizes exceed the file")
+	}
+	t.Samples = make([]Sample, count)
+	for i := range t.Samples {
+		t.Samples[i].Size = fixedSize
+		if fixedSize == 0 {
+			t.Samples[i].Size = binary.BigEndian.Uint32(stsz[12+4*i:])
+		}
+	}
+
+	// decode times, as runs of samples of the same duration
+	stts, ok := child(stbl, "stts")
+	if !ok {
+		return errors.New("track has no sample times")
+	}
+	var dts uint64
+	i := 0
+	err := table(stts, 8, func(entry []byte) {
+		run := binary.BigEndian.Uint32(entry[0:4])
+		delta := binary.BigEndian.Uint32(entry[4:8])
+		for ; run > 0 && i < count; run-- {
+			t.Samples[i].DTS = dts
+			dts += uint64(delta)
+			i++
+		}
+	})
+	if err != nil {
+		return err
+	}
+	t.Duration = dts
+
+	// composition offsets, if the track reorders frames
+	if ctts, ok := child(stbl, "ctts"); ok {
+		i := 0
+		err := table(ctts, 8, func(entry []byte) {
+			run := binary.BigEndian.Uint32(entry[0:4])
+			offset := int32(binary.BigEndian.Uint32(entry[4:8]))
+			for ; run > 0 && i < count; run-- {
+				t.Samples[i].CTSOffset = offset
+				i++
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// without a sync sample table, every sample is one
+	if stss, ok := child(stbl, "stss"); ok {
+		err := table(stss, 4, func(entry []byte) {
+			if n := int(binary.BigEndian.Uint32(entry)); n >= 1 && n <= count {
+				t.Samples[n-1].Keyframe = true
+			}
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		for i := range t.Samples {
+			t.Samples[i].Keyframe = true
+		}
+	}
+
+	return t.readOffsets(stbl, fileSize)
+}
+
+// the offset of each sample, from the offsets of the chunks and the samples in each
+func (t *Track) readOffsets(stbl []byte, fileSize int64) error {
+	chunks := []int64{}
+	if stco, ok := child(stbl, "stco"); ok {
+		err := table(stco, 4, func(entry []byte) {
+			chunks = append(chunks, int64(binary.BigEndian.Uint32(entry)))
+		})
+		if err != nil {
+			return err
+		}
+	} else if co64, ok := child(stbl, "co64"); ok {
+		err := table(co64, 8, func(entry []byte) {
+			chunks = append(chunks, int64(binary.BigEndian.Uint64(entry)))
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		return errors.New("track has no chunk offsets")
+	}
+
+	type run struct {
+		firstChunk int
+		samples    int
+	}
+	runs := []run{}
+	stsc, ok := child(stbl, "stsc")
+	if !ok {
+		return errors.New("track has no sample to chunk table")
+	}
+	err := table(stsc, 12, func(entry []byte) {
+		runs = append(runs, run{
+			firstChunk: int(binary.BigEndian.Uint32(entry[0:4])) - 1,
+			samples:    int(binary.BigEndian.Uint32(entry[4:8])),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	sample := 0
+	for r, current := range runs {
+		// chunks are numbered from 1
+		if current.firstChunk < 0 {
+			return errors.New("sample to chunk table is invalid")
+		}
+		last := len(chunks)
+		if r+1 < len(runs) {
+			last = runs[r+1].firstChunk
+		}
+		for chunk := current.firstChunk; chunk < last && chunk < len(chunks); chunk++ {
+			offset := chunks[chunk]
+			for n := 0; n < current.samples && sample < len(t.Samples); n++ {
+				t.Samples[sample].Offset = offset
+				offset += int64(t.Samples[sample].Size)
+				if offset > fileSize {
+					return errors.New("sample lies outside the file")
+				}
+				sample++
+			}
+		}
+	}
+	if sample < len(t.Samples) {
+		return errors.New("chunks don't cover every sample")
+	}
+	return nil
+}
+
+// calls fn with each entry of a full box's table, which starts with the entry count
+func table(box []byte, entrySize int, fn func(entry []byte)) error {
+	if len(box) < 8 {
+		return errors.New("table is truncated")
+	}
+	count := int(binary.BigEndian.Uint32(box[4:8]))
+	entries := box[8:]
+	if count*entrySize > len(entries) || count < 0 {
+		return errors.New("table is truncated")
+	}
+	for i := 0; i < count; i++ {
+		fn(entries[i*entrySize : (i+1)*entrySize])
+	}
+	return nil
+}
+
+// the body of the moov box, skipping over the others at the top level of the file
+func findMoov(r io.ReadSeeker) ([]byte, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	header := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("file has no moov box")
+			}
+			return nil, fmt.Errorf("unable to read box header: %w", err)
+		}
+		size := uint64(binary.BigEndian.Uint32(header[0:4]))
+		typ := string(header[4:8])
+		headerSize := uint64(8)
+		switch size {
+		case 0:
+			// the box runs to the end of the file
+			if typ != "moov" {
+				return nil, errors.New("file has no moov box")
+			}
+			return io.ReadAll(io.LimitReader(r, maxMoovSize))
+		case 1:
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return nil, fmt.Errorf("unable to read box header: %w", err)
+			}
+			size = binary.BigEndian.Uint64(header[8:16])
+			headerSize = 16
+		}
+		if size < headerSize {
+			return nil, fmt.Errorf("%s box has an invalid size", typ)
+		}
+
+		switch typ {
+		case "moov":
+			if size-headerSize > maxMoovSize {
+				return nil, errors.New("moov box is too large")
+			}
+			moov := make([]byte, size-headerSize)
+			if _, err := io.ReadFull(r, moov); err != nil {
+				return nil, fmt.Errorf("unable to read moov box: %w", err)
+			}
+			return moov, nil
+		case "moof":
+			return nil, errors.New("fragmented MP4 is not supported")
+		}
+		if _, err := r.Seek(int64(size-headerSize), io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// the bodies of the boxes of the type directly within the data
+func children(data []byte, typ string) [][]byte {
+	found := [][]byte{}
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[0:4]))
+		headerSize := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return found
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerSize = 16
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			return found
+		}
+		if string(data[4:8]) == typ {
+			found = append(found, data[headerSize:size])
+		}
+		data = data[size:]
+	}
+	return found
+}
+
+func child(data []byte, typ string) ([]byte, bool) {
+	found := children(data, typ)
+	if len(found) == 0 {
+		return nil, false
+	}
+	return found[0], true
+}
+
+// the box at the path of nested box types
+func descend(data []byte, path ...string) ([]byte, bool) {
+	for _, typ := range path {
+		var ok bool
+		if data, ok = child(data, typ); !ok {
+			return nil, false
+		}
+	}
+	return data, true
+}
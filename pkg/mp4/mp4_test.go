@@ -0,0 +1,234 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var (
+	testSPS = []byte{0x67, 0x42, 0xc0, 0x1e, 0xd9}
+	testPPS = []byte{0x68, 0xce, 0x3c, 0x80}
+	// access units of the test file, as NAL units
+	testSamples = [][][]byte{
+		{{0x65, 0x88, 0x84}},
+		{{0x41, 0x9a, 0x02}, {0x41, 0x9a, 0x03, 0x04}},
+		{{0x41, 0x9a, 0x05}},
+	}
+)
+
+func box(typ string, body ...[]byte) []byte {
+	b := make([]byte, 8)
+	copy(b[4:8], typ)
+	for _, part := range body {
+		b = append(b, part...)
+	}
+	binary.BigEndian.PutUint32(b[0:4], uint32(len(b)))
+	return b
+}
+
+// a full box body of a version, no flags and the fields given
+func full(fields ...uint32) []byte {
+	b := make([]byte, 4)
+	for _, field := range fields {
+		b = binary.BigEndian.AppendUint32(b, field)
+	}
+	return b
+}
+
+// the pieces of a test file, which the cases change to break it
+type testFile struct {
+	handler string
+	stsz    []byte
+	stsc    []byte
+	stss    []byte
+	// offset added to the first chunk
+	chunkShift uint32
+	moof       bool
+}
+
+func newTestFile() *testFile {
+	sizes := []uint32{0, uint32(len(testSamples))}
+	for _, sample := range testSamples {
+		size := 0
+		for _, nalu := range sample {
+			size += 4 + len(nalu)
+		}
+		sizes = append(sizes, uint32(size))
+	}
+	return &testFile{
+		handler: "vide",
+		stsz:    full(sizes...),
+		// every sample in the one chunk
+		stsc: full(1, 1, uint32(len(testSamples)), 1),
+		stss: full(1, 1),
+	}
+}
+
+func (tf *testFile) build() []byte {
+	mdatBody := []byte{}
+	for _, sample := range testSamples {
+		for _, nalu := range sample {
+			mdatBody = binary.BigEndian.AppendUint32(mdatBody, uint32(len(nalu)))
+			mdatBody = append(mdatBody, nalu...)
+		}
+	}
+	ftyp := box("ftyp", []byte("isom"), make([]byte, 4))
+	mdat := box("mdat", mdatBody)
+	firstOffset := uint32(len(ftyp)+8) + tf.chunkShift
+
+	avcC := []byte{1, 0x42, 0xc0, 0x1e, 0xff, 0xe1}
+	avcC = binary.BigEndian.AppendUint16(avcC, uint16(len(testSPS)))
+	avcC = append(avcC, testSPS...)
+	avcC = append(avcC, 1)
+	avcC = binary.BigEndian.AppendUint16(avcC, uint16(len(testPPS)))
+	avcC = append(avcC, testPPS...)
+	visual := make([]byte, 78)
+	binary.BigEndian.PutUint16(visual[24:26], 320)
+	binary.BigEndian.PutUint16(visual[26:28], 240)
+	avc1 := box("avc1", visual, box("avcC", avcC))
+
+	hdlr := full(0, 0)
+	copy(hdlr[8:12], tf.handler)
+	stbl := box("stbl",
+		box("stsd", full(1), avc1),
+		box("stsz", tf.stsz),
+		// 3000 ticks a sample at 90kHz
+		box("stts", full(1, uint32(len(testSamples)), 3000)),
+		box("stss", tf.stss),
+		box("stsc", tf.stsc),
+		box("stco", full(1, firstOffset)),
+	)
+	mdia := box("mdia",
+		box("mdhd", full(0, 0, 90000, 9000, 0)),
+		box("hdlr", hdlr),
+		box("minf", stbl),
+	)
+	moov := box("moov", box("trak", mdia))
+
+	file := append(ftyp, mdat...)
+	if tf.moof {
+		file = append(file, box("moof")...)
+	}
+	return append(file, moov...)
+}
+
+func TestReadH264(t *testing.T) {
+	track, err := ReadH264(bytes.NewReader(newTestFile().build()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if track.Width != 320 || track.Height != 240 || track.Timescale != 90000 {
+		t.Errorf("got %dx%d at %d, want 320x240 at 90000", track.Width, track.Height, track.Timescale)
+	}
+	if !reflect.DeepEqual(track.SPS, [][]byte{testSPS}) || !reflect.DeepEqual(track.PPS, [][]byte{testPPS}) {
+		t.Errorf("got parameter sets %x %x", track.SPS, track.PPS)
+	}
+	if track.Duration != 9000 {
+		t.Errorf("got duration %d, want 9000", track.Duration)
+	}
+	if len(track.Samples) != len(testSamples) {
+		t.Fatalf("got %d samples, want %d", len(track.Samples), len(testSamples))
+	}
+
+	file := bytes.NewReader(newTestFile().build())
+	for i, sample := range track.Samples {
+		if sample.DTS != uint64(i)*3000 {
+			t.Errorf("sample %d: got DTS %d, want %d", i, sample.DTS, i*3000)
+		}
+		if sample.Keyframe != (i == 0) {
+			t.Errorf("sample %d: got keyframe %t", i, sample.Keyframe)
+		}
+		au, err := track.AccessUnit(file, sample)
+		if err != nil {
+			t.Fatalf("sample %d: %s", i, err)
+		}
+		want := testSamples[i]
+		if sample.Keyframe {
+			want = append([][]byte{testSPS, testPPS}, want...)
+		}
+		if !reflect.DeepEqual(au, want) {
+			t.Errorf("sample %d: got %x, want %x", i, au, want)
+		}
+	}
+}
+
+func TestReadH264Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(tf *testFile)
+		err    string
+	}{
+		{
+			name:   "not video",
+			modify: func(tf *testFile) { tf.handler = "soun" },
+			err:    "no H264 video track",
+		},
+		{
+			name:   "fragmented",
+			modify: func(tf *testFile) { tf.moof = true },
+			err:    "fragmented MP4 is not supported",
+		},
+		{
+			name: "fixed size samples beyond the file",
+			// would allocate gigabytes if the count were trusted
+			modify: func(tf *testFile) { tf.stsz = full(16, 0xfffffff) },
+			err:    "more than the",
+		},
+		{
+			name:   "fixed size samples larger than the file",
+			modify: func(tf *testFile) { tf.stsz = full(1<<20, 3) },
+			err:    "sample sizes exceed the file",
+		},
+		{
+			name:   "truncated sample sizes",
+			modify: func(tf *testFile) { tf.stsz = full(0, 1000, 1, 2) },
+			err:    "sample sizes are truncated",
+		},
+		{
+			name:   "samples outside the file",
+			modify: func(tf *testFile) { tf.chunkShift = 1 << 20 },
+			err:    "sample lies outside the file",
+		},
+		{
+			name:   "chunk zero",
+			modify: func(tf *testFile) { tf.stsc = full(1, 0, 3, 1) },
+			err:    "sample to chunk table is invalid",
+		},
+		{
+			name:   "truncated table",
+			modify: func(tf *testFile) { tf.stss = full(5, 1) },
+			err:    "table is truncated",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tf := newTestFile()
+			test.modify(tf)
+			_, err := ReadH264(bytes.NewReader(tf.build()))
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("got error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func FuzzReadH264(f *testing.F) {
+	f.Add(newTestFile().build())
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r := bytes.NewReader(data)
+		track, err := ReadH264(r)
+		if err != nil {
+			return
+		}
+		for i, sample := range track.Samples {
+			if i == 16 {
+				break
+			}
+			track.AccessUnit(r, sample)
+		}
+	})
+}
@@ -0,0 +1,20 @@
+package nalu
+
+// inserts emulation prevention bytes into the payload of a NAL unit, so it can't contain a start code
+func EmulationPrevention(rbsp []byte) []byte {
+	escaped := make([]byte, 0, len(rbsp))
+	zeros := 0
+	for _, b := range rbsp {
+		if zeros == 2 && b <= 3 {
+			escaped = append(escaped, 3)
+			zeros = 0
+		}
+		escaped = append(escaped, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return escaped
+}
@@ -0,0 +1,56 @@
+package nalu
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestEmulationPrevention(t *testing.T) {
+	tests := []struct {
+		rbsp string
+		want string
+	}{
+		{"", ""},
+		{"0102", "0102"},
+		{"000000", "00000300"},
+		{"000001", "00000301"},
+		{"000002", "00000302"},
+		{"000003", "00000303"},
+		{"000004", "000004"},
+		{"0000000000", "00000300000300"},
+		{"00000100000200", "000003010000030200"},
+		{"0000", "0000"},
+		{"ff0000", "ff0000"},
+	}
+	for _, test := range tests {
+		rbsp, _ := hex.DecodeString(test.rbsp)
+		want, _ := hex.DecodeString(test.want)
+		if got := EmulationPrevention(rbsp); !bytes.Equal(got, want) {
+			t.Errorf("%s: got %x, want %s", test.rbsp, got, test.want)
+		}
+	}
+}
+
+func TestUserDataSEI(t *testing.T) {
+	var uuid [16]byte
+	for i := range uuid {
+		uuid[i] = 0x11
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"short", []byte("ab"), "06" + "05" + "12" + "11111111111111111111111111111111" + "6162" + "80"},
+		{"escaped", []byte{0, 0, 1}, "06" + "05" + "13" + "11111111111111111111111111111111" + "00000301" + "80"},
+		// sizes of 255 and over take a run of 0xFF bytes
+		{"long", bytes.Repeat([]byte{0x22}, 300), "06" + "05" + "ff" + "3d" + "11111111111111111111111111111111" +
+			hex.EncodeToString(bytes.Repeat([]byte{0x22}, 300)) + "80"},
+	}
+	for _, test := range tests {
+		if got := hex.EncodeToString(UserDataSEI(uuid, test.data)); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
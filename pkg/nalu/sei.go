@@ -0,0 +1,28 @@
+// Package nalu builds H264 NAL units the server inserts into the video it relays and generates, such as SEI
+package nalu
+
+const (
+	headerSEI = 0x06 // nal_ref_idc 0, SEI
+
+	seiUserDataUnregistered = 5
+)
+
+// an SEI NAL unit with a single user data unregistered message: the UUID identifying the kind of data, then the data
+func UserDataSEI(uuid [16]byte, data []byte) []byte {
+	rbsp := []byte{}
+	rbsp = appendSEIValue(rbsp, seiUserDataUnregistered)
+	rbsp = appendSEIValue(rbsp, len(uuid)+len(data))
+	rbsp = append(rbsp, uuid[:]...)
+	rbsp = append(rbsp, data...)
+	rbsp = append(rbsp, 0x80) // rbsp_trailing_bits
+
+	return append([]byte{headerSEI}, EmulationPrevention(rbsp)...)
+}
+
+// SEI payload types and sizes are coded as a run of 0xFF bytes, each adding 255, then the remainder
+func appendSEIValue(buf []byte, v int) []byte {
+	for ; v >= 255; v -= 255 {
+		buf = append(buf, 0xFF)
+	}
+	return append(buf, byte(v))
+}
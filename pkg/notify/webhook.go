@@ -0,0 +1,250 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/config"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	SignatureHeader = "X-Skyegress-Signature"
+	EventHeader     = "X-Skyegress-Event"
+
+	// events waiting for delivery to a single URL before new ones are dropped
+	queueSize = 128
+	// how long a URL's delivery worker waits for new events before exiting
+	idleTimeout = time.Minute
+)
+
+type delivery struct {
+	eventType skyegresspb.SessionEventType
+	body      []byte
+}
+
+// delivers session lifecycle events to webhook URLs. Each URL gets its own queue and worker, so events
+// arrive in order and a slow or unreachable URL doesn't hold up the others.
+type webhookNotifier struct {
+	cfg         config.WebhookConfig
+	contentType string
+	client      *http.Client
+	ctx         context.Context
+	cancel      context.CancelFunc
+
+	queuesLock sync.Mutex
+	queues     map[string]chan delivery
+	// closed once the notifier is closing, for workers to deliver what's queued and exit
+	flushing chan struct{}
+	closed   bool
+	workers  sync.WaitGroup
+	// warns once that sessions' own URLs get unsigned events
+	unsignedOnce sync.Once
+}
+
+func NewWebhookNotifier(cfg config.WebhookConfig) *webhookNotifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	contentType := "application/json"
+	if cfg.Format == "protobuf" {
+		contentType = "application/x-protobuf"
+	}
+	if len(cfg.Secret) == 0 && len(cfg.URLs) > 0 {
+		fmt.Println("warning: no webhook secret set, so events are delivered to", cfg.URLs, "unsigned")
+	}
+	return &webhookNotifier{
+		cfg:         cfg,
+		contentType: contentType,
+		client:      &http.Client{Timeout: cfg.Timeout},
+		ctx:         ctx,
+		cancel:      cancel,
+		queues:      make(map[string]chan delivery),
+		flushing:    make(chan struct{}),
+	}
+}
+
+// queues the event for delivery to the server-wide URLs and the session's own URLs
+func (wn *webhookNotifier) OnEvent(event *skyegresspb.SessionEvent) {
+	// stats are too frequent for webhooks; they're available from the event stream instead
+	if event.Type == skyegresspb.SessionEventType_SESSION_EVENT_STATS {
+		return
+	}
+
+	urls := append(append([]string{}, wn.cfg.URLs...), event.Session.GetWebhookUrls()...)
+	if len(urls) == 0 {
+		return
+	}
+	if len(wn.cfg.Secret) == 0 && len(event.Session.GetWebhookUrls()) > 0 {
+		wn.unsignedOnce.Do(func() {
+			fmt.Println("warning: no webhook secret set, so events are delivered to sessions' webhook URLs unsigned")
+		})
+	}
+
+	body, err := wn.encode(event)
+	if err != nil {
+		fmt.Println("unable to encode session event", err)
+		return
+	}
+
+	d := delivery{eventType: event.Type, body: body}
+	for _, url := range urls {
+		wn.enqueue(url, d)
+	}
+}
+
+// delivers the events already queued, then stops delivering events. Whatever is still undelivered once the context
+// ends is dropped.
+func (wn *webhookNotifier) Close(ctx context.Context) {
+	wn.queuesLock.Lock()
+	if !wn.closed {
+		wn.closed = true
+		close(wn.flushing)
+	}
+	wn.queuesLock.Unlock()
+
+	flushed := make(chan struct{})
+	go func() {
+		wn.workers.Wait()
+		close(flushed)
+	}()
+	select {
+	case <-flushed:
+	case <-ctx.Done():
+		fmt.Println("dropping webhook events not delivered before shutdown")
+	}
+	wn.cancel()
+}
+
+func (wn *webhookNotifier) encode(event *skyegresspb.SessionEvent) ([]byte, error) {
+	if wn.cfg.Format == "protobuf" {
+		return proto.Marshal(event)
+	}
+	return protojson.Marshal(event)
+}
+
+func (wn *webhookNotifier) enqueue(url string, d delivery) {
+	wn.queuesLock.Lock()
+	defer wn.queuesLock.Unlock()
+	if wn.closed {
+		fmt.Printf("webhook notifier is closed, dropping %s event for %s\n", d.eventType, url)
+		return
+	}
+
+	queue, ok := wn.queues[url]
+	if !ok {
+		queue = make(chan delivery, queueSize)
+		wn.queues[url] = queue
+		wn.workers.Add(1)
+		go wn.deliverAll(url, queue)
+	}
+
+	select {
+	case queue <- d:
+	default:
+		fmt.Printf("webhook queue for %s is full, dropping %s event\n", url, d.eventType)
+	}
+}
+
+// delivers queued events to the URL in order, exiting once nothing has been queued for a while, or once what's
+// queued is delivered when closing
+func (wn *webhookNotifier) deliverAll(url string, queue chan delivery) {
+	defer wn.workers.Done()
+	idle := time.NewTimer(idleTimeout)
+	defer idle.Stop()
+
+	for {
+		select {
+		case <-wn.ctx.Done():
+			return
+		case <-wn.flushing:
+			// nothing more is queued once closing
+			for {
+				select {
+				case d := <-queue:
+					wn.deliver(url, d)
+				default:
+					return
+				}
+			}
+		case d := <-queue:
+			wn.deliver(url, d)
+			idle.Reset(idleTimeout)
+		case <-idle.C:
+			// enqueue holds the lock while sending, so nothing can be queued once we've checked
+			wn.queuesLock.Lock()
+			if len(queue) == 0 {
+				delete(wn.queues, url)
+				wn.queuesLock.Unlock()
+				return
+			}
+			wn.queuesLock.Unlock()
+			idle.Reset(idleTimeout)
+		}
+	}
+}
+
+// attempts delivery until it succeeds or runs out of attempts, backing off exponentially between them
+func (wn *webhookNotifier) deliver(url string, d delivery) {
+	backoff := wn.cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		err := wn.post(url, d)
+		if err == nil {
+			return
+		}
+		if attempt >= wn.cfg.MaxAttempts {
+			fmt.Printf("giving up delivering %s event to %s after %d attempts: %s\n", d.eventType, url, attempt, err)
+			return
+		}
+
+		fmt.Printf("unable to deliver %s event to %s, retrying in %s: %s\n", d.eventType, url, backoff, err)
+		select {
+		case <-wn.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (wn *webhookNotifier) post(url string, d delivery) error {
+	req, err := http.NewRequestWithContext(wn.ctx, http.MethodPost, url, bytes.NewReader(d.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", wn.contentType)
+	req.Header.Set(EventHeader, d.eventType.String())
+	if len(wn.cfg.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(wn.cfg.Secret, d.body))
+	}
+
+	resp, err := wn.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// signature of a webhook body, in the form sent in the signature header
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil)))
+}
+
+// whether the signature header value matches the body
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
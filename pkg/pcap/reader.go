@@ -0,0 +1,276 @@
+// Package pcap reads packet captures in the libpcap and pcapng formats, and the UDP payloads of the packets in them,
+// and writes pcapng captures of UDP payloads
+package pcap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// link types of the captures UDP payloads can be read from
+const (
+	LinkTypeNull     = 0
+	LinkTypeEthernet = 1
+	LinkTypeRaw      = 101
+	LinkTypeLinuxSLL = 113
+	LinkTypeIPv4     = 228
+	LinkTypeIPv6     = 229
+)
+
+const (
+	magicMicroseconds = 0xa1b2c3d4
+	magicNanoseconds  = 0xa1b23c4d
+
+	blockSectionHeader        = 0x0a0d0d0a
+	blockInterfaceDescription = 0x00000001
+	blockSimplePacket         = 0x00000003
+	blockEnhancedPacket       = 0x00000006
+	byteOrderMagic            = 0x1a2b3c4d
+
+	optionEnd       = 0
+	optionIfTsresol = 9
+
+	// larger blocks are taken to be corrupt rather than allocated
+	maxBlockSize = 16 << 20
+)
+
+// a captured packet, starting with the header of its link layer
+type Packet struct {
+	Timestamp time.Time
+	LinkType  uint16
+	Data      []byte
+}
+
+type pcapngInterface struct {
+	linkType uint16
+	// seconds per timestamp unit, as 10^-n or 2^-n
+	tsresol byte
+}
+
+// reads the packets of a capture in either format, telling them apart by the magic at its start
+type Reader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+
+	// libpcap
+	ng       bool
+	linkType uint16
+	nanos    bool
+
+	// pcapng; interfaces are numbered in the order they're described within a section
+	interfaces []pcapngInterface
+}
+
+func NewReader(r io.Reader) (*Reader, error) {
+	pr := &Reader{r: bufio.NewReader(r)}
+	magic, err := pr.r.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read capture header: %w", err)
+	}
+
+	switch {
+	case binary.LittleEndian.Uint32(magic) == blockSectionHeader:
+		pr.ng = true
+		return pr, nil
+	case binary.LittleEndian.Uint32(magic) == magicMicroseconds || binary.LittleEndian.Uint32(magic) == magicNanoseconds:
+		pr.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(magic) == magicMicroseconds || binary.BigEndian.Uint32(magic) == magicNanoseconds:
+		pr.order = binary.BigEndian
+	default:
+		return nil, errors.New("not a pcap or pcapng capture")
+	}
+
+	// magic, version, timezone, sigfigs, snaplen, link type
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(pr.r, header); err != nil {
+		return nil, fmt.Errorf("unable to read capture header: %w", err)
+	}
+	pr.nanos = pr.order.Uint32(header[0:4]) == magicNanoseconds
+	pr.linkType = uint16(pr.order.Uint32(header[20:24]))
+	return pr, nil
+}
+
+// the next packet of the capture; io.EOF after the last
+func (pr *Reader) ReadPacket() (*Packet, error) {
+	if pr.ng {
+		return pr.readBlocks()
+	}
+
+	// seconds, fraction, captured length, original length
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(pr.r, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, errors.New("capture is truncated")
+		}
+		return nil, err
+	}
+	size := pr.order.Uint32(header[8:12])
+	if size > maxBlockSize {
+		return nil, fmt.Errorf("packet of %d bytes is too large", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(pr.r, data); err != nil {
+		return nil, errors.New("capture is truncated")
+	}
+
+	fraction := time.Duration(pr.order.Uint32(header[4:8]))
+	if !pr.nanos {
+		fraction *= time.Microsecond
+	}
+	return &Packet{
+		Timestamp: time.Unix(int64(pr.order.Uint32(header[0:4])), int64(fraction)),
+		LinkType:  pr.linkType,
+		Data:      data,
+	}, nil
+}
+
+// reads pcapng blocks up to the next that carries a packet
+func (pr *Reader) readBlocks() (*Packet, error) {
+	for {
+		typ, body, err := pr.readBlock()
+		if err != nil {
+			return nil, err
+		}
+
+		switch typ {
+		case blockSectionHeader:
+			if len(body) < 4 {
+				return nil, errors.New("section header block is truncated")
+			}
+			pr.interfaces = nil
+
+		case blockInterfaceDescription:
+			if len(body) < 8 {
+				return nil, errors.New("interface description block is truncated")
+			}
+			iface := pcapngInterface{linkType: pr.order.Uint16(body[0:2]), tsresol: 6}
+			pr.readOptions(body[8:], func(code uint16, value []byte) {
+				if code == optionIfTsresol && len(value) > 0 {
+					iface.tsresol = value[0]
+				}
+			})
+			pr.interfaces = append(pr.interfaces, iface)
+
+		case blockEnhancedPacket:
+			if len(body) < 20 {
+				return nil, errors.New("enhanced packet block is truncated")
+			}
+			id := int(pr.order.Uint32(body[0:4]))
+			if id >= len(pr.interfaces) {
+				return nil, fmt.Errorf("packet on undescribed interface %d", id)
+			}
+			size := int(pr.order.Uint32(body[12:16]))
+			if 20+size > len(body) {
+				return nil, errors.New("enhanced packet block is truncated")
+			}
+			iface := pr.interfaces[id]
+			ts := uint64(pr.order.Uint32(body[4:8]))<<32 | uint64(pr.order.Uint32(body[8:12]))
+			return &Packet{
+				Timestamp: timestamp(ts, iface.tsresol),
+				LinkType:  iface.linkType,
+				Data:      body[20 : 20+size],
+			}, nil
+
+		case blockSimplePacket:
+			if len(pr.interfaces) == 0 {
+				return nil, errors.New("packet on undescribed interface 0")
+			}
+			if len(body) < 4 {
+				return nil, errors.New("simple packet block is truncated")
+			}
+			size := int(pr.order.Uint32(body[0:4]))
+			if 4+size > len(body) {
+				size = len(body) - 4
+			}
+			// simple packets carry no timestamp
+			return &Packet{LinkType: pr.interfaces[0].linkType, Data: body[4 : 4+size]}, nil
+		}
+	}
+}
+
+// the type and body of the next block, working out the byte order at each section header
+func (pr *Reader) readBlock() (uint32, []byte, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(pr.r, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, nil, errors.New("capture is truncated")
+		}
+		return 0, nil, err
+	}
+
+	typ := binary.LittleEndian.Uint32(header[0:4])
+	if typ == blockSectionHeader {
+		// the block type reads the same either way round; the byte order magic that follows it doesn't
+		magic, err := pr.r.Peek(4)
+		if err != nil {
+			return 0, nil, errors.New("capture is truncated")
+		}
+		switch {
+		case binary.LittleEndian.Uint32(magic) == byteOrderMagic:
+			pr.order = binary.LittleEndian
+		case binary.BigEndian.Uint32(magic) == byteOrderMagic:
+			pr.order = binary.BigEndian
+		default:
+			return 0, nil, errors.New("section header has an unknown byte order")
+		}
+	} else {
+		typ = pr.order.Uint32(header[0:4])
+	}
+
+	// the total length counts the type and both copies of the length
+	length := pr.order.Uint32(header[4:8])
+	if length < 12 || length%4 != 0 || length > maxBlockSize {
+		return 0, nil, fmt.Errorf("block of %d bytes is invalid", length)
+	}
+	rest := make([]byte, length-8)
+	if _, err := io.ReadFull(pr.r, rest); err != nil {
+		return 0, nil, errors.New("capture is truncated")
+	}
+	return typ, rest[:len(rest)-4], nil
+}
+
+func (pr *Reader) readOptions(options []byte, fn func(code uint16, value []byte)) {
+	for len(options) >= 4 {
+		code := pr.order.Uint16(options[0:2])
+		size := int(pr.order.Uint16(options[2:4]))
+		options = options[4:]
+		if code == optionEnd || size > len(options) {
+			return
+		}
+		fn(code, options[:size])
+		// values are padded to 32 bits
+		padded := (size + 3) &^ 3
+		if padded > len(options) {
+			return
+		}
+		options = options[padded:]
+	}
+}
+
+// the time of a pcapng timestamp in units of the interface's resolution
+func timestamp(ts uint64, tsresol byte) time.Time {
+	switch tsresol {
+	case 6:
+		return time.UnixMicro(int64(ts))
+	case 9:
+		return time.Unix(0, int64(ts))
+	}
+
+	exponent := float64(tsresol & 0x7f)
+	perSecond := math.Pow(10, exponent)
+	if tsresol&0x80 != 0 {
+		perSecond = math.Pow(2, exponent)
+	}
+	if perSecond >= math.MaxInt64 {
+		// too fine for any timestamp to reach a nanosecond
+		return time.Unix(0, 0)
+	}
+	seconds := ts / uint64(perSecond)
+	fraction := float64(ts%uint64(perSecond)) / perSecond
+	return time.Unix(int64(seconds), int64(fraction*float64(time.Second)))
+}
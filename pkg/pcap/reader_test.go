@@ -0,0 +1,209 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// an IPv4 UDP datagram carrying the payload, as the writer captures them
+func testIPv4(payload []byte) []byte {
+	var b bytes.Buffer
+	w, err := NewWriter(&b, "")
+	if err != nil {
+		panic(err)
+	}
+	if err := w.WriteUDP(time.Unix(0, 0), 5004, 5004, payload); err != nil {
+		panic(err)
+	}
+	r, err := NewReader(&b)
+	if err != nil {
+		panic(err)
+	}
+	packet, err := r.ReadPacket()
+	if err != nil {
+		panic(err)
+	}
+	return packet.Data
+}
+
+// a libpcap capture of the packets, each captured a second apart with a fraction of 123
+func libpcap(order binary.AppendByteOrder, magic uint32, linkType uint32, packets ...[]byte) []byte {
+	b := order.AppendUint32(nil, magic)
+	b = order.AppendUint16(b, 2)
+	b = order.AppendUint16(b, 4)
+	b = append(b, make([]byte, 8)...)
+	b = order.AppendUint32(b, 65535)
+	b = order.AppendUint32(b, linkType)
+	for i, packet := range packets {
+		b = order.AppendUint32(b, uint32(1700000000+i))
+		b = order.AppendUint32(b, 123)
+		b = order.AppendUint32(b, uint32(len(packet)))
+		b = order.AppendUint32(b, uint32(len(packet)))
+		b = append(b, packet...)
+	}
+	return b
+}
+
+func readAll(t *testing.T, capture []byte) []*Packet {
+	t.Helper()
+	r, err := NewReader(bytes.NewReader(capture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	packets := []*Packet{}
+	for {
+		packet, err := r.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			return packets
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		packets = append(packets, packet)
+	}
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewWriter(&b, "test capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Unix(1700000000, 123456789)
+	payloads := [][]byte{{0x80, 0x60, 0x00, 0x01}, {}, bytes.Repeat([]byte{0xab}, 1401)}
+	for i, payload := range payloads {
+		if err := w.WriteUDP(at.Add(time.Duration(i)*time.Nanosecond), 5004, 5005, payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w.Written() != int64(b.Len()) {
+		t.Errorf("written %d, buffered %d", w.Written(), b.Len())
+	}
+
+	packets := readAll(t, b.Bytes())
+	if len(packets) != len(payloads) {
+		t.Fatalf("got %d packets, want %d", len(packets), len(payloads))
+	}
+	for i, packet := range packets {
+		if want := at.Add(time.Duration(i) * time.Nanosecond); !packet.Timestamp.Equal(want) {
+			t.Errorf("packet %d: got timestamp %s, want %s", i, packet.Timestamp, want)
+		}
+		if packet.LinkType != LinkTypeRaw {
+			t.Errorf("packet %d: got link type %d", i, packet.LinkType)
+		}
+		if ipv4Checksum(packet.Data[:ipv4HeaderSize]) != 0 {
+			t.Errorf("packet %d: IPv4 checksum doesn't verify", i)
+		}
+		payload, ok := packet.UDPPayload()
+		if !ok || !bytes.Equal(payload, payloads[i]) {
+			t.Errorf("packet %d: got payload %x %t, want %x", i, payload, ok, payloads[i])
+		}
+	}
+}
+
+func TestReadLibpcap(t *testing.T) {
+	ip := testIPv4([]byte("rtp"))
+	tests := []struct {
+		name     string
+		order    binary.AppendByteOrder
+		magic    uint32
+		fraction time.Duration
+	}{
+		{"little endian microseconds", binary.LittleEndian, magicMicroseconds, 123 * time.Microsecond},
+		{"big endian microseconds", binary.BigEndian, magicMicroseconds, 123 * time.Microsecond},
+		{"little endian nanoseconds", binary.LittleEndian, magicNanoseconds, 123},
+		{"big endian nanoseconds", binary.BigEndian, magicNanoseconds, 123},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			packets := readAll(t, libpcap(test.order, test.magic, LinkTypeRaw, ip, ip))
+			if len(packets) != 2 {
+				t.Fatalf("got %d packets, want 2", len(packets))
+			}
+			for i, packet := range packets {
+				want := time.Unix(int64(1700000000+i), int64(test.fraction))
+				if !packet.Timestamp.Equal(want) {
+					t.Errorf("packet %d: got timestamp %s, want %s", i, packet.Timestamp, want)
+				}
+				if payload, ok := packet.UDPPayload(); !ok || string(payload) != "rtp" {
+					t.Errorf("packet %d: got payload %q %t", i, payload, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	tooLarge := libpcap(binary.LittleEndian, magicMicroseconds, LinkTypeRaw, []byte{0})
+	binary.LittleEndian.PutUint32(tooLarge[24+8:], maxBlockSize+1)
+
+	tests := []struct {
+		name    string
+		capture []byte
+		err     string
+	}{
+		{"empty", nil, "unable to read capture header"},
+		{"not a capture", []byte("GIF89a..."), "not a pcap or pcapng capture"},
+		{"truncated header", libpcap(binary.LittleEndian, magicMicroseconds, LinkTypeRaw)[:20], "unable to read capture header"},
+		{"truncated packet", libpcap(binary.LittleEndian, magicMicroseconds, LinkTypeRaw, []byte("packet"))[:30], "capture is truncated"},
+		{"packet too large", tooLarge, "too large"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(test.capture))
+			if err == nil {
+				_, err = r.ReadPacket()
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("got error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestTimestampResolutions(t *testing.T) {
+	tests := []struct {
+		tsresol byte
+		ts      uint64
+		want    time.Time
+	}{
+		{6, 1500000, time.Unix(1, 500000000)},
+		{9, 1500000000, time.Unix(1, 500000000)},
+		{3, 1500, time.Unix(1, 500000000)},
+		{0x80 | 10, 1536, time.Unix(1, 500000000)},
+		// finer than a nanosecond
+		{0x80 | 127, 1 << 40, time.Unix(0, 0)},
+		{100, 1 << 40, time.Unix(0, 0)},
+	}
+	for _, test := range tests {
+		if got := timestamp(test.ts, test.tsresol); !got.Equal(test.want) {
+			t.Errorf("timestamp(%d, %#x) = %s, want %s", test.ts, test.tsresol, got, test.want)
+		}
+	}
+}
+
+func FuzzReader(f *testing.F) {
+	var ng bytes.Buffer
+	w, _ := NewWriter(&ng, "seed")
+	w.WriteUDP(time.Unix(1, 0), 5004, 5004, []byte("rtp"))
+	f.Add(ng.Bytes())
+	f.Add(libpcap(binary.BigEndian, magicNanoseconds, LinkTypeEthernet, []byte("short")))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r, err := NewReader(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		for i := 0; i < 64; i++ {
+			packet, err := r.ReadPacket()
+			if err != nil {
+				return
+			}
+			packet.UDPPayload()
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package pcap
+
+import "encoding/binary"
+
+const (
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeVLAN = 0x8100
+
+	protocolUDP = 17
+)
+
+// the payload of the packet if it's a UDP datagram over IPv4 or IPv6; fragmented datagrams and IPv6 extension
+// headers aren't followed
+func (p *Packet) UDPPayload() ([]byte, bool) {
+	data := p.Data
+	switch p.LinkType {
+	case LinkTypeNull:
+		// a host byte order address family, which is 2 for IPv4 everywhere
+		if len(data) < 4 {
+			return nil, false
+		}
+		return ipPayload(data[4:])
+
+	case LinkTypeEthernet:
+		if len(data) < 14 {
+			return nil, false
+		}
+		etherType := binary.BigEndian.Uint16(data[12:14])
+		data = data[14:]
+		if etherType == etherTypeVLAN {
+			if len(data) < 4 {
+				return nil, false
+			}
+			etherType = binary.BigEndian.Uint16(data[2:4])
+			data = data[4:]
+		}
+		if etherType != etherTypeIPv4 && etherType != etherTypeIPv6 {
+			return nil, false
+		}
+		return ipPayload(data)
+
+	case LinkTypeLinuxSLL:
+		if len(data) < 16 {
+			return nil, false
+		}
+		return ipPayload(data[16:])
+
+	case LinkTypeRaw, LinkTypeIPv4, LinkTypeIPv6:
+		return ipPayload(data)
+	}
+	return nil, false
+}
+
+// the UDP payload of an IP packet of either version
+func ipPayload(data []byte) ([]byte, bool) {
+	if len(data) < 1 {
+		return nil, false
+	}
+
+	switch data[0] >> 4 {
+	case 4:
+		if len(data) < 20 {
+			return nil, false
+		}
+		headerSize := int(data[0]&0x0f) * 4
+		fragmented := binary.BigEndian.Uint16(data[6:8])&0x3fff != 0
+		if data[9] != protocolUDP || fragmented || headerSize < 20 || len(data) < headerSize {
+			return nil, false
+		}
+		return udpPayload(data[headerSize:])
+
+	case 6:
+		if len(data) < 40 || data[6] != protocolUDP {
+			return nil, false
+		}
+		return udpPayload(data[40:])
+	}
+	return nil, false
+}
+
+func udpPayload(data []byte) ([]byte, bool) {
+	if len(data) < 8 {
+		return nil, false
+	}
+	size := int(binary.BigEndian.Uint16(data[4:6]))
+	if size < 8 || size > len(data) {
+		return nil, false
+	}
+	return data[8:size], true
+}
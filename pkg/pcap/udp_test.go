@@ -0,0 +1,97 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func testUDP(payload []byte) []byte {
+	udp := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint16(udp[0:2], 5004)
+	binary.BigEndian.PutUint16(udp[2:4], 5004)
+	binary.BigEndian.PutUint16(udp[4:6], uint16(8+len(payload)))
+	return append(udp, payload...)
+}
+
+func testIPv6(payload []byte) []byte {
+	ip := make([]byte, 40)
+	ip[0] = 0x60
+	ip[6] = protocolUDP
+	return append(ip, testUDP(payload)...)
+}
+
+func ethernet(etherType uint16, vlan bool, ip []byte) []byte {
+	frame := make([]byte, 12)
+	if vlan {
+		frame = binary.BigEndian.AppendUint16(frame, etherTypeVLAN)
+		frame = binary.BigEndian.AppendUint16(frame, 42)
+	}
+	frame = binary.BigEndian.AppendUint16(frame, etherType)
+	return append(frame, ip...)
+}
+
+func TestUDPPayload(t *testing.T) {
+	payload := []byte("rtp packet")
+	ipv4 := testIPv4(payload)
+
+	fragmented := append([]byte{}, ipv4...)
+	// more fragments
+	fragmented[6] |= 0x20
+
+	tcp := append([]byte{}, ipv4...)
+	tcp[9] = 6
+
+	shortUDP := append([]byte{}, ipv4...)
+	binary.BigEndian.PutUint16(shortUDP[ipv4HeaderSize+4:], 4)
+
+	tests := []struct {
+		name     string
+		linkType uint16
+		data     []byte
+		ok       bool
+	}{
+		{"raw IPv4", LinkTypeRaw, ipv4, true},
+		{"IPv4", LinkTypeIPv4, ipv4, true},
+		{"IPv6", LinkTypeIPv6, testIPv6(payload), true},
+		{"null", LinkTypeNull, append([]byte{2, 0, 0, 0}, ipv4...), true},
+		{"ethernet", LinkTypeEthernet, ethernet(etherTypeIPv4, false, ipv4), true},
+		{"ethernet IPv6", LinkTypeEthernet, ethernet(etherTypeIPv6, false, testIPv6(payload)), true},
+		{"ethernet VLAN", LinkTypeEthernet, ethernet(etherTypeIPv4, true, ipv4), true},
+		{"linux cooked", LinkTypeLinuxSLL, append(make([]byte, 16), ipv4...), true},
+		{"ethernet ARP", LinkTypeEthernet, ethernet(0x0806, false, ipv4), false},
+		{"unknown link type", 147, ipv4, false},
+		{"fragmented", LinkTypeRaw, fragmented, false},
+		{"TCP", LinkTypeRaw, tcp, false},
+		{"UDP length too short", LinkTypeRaw, shortUDP, false},
+		{"truncated IPv4", LinkTypeRaw, ipv4[:19], false},
+		{"truncated UDP", LinkTypeRaw, ipv4[:ipv4HeaderSize+4], false},
+		{"truncated IPv6", LinkTypeIPv6, testIPv6(payload)[:39], false},
+		{"truncated ethernet", LinkTypeEthernet, ethernet(etherTypeIPv4, false, nil)[:13], false},
+		{"empty", LinkTypeRaw, nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			packet := &Packet{LinkType: test.linkType, Data: test.data}
+			got, ok := packet.UDPPayload()
+			if ok != test.ok {
+				t.Fatalf("got ok %t, want %t", ok, test.ok)
+			}
+			if ok && !bytes.Equal(got, payload) {
+				t.Errorf("got payload %q, want %q", got, payload)
+			}
+		})
+	}
+}
+
+func FuzzUDPPayload(f *testing.F) {
+	f.Add(uint16(LinkTypeRaw), testIPv4([]byte("rtp")))
+	f.Add(uint16(LinkTypeEthernet), ethernet(etherTypeIPv6, true, testIPv6([]byte("rtp"))))
+	f.Fuzz(func(t *testing.T, linkType uint16, data []byte) {
+		packet := &Packet{LinkType: linkType, Data: data}
+		payload, ok := packet.UDPPayload()
+		if ok && len(payload) > len(data) {
+			t.Errorf("payload of %d bytes from a packet of %d", len(payload), len(data))
+		}
+	})
+}
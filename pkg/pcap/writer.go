@@ -0,0 +1,140 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+const (
+	optionComment = 1
+	// the default tsresol of microseconds is too coarse for packets of the same frame
+	nanosecondTsresol = 9
+
+	ipv4HeaderSize = 20
+	udpHeaderSize  = 8
+	ipv4TTL        = 64
+)
+
+// the addresses written UDP datagrams are sent between; a capture only records what each datagram carried
+var (
+	writerSourceAddress      = [4]byte{10, 0, 0, 1}
+	writerDestinationAddress = [4]byte{10, 0, 0, 2}
+)
+
+// writes a pcapng capture of a single interface of raw IP packets, with nanosecond timestamps
+type Writer struct {
+	w       io.Writer
+	written int64
+}
+
+// starts the capture, noting the comment in its section header
+func NewWriter(w io.Writer, comment string) (*Writer, error) {
+	pw := &Writer{w: w}
+
+	var options []byte
+	if len(comment) > 0 {
+		options = appendOption(options, optionComment, []byte(comment))
+		options = appendOption(options, optionEnd, nil)
+	}
+	shb := make([]byte, 16, 16+len(options))
+	binary.LittleEndian.PutUint32(shb[0:4], byteOrderMagic)
+	binary.LittleEndian.PutUint16(shb[4:6], 1) // version 1.0
+	binary.LittleEndian.PutUint16(shb[6:8], 0)
+	// section length not given
+	binary.LittleEndian.PutUint64(shb[8:16], ^uint64(0))
+	if err := pw.writeBlock(blockSectionHeader, append(shb, options...)); err != nil {
+		return nil, err
+	}
+
+	idb := make([]byte, 8)
+	binary.LittleEndian.PutUint16(idb[0:2], LinkTypeRaw)
+	// no snap length
+	binary.LittleEndian.PutUint32(idb[4:8], 0)
+	idb = appendOption(idb, optionIfTsresol, []byte{nanosecondTsresol})
+	idb = appendOption(idb, optionEnd, nil)
+	if err := pw.writeBlock(blockInterfaceDescription, idb); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// bytes written to the capture so far
+func (pw *Writer) Written() int64 {
+	return pw.written
+}
+
+// writes the payload as a UDP datagram over IPv4 between the ports, captured at the time
+func (pw *Writer) WriteUDP(at time.Time, sourcePort uint16, destinationPort uint16, payload []byte) error {
+	packet := make([]byte, ipv4HeaderSize+udpHeaderSize+len(payload))
+
+	ip := packet[:ipv4HeaderSize]
+	ip[0] = 0x45 // version 4, 5 words of header
+	binary.BigEndian.PutUint16(ip[2:4], uint16(len(packet)))
+	ip[8] = ipv4TTL
+	ip[9] = protocolUDP
+	copy(ip[12:16], writerSourceAddress[:])
+	copy(ip[16:20], writerDestinationAddress[:])
+	binary.BigEndian.PutUint16(ip[10:12], ipv4Checksum(ip))
+
+	udp := packet[ipv4HeaderSize:]
+	binary.BigEndian.PutUint16(udp[0:2], sourcePort)
+	binary.BigEndian.PutUint16(udp[2:4], destinationPort)
+	binary.BigEndian.PutUint16(udp[4:6], uint16(udpHeaderSize+len(payload)))
+	// a checksum of 0 is none, which IPv4 allows
+	copy(udp[udpHeaderSize:], payload)
+
+	return pw.WritePacket(at, packet)
+}
+
+// writes a raw IP packet, captured at the time
+func (pw *Writer) WritePacket(at time.Time, data []byte) error {
+	epb := make([]byte, 20, 20+len(data)+3)
+	ts := uint64(at.UnixNano())
+	binary.LittleEndian.PutUint32(epb[0:4], 0) // the one interface
+	binary.LittleEndian.PutUint32(epb[4:8], uint32(ts>>32))
+	binary.LittleEndian.PutUint32(epb[8:12], uint32(ts))
+	binary.LittleEndian.PutUint32(epb[12:16], uint32(len(data)))
+	binary.LittleEndian.PutUint32(epb[16:20], uint32(len(data)))
+	epb = append(epb, data...)
+	return pw.writeBlock(blockEnhancedPacket, pad32(epb))
+}
+
+// writes a block around the body, which must already be padded to 32 bits
+func (pw *Writer) writeBlock(blockType uint32, body []byte) error {
+	size := uint32(12 + len(body))
+	block := make([]byte, 0, size)
+	block = binary.LittleEndian.AppendUint32(block, blockType)
+	block = binary.LittleEndian.AppendUint32(block, size)
+	block = append(block, body...)
+	block = binary.LittleEndian.AppendUint32(block, size)
+
+	n, err := pw.w.Write(block)
+	pw.written += int64(n)
+	return err
+}
+
+func appendOption(options []byte, code uint16, value []byte) []byte {
+	options = binary.LittleEndian.AppendUint16(options, code)
+	options = binary.LittleEndian.AppendUint16(options, uint16(len(value)))
+	return pad32(append(options, value...))
+}
+
+// pads to a multiple of 32 bits with zeros
+func pad32(b []byte) []byte {
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func ipv4Checksum(header []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(header); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(header[i : i+2]))
+	}
+	for sum > 0xffff {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
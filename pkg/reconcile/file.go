@@ -0,0 +1,109 @@
+package reconcile
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/stream"
+	"gopkg.in/yaml.v3"
+)
+
+// a single declared session, e.g.
+//
+//	sessions:
+//	  - path: site-a/cam1
+//	    room_name: devroom
+//	    track_name: demo
+//	  - path: site-a/bars
+//	    source: testpattern
+type sessionSpec struct {
+	Path                string   `yaml:"path"`
+	RoomName            string   `yaml:"room_name"`
+	TrackName           string   `yaml:"track_name"`
+	ParticipantIdentity string   `yaml:"participant_identity"`
+	WebhookUrls         []string `yaml:"webhook_urls"`
+	Source              string   `yaml:"source"`
+}
+
+type sessionsFileSpec struct {
+	Sessions []sessionSpec `yaml:"sessions"`
+}
+
+// declares the sessions listed in a YAML file. The file is read on every reconcile and only parsed again when its
+// contents change, which catches edits that leave its modification time as it was.
+type sessionsFile struct {
+	path     string
+	sum      [sha256.Size]byte
+	sessions map[string]*skyegresspb.Session
+}
+
+func NewSessionsFile(path string) *sessionsFile {
+	return &sessionsFile{path: path}
+}
+
+func (sf *sessionsFile) Name() string {
+	return sf.path
+}
+
+func (sf *sessionsFile) Declared(ctx context.Context) (map[string]*skyegresspb.Session, error) {
+	raw, err := os.ReadFile(sf.path)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	if sf.sessions != nil && sum == sf.sum {
+		return sf.sessions, nil
+	}
+
+	sessions, err := parseSessionsFile(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("loaded %d sessions from %s\n", len(sessions), sf.path)
+	sf.sum = sum
+	sf.sessions = sessions
+	return sessions, nil
+}
+
+// parses the contents of a sessions file, returning the declared sessions keyed by SID
+func parseSessionsFile(raw []byte) (map[string]*skyegresspb.Session, error) {
+	spec := sessionsFileSpec{}
+	if err := yaml.Unmarshal(raw, &spec); err != nil {
+		return nil, err
+	}
+
+	sessions := make(map[string]*skyegresspb.Session, len(spec.Sessions))
+	for i, ss := range spec.Sessions {
+		if len(ss.Source) > 0 {
+			if len(ss.Path) == 0 && len(stream.DefaultSourcePath(ss.Source)) == 0 {
+				return nil, fmt.Errorf("session %d: path must be provided for sessions with a source", i)
+			}
+		} else {
+			if len(ss.RoomName) == 0 {
+				return nil, fmt.Errorf("session %d: room_name must be provided", i)
+			}
+			if len(ss.TrackName) == 0 {
+				return nil, fmt.Errorf("session %d: track_name must be provided", i)
+			}
+		}
+
+		session := stream.NewSession(&skyegresspb.StartSessionRequest{
+			RoomName:            ss.RoomName,
+			TrackName:           ss.TrackName,
+			Path:                ss.Path,
+			ParticipantIdentity: ss.ParticipantIdentity,
+			WebhookUrls:         ss.WebhookUrls,
+			Source:              ss.Source,
+		})
+		if _, ok := sessions[session.Sid]; ok {
+			return nil, fmt.Errorf("session %d: path %s is declared more than once", i, session.Sid)
+		}
+		sessions[session.Sid] = session
+	}
+
+	return sessions, nil
+}
@@ -0,0 +1,49 @@
+package reconcile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSessionsFileEditWithinSameModTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.yaml")
+	modTime := time.Now().Truncate(time.Second)
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// as an edit saved within the same second looks on a filesystem with coarse timestamps
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sf := NewSessionsFile(path)
+	write("sessions:\n  - room_name: devroom\n    track_name: demo\n")
+	sessions, err := sf.Declared(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sessions["devroom/demo"]; !ok || len(sessions) != 1 {
+		t.Fatalf("got sessions %v", sessions)
+	}
+
+	write("sessions:\n  - room_name: devroom\n    track_name: other\n")
+	sessions, err = sf.Declared(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sessions["devroom/other"]; !ok || len(sessions) != 1 {
+		t.Fatalf("edit wasn't picked up, got sessions %v", sessions)
+	}
+
+	// an invalid edit is reported, not taken as declaring nothing
+	write("sessions:\n  - room_name: devroom\n")
+	if _, err := sf.Declared(context.Background()); err == nil {
+		t.Fatal("expected an error for a session without a track")
+	}
+}
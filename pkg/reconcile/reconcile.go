@@ -0,0 +1,152 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/stream"
+	"google.golang.org/protobuf/proto"
+)
+
+// declares the sessions that should be running
+type Declarer interface {
+	// name used when logging
+	Name() string
+	// returns the declared sessions keyed by SID
+	Declared(ctx context.Context) (map[string]*skyegresspb.Session, error)
+}
+
+// decides which node of the cluster runs each declared session, so nodes sharing a declarer don't all start it
+type Placer interface {
+	// whether this node should run the session
+	ShouldRun(ctx context.Context, sid string) (bool, error)
+}
+
+// keeps the sessions declared by a Declarer running: missing sessions are started, failed ones are
+// restarted and sessions that are no longer declared are stopped. Sessions started through other
+// means are left alone unless they are identical to a declared one. A session is only restarted when its declaration
+// changes, so a retarget or a switch to its backup isn't undone. In a cluster, each declared session is only run on
+// the node it's placed on.
+type Reconciler struct {
+	declarer Declarer
+	interval time.Duration
+	manager  *stream.SkyEgressStreamManager
+	placer   Placer
+
+	desired map[string]*skyegresspb.Session
+	// the sessions this reconciler is responsible for, as declared when they were started or adopted
+	managed map[string]*skyegresspb.Session
+}
+
+func NewReconciler(declarer Declarer, interval time.Duration, manager *stream.SkyEgressStreamManager, placer Placer) Reconciler {
+	return Reconciler{
+		declarer: declarer,
+		interval: interval,
+		manager:  manager,
+		placer:   placer,
+		desired:  make(map[string]*skyegresspb.Session),
+		managed:  make(map[string]*skyegresspb.Session),
+	}
+}
+
+// reconciles until the context is cancelled
+func (r *Reconciler) Run(ctx context.Context) {
+	fmt.Println("reconciling sessions from", r.declarer.Name())
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.reconcile(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Reconciler) reconcile(ctx context.Context) {
+	// if the declarer can't be reached, keep doing what we were doing rather than stopping everything
+	desired, err := r.declarer.Declared(ctx)
+	if err != nil {
+		fmt.Printf("unable to read sessions from %s, keeping previous sessions: %s\n", r.declarer.Name(), err)
+	} else {
+		r.desired = desired
+	}
+
+	for sid, want := range r.desired {
+		run, err := r.placer.ShouldRun(ctx, sid)
+		if err != nil {
+			fmt.Printf("unable to place declared session %s, will retry: %s\n", sid, err)
+			continue
+		}
+		stream, ok := r.manager.GetStream(sid)
+		if !run {
+			if _, owned := r.managed[sid]; owned && ok {
+				fmt.Println("declared session is running on another node, stopping", sid)
+				r.manager.RemoveStream(sid, "running on another node")
+			}
+			delete(r.managed, sid)
+			continue
+		}
+		if !ok {
+			r.start(want)
+			continue
+		}
+
+		have := stream.Session()
+		declared, owned := r.managed[sid]
+		switch {
+		case !owned && !sameSource(have, want):
+			fmt.Printf("declared session %s conflicts with a session started elsewhere, skipping\n", sid)
+			continue
+		case owned && !sameSource(declared, want):
+			// the running session may have been retargeted since; only the declaration decides
+			fmt.Println("declared session changed, restarting", sid)
+			r.manager.RemoveStream(sid, "declared session changed")
+			r.start(want)
+		case have.State == skyegresspb.SessionState_SESSION_STATE_FAILED:
+			fmt.Println("declared session failed, restarting", sid)
+			r.manager.RemoveStream(sid, "restarting failed session")
+			r.start(want)
+		default:
+			// an identical session may have been started through the API; adopt it
+			if !owned {
+				r.managed[sid] = want
+			}
+		}
+	}
+
+	for sid := range r.managed {
+		if _, ok := r.desired[sid]; ok {
+			continue
+		}
+		fmt.Println("session no longer declared, stopping", sid)
+		r.manager.RemoveStream(sid, fmt.Sprintf("no longer declared by %s", r.declarer.Name()))
+		delete(r.managed, sid)
+	}
+}
+
+func (r *Reconciler) start(want *skyegresspb.Session) {
+	// the stream takes ownership of the session it is given, so hand it a copy
+	session := proto.Clone(want).(*skyegresspb.Session)
+	if _, err := r.manager.StartStream(session); err != nil {
+		fmt.Printf("unable to start declared session %s, will retry: %s\n", want.Sid, err)
+		delete(r.managed, want.Sid)
+		return
+	}
+	r.managed[want.Sid] = want
+}
+
+// whether two sessions egress the same track
+func sameSource(a *skyegresspb.Session, b *skyegresspb.Session) bool {
+	// running sessions only show their source without credentials
+	return stream.RedactSource(a.Source) == stream.RedactSource(b.Source) &&
+		a.RoomName == b.RoomName &&
+		a.TrackName == b.TrackName &&
+		a.ParticipantIdentity == b.ParticipantIdentity
+}
@@ -0,0 +1,198 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+
+	lkproto "github.com/livekit/protocol/livekit"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/stream"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultPathTemplate = "{{.Room}}/{{.Identity}}/{{.Track}}"
+
+// a single auto-egress rule, e.g.
+//
+//	rules:
+//	  - name: drones
+//	    room: mission-*
+//	    identity: drone-*
+//	    metadata: '"role":\s*"drone"'
+//	    track: "*"
+//	    source: camera
+//	    path: "{{.Room}}/{{.Identity}}"
+//
+// room, identity and track are globs, metadata is a regular expression; empty fields match anything
+type ruleSpec struct {
+	Name     string `yaml:"name"`
+	Room     string `yaml:"room"`
+	Identity string `yaml:"identity"`
+	Metadata string `yaml:"metadata"`
+	Track    string `yaml:"track"`
+	Source   string `yaml:"source"`
+	Path     string `yaml:"path"`
+}
+
+type rulesFileSpec struct {
+	Rules []ruleSpec `yaml:"rules"`
+}
+
+// values available to a rule's path template
+type PathVars struct {
+	Room     string
+	Identity string
+	Track    string
+	TrackSid string
+	Source   string
+}
+
+// decides whether a published video track should be egressed, and at which RTSP path
+type Rule struct {
+	name      string
+	room      string
+	identity  string
+	metadata  *regexp.Regexp
+	track     string
+	source    lkproto.TrackSource
+	anySource bool
+	path      *template.Template
+}
+
+func newRule(spec ruleSpec) (*Rule, error) {
+	rule := &Rule{
+		name:      spec.Name,
+		room:      spec.Room,
+		identity:  spec.Identity,
+		track:     spec.Track,
+		anySource: len(spec.Source) == 0,
+	}
+
+	for _, glob := range []string{spec.Room, spec.Identity, spec.Track} {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("rule %s: invalid pattern %q: %w", spec.Name, glob, err)
+		}
+	}
+
+	if len(spec.Metadata) > 0 {
+		re, err := regexp.Compile(spec.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: invalid metadata expression: %w", spec.Name, err)
+		}
+		rule.metadata = re
+	}
+
+	if !rule.anySource {
+		source, ok := lkproto.TrackSource_value[strings.ToUpper(spec.Source)]
+		if !ok {
+			return nil, fmt.Errorf("rule %s: unknown track source %s", spec.Name, spec.Source)
+		}
+		rule.source = lkproto.TrackSource(source)
+	}
+
+	pathTemplate := spec.Path
+	if len(pathTemplate) == 0 {
+		pathTemplate = defaultPathTemplate
+	}
+	tmpl, err := template.New(spec.Name).Option("missingkey=error").Parse(pathTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: invalid path template: %w", spec.Name, err)
+	}
+	rule.path = tmpl
+
+	return rule, nil
+}
+
+func (r *Rule) Name() string {
+	return r.name
+}
+
+// whether the rule could match tracks in the room
+func (r *Rule) MatchRoom(room string) bool {
+	return globMatch(r.room, room)
+}
+
+// returns the session to start if the rule matches the given track
+func (r *Rule) Match(room string, p *lkproto.ParticipantInfo, t *lkproto.TrackInfo) (*skyegresspb.Session, bool) {
+	if t.Type != lkproto.TrackType_VIDEO {
+		return nil, false
+	}
+	if !r.MatchRoom(room) || !globMatch(r.identity, p.Identity) || !globMatch(r.track, t.Name) {
+		return nil, false
+	}
+	if r.metadata != nil && !r.metadata.MatchString(p.Metadata) {
+		return nil, false
+	}
+	if !r.anySource && t.Source != r.source {
+		return nil, false
+	}
+
+	vars := PathVars{
+		Room:     room,
+		Identity: p.Identity,
+		Track:    t.Name,
+		TrackSid: t.Sid,
+		Source:   strings.ToLower(t.Source.String()),
+	}
+	sb := strings.Builder{}
+	if err := r.path.Execute(&sb, vars); err != nil {
+		fmt.Printf("rule %s: unable to render path: %s\n", r.name, err)
+		return nil, false
+	}
+
+	return stream.NewSession(&skyegresspb.StartSessionRequest{
+		RoomName:            room,
+		TrackName:           t.Name,
+		Path:                sb.String(),
+		ParticipantIdentity: p.Identity,
+	}), true
+}
+
+// returns the session for the first rule matching the given track
+func Match(rules []*Rule, room string, p *lkproto.ParticipantInfo, t *lkproto.TrackInfo) (*skyegresspb.Session, bool) {
+	for _, rule := range rules {
+		if session, ok := rule.Match(room, p, t); ok {
+			return session, true
+		}
+	}
+	return nil, false
+}
+
+func LoadRules(file string) ([]*Rule, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := rulesFileSpec{}
+	if err := yaml.Unmarshal(raw, &spec); err != nil {
+		return nil, err
+	}
+
+	rules := make([]*Rule, 0, len(spec.Rules))
+	for i, rs := range spec.Rules {
+		if len(rs.Name) == 0 {
+			rs.Name = fmt.Sprintf("rule-%d", i)
+		}
+		rule, err := newRule(rs)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// an empty pattern matches anything
+func globMatch(pattern string, value string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
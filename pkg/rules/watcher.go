@@ -0,0 +1,76 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+
+	lkproto "github.com/livekit/protocol/livekit"
+	lksdk "github.com/livekit/server-sdk-go"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/config"
+)
+
+// declares a session for every published track matching a rule, found by polling LiveKit's RoomService
+type roomWatcher struct {
+	rules  []*Rule
+	client *lksdk.RoomServiceClient
+}
+
+func NewRoomWatcher(lkCfg config.LiveKitConfig, rules []*Rule) *roomWatcher {
+	httpUrl := fmt.Sprintf("https://%s", lkCfg.Host)
+	return &roomWatcher{
+		rules:  rules,
+		client: lksdk.NewRoomServiceClient(httpUrl, lkCfg.ApiKey, lkCfg.ApiSecret),
+	}
+}
+
+func (rw *roomWatcher) Name() string {
+	return "livekit rooms"
+}
+
+func (rw *roomWatcher) Declared(ctx context.Context) (map[string]*skyegresspb.Session, error) {
+	roomsRes, err := rw.client.ListRooms(ctx, &lkproto.ListRoomsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make(map[string]*skyegresspb.Session)
+	for _, room := range roomsRes.Rooms {
+		if !rw.anyRuleMatchesRoom(room.Name) {
+			continue
+		}
+
+		participantsRes, err := rw.client.ListParticipants(ctx, &lkproto.ListParticipantsRequest{Room: room.Name})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range participantsRes.Participants {
+			for _, t := range p.Tracks {
+				session, ok := Match(rw.rules, room.Name, p, t)
+				if !ok {
+					continue
+				}
+				if existing, ok := sessions[session.Sid]; ok {
+					fmt.Printf(
+						"track %s of %s maps to path %s already used by %s, skipping\n",
+						t.Name, p.Identity, session.Sid, existing.ParticipantIdentity,
+					)
+					continue
+				}
+				sessions[session.Sid] = session
+			}
+		}
+	}
+
+	return sessions, nil
+}
+
+func (rw *roomWatcher) anyRuleMatchesRoom(room string) bool {
+	for _, rule := range rw.rules {
+		if rule.MatchRoom(room) {
+			return true
+		}
+	}
+	return false
+}
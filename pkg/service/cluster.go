@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/cluster"
+	"github.com/treyhaknson/skyegress/pkg/stream"
+	"github.com/treyhaknson/skyegress/pkg/util"
+	"google.golang.org/protobuf/proto"
+)
+
+// set on requests one node forwards to another, which handles them itself rather than routing them again
+const forwardedHeader = "X-Skyegress-Forwarded-By"
+
+func isForwarded(r *http.Request) bool {
+	return len(r.Header.Get(forwardedHeader)) > 0
+}
+
+// the node a request for the session should be forwarded to; nil when it should be handled here, because the
+// session runs here, runs nowhere, or the request was already forwarded
+func remoteOwner(ctx context.Context, c *cluster.Cluster, r *http.Request, sid string) (*skyegresspb.NodeInfo, error) {
+	if isForwarded(r) {
+		return nil, nil
+	}
+	owner, ok, err := c.Owner(ctx, sid)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || c.IsLocal(owner) {
+		return nil, nil
+	}
+	return owner, nil
+}
+
+// stops the session on whichever node runs it
+func stopSession(ctx context.Context, c *cluster.Cluster, manager *stream.SkyEgressStreamManager, sid string, reason string) error {
+	owner, ok, err := c.Owner(ctx, sid)
+	if err != nil {
+		return err
+	}
+	if !ok || c.IsLocal(owner) {
+		manager.RemoveStream(sid, reason)
+		return nil
+	}
+
+	req := &skyegresspb.StopSessionRequest{Sid: sid, Reason: reason}
+	res := &skyegresspb.StopSessionResponse{}
+	status, err := forward(c, owner, "/session/stop", req, res)
+	if err != nil {
+		return err
+	}
+	if len(res.GetError()) > 0 {
+		return errors.New(res.GetError())
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("node %s replied with status %d", owner.Id, status)
+	}
+	return nil
+}
+
+// makes the request of another node in the cluster, returning the status it replied with
+func forward(c *cluster.Cluster, node *skyegresspb.NodeInfo, path string, req proto.Message, res proto.Message) (int, error) {
+	fmt.Printf("Forwarding %s to node %s\n", path, node.Id)
+	pc := util.NewProtoClient(node.Url)
+	pc.AddHeader(forwardedHeader, c.NodeID())
+	status, err := pc.RequestStatus(util.POST, path, req, res)
+	if err != nil {
+		return 0, fmt.Errorf("unable to forward request to node %s: %w", node.Id, err)
+	}
+	return status, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/events"
+	"github.com/treyhaknson/skyegress/pkg/stream"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// comments are sent this often so idle connections aren't closed by proxies
+const keepaliveInterval = 15 * time.Second
+
+// streams session events to clients as server-sent events
+type eventsHandler struct {
+	bus     *events.Bus
+	manager *stream.SkyEgressStreamManager
+}
+
+func NewEventsHandler(bus *events.Bus, manager *stream.SkyEgressStreamManager) eventsHandler {
+	return eventsHandler{bus: bus, manager: manager}
+}
+
+// GET /session/events?room_name=<room>&sid=<sid>; each event is a protojson encoded SessionEvent, named
+// after its type. The stream opens with a snapshot of every matching session.
+func (eh *eventsHandler) watch(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("streaming is not supported"))
+		return
+	}
+
+	filter := events.Filter{
+		RoomName: r.URL.Query().Get("room_name"),
+		Sid:      r.URL.Query().Get("sid"),
+	}
+	fmt.Printf("Received watch request %+v\n", filter)
+
+	// subscribe before taking the snapshot so nothing falls in between
+	sessionEvents, unsubscribe := eh.bus.Subscribe(filter)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, session := range eh.manager.Sessions() {
+		event := stream.NewSessionEvent(skyegresspb.SessionEventType_SESSION_EVENT_SNAPSHOT, session, "")
+		if !filter.Match(event) {
+			continue
+		}
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepalive := time.NewTicker(keepaliveInterval)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-sessionEvents:
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-keepalive.C:
+			if _, err := w.Write([]byte(": keepalive\n\n")); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func (eh *eventsHandler) Mount(mux *http.ServeMux) {
+	mux.HandleFunc("/session/events", eh.watch)
+}
+
+func writeEvent(w http.ResponseWriter, event *skyegresspb.SessionEvent) error {
+	data, err := protojson.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
@@ -8,14 +8,18 @@ import (
 	healthhttp "github.com/AppsFlyer/go-sundheit/http"
 
 	"github.com/treyhaknson/skyegress/pkg/config"
+	"github.com/treyhaknson/skyegress/pkg/stream"
 	"github.com/treyhaknson/skyegress/pkg/util"
 )
 
+// serves liveness on /health, which only fails when the node can't work at all, and readiness on /ready, which also
+// fails while the node shouldn't be sent new sessions
 type healthHandler struct {
 	health gosundheit.Health
+	ready  gosundheit.Health
 }
 
-func NewHealthHandler(cfg *config.Config) healthHandler {
+func NewHealthHandler(cfg *config.Config, manager *stream.SkyEgressStreamManager) healthHandler {
 	gh := gosundheit.New()
 
 	lkAuthCheck := util.NewLiveKitAuthCheck(
@@ -33,10 +37,29 @@ func NewHealthHandler(cfg *config.Config) healthHandler {
 		panic(err)
 	}
 
-	return healthHandler{health: gh}
+	// a full node is busy rather than dead, so restarting it would only drop its sessions
+	rh := gosundheit.New()
+	err = rh.RegisterCheck(
+		util.NewCapacityCheck(manager),
+		gosundheit.ExecutionPeriod(5*time.Second),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	// nor is a draining one, which has sessions to finish
+	err = rh.RegisterCheck(
+		util.NewDrainCheck(manager),
+		gosundheit.ExecutionPeriod(time.Second),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return healthHandler{health: gh, ready: rh}
 }
 
 func (hh *healthHandler) Mount(mux *http.ServeMux) {
-	handler := healthhttp.HandleHealthJSON(hh.health)
-	mux.HandleFunc("/health", handler)
+	mux.HandleFunc("/health", healthhttp.HandleHealthJSON(hh.health))
+	mux.HandleFunc("/ready", healthhttp.HandleHealthJSON(hh.ready))
 }
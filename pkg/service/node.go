@@ -0,0 +1,70 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/config"
+	"github.com/treyhaknson/skyegress/pkg/stream"
+	"google.golang.org/protobuf/proto"
+)
+
+type nodeHandler struct {
+	cfg     config.DrainConfig
+	manager *stream.SkyEgressStreamManager
+}
+
+func NewNodeHandler(cfg config.DrainConfig, manager *stream.SkyEgressStreamManager) nodeHandler {
+	return nodeHandler{cfg: cfg, manager: manager}
+}
+
+func (nh *nodeHandler) drain(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Received drain request")
+	res := &skyegresspb.DrainNodeResponse{}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		res.Result = &skyegresspb.DrainNodeResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+
+	req := skyegresspb.DrainNodeRequest{}
+	err = proto.Unmarshal(body, &req)
+	if err != nil {
+		res.Result = &skyegresspb.DrainNodeResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+
+	timeout := nh.cfg.Timeout
+	if req.TimeoutMs > 0 {
+		timeout = time.Duration(req.TimeoutMs) * time.Millisecond
+	}
+	nh.manager.DrainBy(time.Now().Add(timeout))
+
+	fmt.Println("Sending response")
+	draining, deadline := nh.manager.DrainStatus()
+	status := &skyegresspb.NodeStatus{
+		Draining: draining,
+		Sessions: uint32(nh.manager.Usage().Sessions),
+	}
+	if !deadline.IsZero() {
+		status.DrainDeadline = deadline.UnixMilli()
+	}
+	res.Result = &skyegresspb.DrainNodeResponse_Status{Status: status}
+	resb, err := proto.Marshal(res)
+	if err != nil {
+		res.Result = &skyegresspb.DrainNodeResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+	w.Write(resb)
+}
+
+func (nh *nodeHandler) Mount(mux *http.ServeMux) {
+	mux.HandleFunc("/node/drain", nh.drain)
+}
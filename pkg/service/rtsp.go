@@ -1,11 +1,18 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"path"
 	"strings"
 
 	"github.com/aler9/gortsplib/v2"
+	"github.com/aler9/gortsplib/v2/pkg/auth"
 	"github.com/aler9/gortsplib/v2/pkg/base"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/config"
 	"github.com/treyhaknson/skyegress/pkg/stream"
 )
 
@@ -13,18 +20,50 @@ func pathToSID(path string) string {
 	return strings.TrimPrefix(path, "/")
 }
 
+// the SID an RTSP publisher pushes to, attached to its session
+type publisherSID string
+
+// prefixes the identities RTSP publishers join rooms as, so they can't take over those of other participants
+const publisherIdentityPrefix = "rtsp-"
+
+// finds the streams that aren't served by this node
+type SessionDirectory interface {
+	// base RTSP URL of the node serving the stream, if another node is
+	Locate(ctx context.Context, sid string) (string, bool, error)
+}
+
 type rtspHandler struct {
-	manager *stream.SkyEgressStreamManager
+	manager   *stream.SkyEgressStreamManager
+	directory SessionDirectory
+	publish   config.PublishConfig
+	// nil unless publishers can authenticate, which they must to publish
+	validator *auth.Validator
 }
 
-func NewRTSPHandler(manager *stream.SkyEgressStreamManager) rtspHandler {
-	return rtspHandler{manager: manager}
+func NewRTSPHandler(manager *stream.SkyEgressStreamManager, directory SessionDirectory, publish config.PublishConfig) rtspHandler {
+	rh := rtspHandler{manager: manager, directory: directory, publish: publish}
+	if len(publish.User) > 0 {
+		rh.validator = auth.NewValidator(publish.User, publish.Pass, nil)
+	}
+	return rh
 }
 
 func (rh *rtspHandler) Mount(server *gortsplib.Server) {
 	server.Handler = rh
 }
 
+func (rh *rtspHandler) OnConnOpen(ctx *gortsplib.ServerHandlerOnConnOpenCtx) {
+	fmt.Println("rtsp connection opened", ctx.Conn.NetConn().RemoteAddr())
+}
+
+func (rh *rtspHandler) OnConnClose(ctx *gortsplib.ServerHandlerOnConnCloseCtx) {
+	fmt.Println("rtsp connection closed", ctx.Conn.NetConn().RemoteAddr(), ctx.Error)
+}
+
+func (rh *rtspHandler) OnSessionOpen(ctx *gortsplib.ServerHandlerOnSessionOpenCtx) {
+	fmt.Println("rtsp session opened", ctx.Conn.NetConn().RemoteAddr())
+}
+
 func (rh *rtspHandler) OnDescribe(ctx *gortsplib.ServerHandlerOnDescribeCtx) (*base.Response, *gortsplib.ServerStream, error) {
 	sid := pathToSID(ctx.Path)
 	fmt.Println("describe request", sid)
@@ -32,9 +71,7 @@ func (rh *rtspHandler) OnDescribe(ctx *gortsplib.ServerHandlerOnDescribeCtx) (*b
 	// attempt to locate the requested stream
 	stream, ok := rh.manager.GetStream(sid)
 	if !ok {
-		return &base.Response{
-			StatusCode: base.StatusNotFound,
-		}, nil, nil
+		return rh.redirect(ctx), nil, nil
 	}
 
 	// send the request stream
@@ -47,6 +84,13 @@ func (rh *rtspHandler) OnSetup(ctx *gortsplib.ServerHandlerOnSetupCtx) (*base.Re
 	sid := pathToSID(ctx.Path)
 	fmt.Println("setup request", sid)
 
+	// publishers set up the media they announced, which were checked then
+	if _, publishing := ctx.Session.UserData().(publisherSID); publishing {
+		return &base.Response{
+			StatusCode: base.StatusOK,
+		}, nil, nil
+	}
+
 	stream, ok := rh.manager.GetStream(sid)
 	if !ok {
 		return &base.Response{
@@ -54,6 +98,19 @@ func (rh *rtspHandler) OnSetup(ctx *gortsplib.ServerHandlerOnSetupCtx) (*base.Re
 		}, nil, nil
 	}
 
+	// SETUP is sent for every media, but only the first one admits a reader, reserving its slot until it plays
+	if _, attached := ctx.Session.UserData().(string); !attached {
+		if err := rh.manager.AdmitReader(stream, ctx.Session); err != nil {
+			fmt.Println("rejecting reader", sid, err)
+			return &base.Response{
+				StatusCode: base.StatusNotEnoughBandwidth,
+			}, nil, nil
+		}
+	}
+
+	// attach the session to the stream it reads, so it can be found again when it plays and closes
+	ctx.Session.SetUserData(sid)
+
 	// send the request stream
 	return &base.Response{
 		StatusCode: base.StatusOK,
@@ -64,13 +121,156 @@ func (rh *rtspHandler) OnPlay(ctx *gortsplib.ServerHandlerOnPlayCtx) (*base.Resp
 	sid := pathToSID(ctx.Path)
 	fmt.Println("play request", sid)
 
-	if _, ok := rh.manager.GetStream(sid); !ok {
+	stream, ok := rh.manager.GetStream(sid)
+	if !ok {
+		return &base.Response{
+			StatusCode: base.StatusNotFound,
+		}, nil
+	}
+
+	stream.AddReader(ctx.Session, ctx.Conn)
+
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, nil
+}
+
+// starts a publish session for an authenticated publisher pushing to /<room>/<identity> of a room it may publish
+// into, joining the room as rtsp-<identity>
+func (rh *rtspHandler) OnAnnounce(ctx *gortsplib.ServerHandlerOnAnnounceCtx) (*base.Response, error) {
+	sid := pathToSID(ctx.Path)
+	fmt.Println("announce request", sid, ctx.Conn.NetConn().RemoteAddr())
+
+	if len(rh.publish.Rooms) == 0 || rh.validator == nil {
+		fmt.Println("rejecting publisher", sid, "publishing is disabled")
+		return &base.Response{
+			StatusCode: base.StatusForbidden,
+		}, nil
+	}
+	if err := rh.validator.ValidateRequest(ctx.Request, nil); err != nil {
+		return &base.Response{
+			StatusCode: base.StatusUnauthorized,
+			Header: base.Header{
+				"WWW-Authenticate": rh.validator.Header(),
+			},
+		}, nil
+	}
+
+	room, identity, ok := strings.Cut(sid, "/")
+	if !ok || len(room) == 0 || len(identity) == 0 || strings.Contains(identity, "/") {
+		fmt.Println("rejecting publisher", sid, "publishers push to /<room>/<identity>")
+		return &base.Response{
+			StatusCode: base.StatusBadRequest,
+		}, nil
+	}
+	if !rh.mayPublish(room) {
+		fmt.Println("rejecting publisher", sid, "not allowed to publish into", room)
+		return &base.Response{
+			StatusCode: base.StatusForbidden,
+		}, nil
+	}
+	query, _ := url.ParseQuery(ctx.Query)
+
+	session := stream.NewSession(&skyegresspb.StartSessionRequest{
+		Kind:                skyegresspb.SessionKind_SESSION_KIND_PUBLISH,
+		RoomName:            room,
+		TrackName:           query.Get("track"),
+		Path:                sid,
+		ParticipantIdentity: publisherIdentityPrefix + identity,
+		Source:              ctx.Conn.NetConn().RemoteAddr().String(),
+	})
+	if _, err := rh.manager.StartPublish(session, ctx.Session, ctx.Medias); err != nil {
+		fmt.Println("rejecting publisher", sid, err)
+		statusCode := base.StatusBadRequest
+		if errors.Is(err, stream.ErrDraining) {
+			statusCode = base.StatusServiceUnavailable
+		} else if errors.Is(err, stream.ErrResourceExhausted) {
+			statusCode = base.StatusNotEnoughBandwidth
+		}
+		return &base.Response{
+			StatusCode: statusCode,
+		}, nil
+	}
+
+	// attach the session to the publish session it pushes to, so it can be found again when it records and closes
+	ctx.Session.SetUserData(publisherSID(session.Sid))
+
+	return &base.Response{
+		StatusCode: base.StatusOK,
+	}, nil
+}
+
+func (rh *rtspHandler) OnRecord(ctx *gortsplib.ServerHandlerOnRecordCtx) (*base.Response, error) {
+	sid, _ := ctx.Session.UserData().(publisherSID)
+	fmt.Println("record request", sid)
+
+	ingress, ok := rh.manager.GetPublish(string(sid), ctx.Session)
+	if !ok {
 		return &base.Response{
 			StatusCode: base.StatusNotFound,
 		}, nil
 	}
 
+	ingress.Record()
+
 	return &base.Response{
 		StatusCode: base.StatusOK,
 	}, nil
 }
+
+// whether publishers may push into the room
+func (rh *rtspHandler) mayPublish(room string) bool {
+	for _, pattern := range rh.publish.Rooms {
+		if matched, _ := path.Match(pattern, room); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (rh *rtspHandler) OnSessionClose(ctx *gortsplib.ServerHandlerOnSessionCloseCtx) {
+	if sid, ok := ctx.Session.UserData().(publisherSID); ok {
+		fmt.Println("rtsp publisher closed", sid, ctx.Error)
+		// unless the session was stopped, and maybe replaced, already
+		if _, ok := rh.manager.GetPublish(string(sid), ctx.Session); ok {
+			rh.manager.RemoveStream(string(sid), "RTSP publisher disconnected")
+		}
+		return
+	}
+
+	sid, ok := ctx.Session.UserData().(string)
+	if !ok {
+		return
+	}
+	fmt.Println("rtsp session closed", sid, ctx.Error)
+
+	if stream, ok := rh.manager.GetStream(sid); ok {
+		stream.RemoveReader(ctx.Session)
+	}
+}
+
+// sends the reader to the node serving the stream, or tells it the stream doesn't exist
+func (rh *rtspHandler) redirect(ctx *gortsplib.ServerHandlerOnDescribeCtx) *base.Response {
+	sid := pathToSID(ctx.Path)
+	url, ok, err := rh.directory.Locate(context.Background(), sid)
+	if err != nil {
+		fmt.Println("unable to locate stream", sid, err)
+	}
+	if !ok {
+		return &base.Response{
+			StatusCode: base.StatusNotFound,
+		}
+	}
+
+	location := url + ctx.Path
+	if len(ctx.Query) > 0 {
+		location += "?" + ctx.Query
+	}
+	fmt.Println("redirecting reader to", location)
+	return &base.Response{
+		StatusCode: base.StatusFound,
+		Header: base.Header{
+			"Location": base.HeaderValue{location},
+		},
+	}
+}
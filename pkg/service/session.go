@@ -1,35 +1,40 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
-	lksdk "github.com/livekit/server-sdk-go"
 	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
-	"github.com/treyhaknson/skyegress/pkg/config"
+	"github.com/treyhaknson/skyegress/pkg/cluster"
 	"github.com/treyhaknson/skyegress/pkg/stream"
 	"google.golang.org/protobuf/proto"
 )
 
 func writeError(w http.ResponseWriter, res proto.Message) {
+	writeStatus(w, http.StatusBadRequest, res)
+}
+
+func writeStatus(w http.ResponseWriter, status int, res proto.Message) {
 	resb, err := proto.Marshal(res)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
 		return
 	}
-	w.WriteHeader(http.StatusBadRequest)
+	w.WriteHeader(status)
 	w.Write(resb)
 }
 
 type sessionHandler struct {
-	cfg     *config.Config
 	manager *stream.SkyEgressStreamManager
+	cluster *cluster.Cluster
 }
 
-func NewSessionHandler(cfg *config.Config, manager *stream.SkyEgressStreamManager) sessionHandler {
-	return sessionHandler{cfg: cfg, manager: manager}
+func NewSessionHandler(manager *stream.SkyEgressStreamManager, cluster *cluster.Cluster) sessionHandler {
+	return sessionHandler{manager: manager, cluster: cluster}
 }
 
 func (sh *sessionHandler) start(w http.ResponseWriter, r *http.Request) {
@@ -52,52 +57,112 @@ func (sh *sessionHandler) start(w http.ResponseWriter, r *http.Request) {
 	}
 	fmt.Printf("Parsed start request %s, %s\n", req.RoomName, req.TrackName)
 
-	if len(req.RoomName) == 0 {
-		res.Result = &skyegresspb.StartSessionResponse_Error{Error: "room_name must be provided"}
+	if req.Kind == skyegresspb.SessionKind_SESSION_KIND_PUBLISH {
+		res.Result = &skyegresspb.StartSessionResponse_Error{Error: "publish sessions are started by RTSP publishers pushing to /<room>/<identity>"}
 		writeError(w, res)
 		return
-	}
+	} else if req.Kind == skyegresspb.SessionKind_SESSION_KIND_INGRESS {
+		if len(req.Source) == 0 {
+			res.Result = &skyegresspb.StartSessionResponse_Error{Error: "source must be provided for ingress sessions"}
+			writeError(w, res)
+			return
+		}
+		if len(req.RoomName) == 0 {
+			res.Result = &skyegresspb.StartSessionResponse_Error{Error: "room_name must be provided"}
+			writeError(w, res)
+			return
+		}
+	} else if len(req.Source) > 0 {
+		// the SID can't default to the room and track
+		if len(strings.Trim(req.Path, "/")) == 0 && len(stream.DefaultSourcePath(req.Source)) == 0 {
+			res.Result = &skyegresspb.StartSessionResponse_Error{Error: "path must be provided for sessions with a source"}
+			writeError(w, res)
+			return
+		}
+	} else {
+		if len(req.RoomName) == 0 {
+			res.Result = &skyegresspb.StartSessionResponse_Error{Error: "room_name must be provided"}
+			writeError(w, res)
+			return
+		}
 
-	if len(req.TrackName) == 0 {
-		res.Result = &skyegresspb.StartSessionResponse_Error{Error: "track_name must be provided"}
-		writeError(w, res)
-		return
+		if len(req.TrackName) == 0 {
+			res.Result = &skyegresspb.StartSessionResponse_Error{Error: "track_name must be provided"}
+			writeError(w, res)
+			return
+		}
 	}
 
-	sid := fmt.Sprintf("%s/%s", req.RoomName, req.TrackName)
-	identity := fmt.Sprintf("skyegress-%s-%s", req.RoomName, req.TrackName)
-	session := &skyegresspb.Session{
-		Sid:            sid,
-		RoomName:       req.RoomName,
-		TrackName:      req.TrackName,
-		EgressIdentity: identity,
-	}
+	session := stream.NewSession(&req)
 
-	fmt.Println("Adding new stream")
-	stream, err := sh.manager.AddStream(session)
-	if err != nil {
-		fmt.Println("Failed to add stream", err)
-		res.Result = &skyegresspb.StartSessionResponse_Error{Error: err.Error()}
-		writeError(w, res)
-		return
+	if !isForwarded(r) {
+		owner, ok, err := sh.cluster.Owner(r.Context(), session.Sid)
+		if err != nil {
+			res.Result = &skyegresspb.StartSessionResponse_Error{Error: err.Error()}
+			writeError(w, res)
+			return
+		}
+		if ok && !sh.cluster.IsLocal(owner) {
+			msg := fmt.Sprintf("stream with SID %s already exists on node %s", session.Sid, owner.Id)
+			res.Result = &skyegresspb.StartSessionResponse_Error{Error: msg}
+			writeError(w, res)
+			return
+		}
+
+		node, err := sh.cluster.Place(r.Context())
+		if err != nil {
+			res.Result = &skyegresspb.StartSessionResponse_Error{Error: err.Error()}
+			writeStatus(w, http.StatusServiceUnavailable, res)
+			return
+		}
+		// another node handling the same start at the same time may also have found no owner
+		holder, err := sh.cluster.Claim(r.Context(), session.Sid, node.Id)
+		if err != nil {
+			res.Result = &skyegresspb.StartSessionResponse_Error{Error: err.Error()}
+			writeError(w, res)
+			return
+		}
+		if holder != node.Id {
+			msg := fmt.Sprintf("stream with SID %s is already being started on node %s", session.Sid, holder)
+			res.Result = &skyegresspb.StartSessionResponse_Error{Error: msg}
+			writeError(w, res)
+			return
+		}
+		if !sh.cluster.IsLocal(node) {
+			status, err := forward(sh.cluster, node, "/session/start", &req, res)
+			if err != nil {
+				sh.cluster.Release(r.Context(), session.Sid, node.Id)
+				res.Result = &skyegresspb.StartSessionResponse_Error{Error: err.Error()}
+				writeError(w, res)
+				return
+			}
+			if status != http.StatusOK {
+				sh.cluster.Release(r.Context(), session.Sid, node.Id)
+			}
+			writeStatus(w, status, res)
+			return
+		}
 	}
 
-	err = stream.Start(sh.cfg.LiveKitConfig.Host, lksdk.ConnectInfo{
-		APIKey:              sh.cfg.LiveKitConfig.ApiKey,
-		APISecret:           sh.cfg.LiveKitConfig.ApiSecret,
-		RoomName:            req.RoomName,
-		ParticipantIdentity: identity,
-	})
+	fmt.Println("Adding new stream")
+	started, err := sh.startSession(session)
 	if err != nil {
-		fmt.Println("Failed to start stream, cleaning up", err)
-		sh.manager.RemoveStream(sid)
+		fmt.Println("Failed to start stream", err)
+		// unless the session was already running here, nothing holds the claim now
+		if !sh.manager.HasSession(session.Sid) {
+			sh.cluster.Release(r.Context(), session.Sid, sh.cluster.NodeID())
+		}
 		res.Result = &skyegresspb.StartSessionResponse_Error{Error: err.Error()}
+		if errors.Is(err, stream.ErrResourceExhausted) || errors.Is(err, stream.ErrDraining) {
+			writeStatus(w, http.StatusServiceUnavailable, res)
+			return
+		}
 		writeError(w, res)
 		return
 	}
 
 	fmt.Println("Sending response")
-	res.Result = &skyegresspb.StartSessionResponse_Session{Session: session}
+	res.Result = &skyegresspb.StartSessionResponse_Session{Session: started}
 	resb, err := proto.Marshal(res)
 	if err != nil {
 		res.Result = &skyegresspb.StartSessionResponse_Error{Error: err.Error()}
@@ -107,6 +172,22 @@ func (sh *sessionHandler) start(w http.ResponseWriter, r *http.Request) {
 	w.Write(resb)
 }
 
+// starts the session as an egress stream or an ingress, as it asks, returning a snapshot of it
+func (sh *sessionHandler) startSession(session *skyegresspb.Session) (*skyegresspb.Session, error) {
+	if session.Kind == skyegresspb.SessionKind_SESSION_KIND_INGRESS {
+		ingress, err := sh.manager.StartIngress(session)
+		if err != nil {
+			return nil, err
+		}
+		return ingress.Session(), nil
+	}
+	started, err := sh.manager.StartStream(session)
+	if err != nil {
+		return nil, err
+	}
+	return started.Session(), nil
+}
+
 func (sh *sessionHandler) list(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("Received list request")
 	res := &skyegresspb.ListSessionsResponse{}
@@ -126,8 +207,15 @@ func (sh *sessionHandler) list(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	all, err := sh.cluster.Sessions(r.Context())
+	if err != nil {
+		res.Result = &skyegresspb.ListSessionsResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+
 	fmt.Println("Sending response")
-	sessions := &skyegresspb.Sessions{Sessions: sh.manager.Sessions()}
+	sessions := &skyegresspb.Sessions{Sessions: all}
 	res.Result = &skyegresspb.ListSessionsResponse_Sessions{Sessions: sessions}
 	resb, err := proto.Marshal(res)
 	if err != nil {
@@ -157,7 +245,28 @@ func (sh *sessionHandler) stop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sh.manager.RemoveStream(req.Sid)
+	owner, err := remoteOwner(r.Context(), sh.cluster, r, req.Sid)
+	if err != nil {
+		res.Result = &skyegresspb.StopSessionResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+	if owner != nil {
+		status, err := forward(sh.cluster, owner, "/session/stop", &req, res)
+		if err != nil {
+			res.Result = &skyegresspb.StopSessionResponse_Error{Error: err.Error()}
+			writeError(w, res)
+			return
+		}
+		writeStatus(w, status, res)
+		return
+	}
+
+	reason := req.Reason
+	if len(reason) == 0 {
+		reason = "stopped through the API"
+	}
+	sh.manager.RemoveStream(req.Sid, reason)
 
 	fmt.Println("Sending response")
 	resb, err := proto.Marshal(res)
@@ -169,8 +278,312 @@ func (sh *sessionHandler) stop(w http.ResponseWriter, r *http.Request) {
 	w.Write(resb)
 }
 
+func (sh *sessionHandler) retarget(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Received retarget request")
+	res := &skyegresspb.RetargetSessionResponse{}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		res.Result = &skyegresspb.RetargetSessionResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+
+	req := skyegresspb.RetargetSessionRequest{}
+	err = proto.Unmarshal(body, &req)
+	if err != nil {
+		res.Result = &skyegresspb.RetargetSessionResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+	fmt.Printf("Parsed retarget request %s -> %s, %s\n", req.Sid, req.RoomName, req.TrackName)
+
+	if len(req.RoomName) == 0 {
+		res.Result = &skyegresspb.RetargetSessionResponse_Error{Error: "room_name must be provided"}
+		writeError(w, res)
+		return
+	}
+
+	if len(req.TrackName) == 0 {
+		res.Result = &skyegresspb.RetargetSessionResponse_Error{Error: "track_name must be provided"}
+		writeError(w, res)
+		return
+	}
+
+	owner, err := remoteOwner(r.Context(), sh.cluster, r, req.Sid)
+	if err != nil {
+		res.Result = &skyegresspb.RetargetSessionResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+	if owner != nil {
+		status, err := forward(sh.cluster, owner, "/session/retarget", &req, res)
+		if err != nil {
+			res.Result = &skyegresspb.RetargetSessionResponse_Error{Error: err.Error()}
+			writeError(w, res)
+			return
+		}
+		writeStatus(w, status, res)
+		return
+	}
+
+	retargeted, err := sh.manager.RetargetStream(req.Sid, req.RoomName, req.TrackName, req.ParticipantIdentity)
+	if err != nil {
+		fmt.Println("Failed to retarget stream", err)
+		res.Result = &skyegresspb.RetargetSessionResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+
+	fmt.Println("Sending response")
+	res.Result = &skyegresspb.RetargetSessionResponse_Session{Session: retargeted.Session()}
+	resb, err := proto.Marshal(res)
+	if err != nil {
+		res.Result = &skyegresspb.RetargetSessionResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+	w.Write(resb)
+}
+
+func (sh *sessionHandler) viewers(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Received viewers request")
+	res := &skyegresspb.ListViewersResponse{}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		res.Result = &skyegresspb.ListViewersResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+
+	req := skyegresspb.ListViewersRequest{}
+	err = proto.Unmarshal(body, &req)
+	if err != nil {
+		res.Result = &skyegresspb.ListViewersResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+
+	owner, err := remoteOwner(r.Context(), sh.cluster, r, req.Sid)
+	if err != nil {
+		res.Result = &skyegresspb.ListViewersResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+	if owner != nil {
+		status, err := forward(sh.cluster, owner, "/session/viewers", &req, res)
+		if err != nil {
+			res.Result = &skyegresspb.ListViewersResponse_Error{Error: err.Error()}
+			writeError(w, res)
+			return
+		}
+		writeStatus(w, status, res)
+		return
+	}
+
+	stream, ok := sh.manager.GetStream(req.Sid)
+	if !ok {
+		res.Result = &skyegresspb.ListViewersResponse_Error{Error: fmt.Sprintf("stream with SID %s does not exist", req.Sid)}
+		writeError(w, res)
+		return
+	}
+
+	fmt.Println("Sending response")
+	viewers := &skyegresspb.Viewers{Viewers: stream.Readers()}
+	res.Result = &skyegresspb.ListViewersResponse_Viewers{Viewers: viewers}
+	resb, err := proto.Marshal(res)
+	if err != nil {
+		res.Result = &skyegresspb.ListViewersResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+	w.Write(resb)
+}
+
+func (sh *sessionHandler) clock(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Received clock request")
+	res := &skyegresspb.GetClockResponse{}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		res.Result = &skyegresspb.GetClockResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+
+	req := skyegresspb.GetClockRequest{}
+	err = proto.Unmarshal(body, &req)
+	if err != nil {
+		res.Result = &skyegresspb.GetClockResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+
+	owner, err := remoteOwner(r.Context(), sh.cluster, r, req.Sid)
+	if err != nil {
+		res.Result = &skyegresspb.GetClockResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+	if owner != nil {
+		status, err := forward(sh.cluster, owner, "/session/clock", &req, res)
+		if err != nil {
+			res.Result = &skyegresspb.GetClockResponse_Error{Error: err.Error()}
+			writeError(w, res)
+			return
+		}
+		writeStatus(w, status, res)
+		return
+	}
+
+	stream, ok := sh.manager.GetStream(req.Sid)
+	if !ok {
+		res.Result = &skyegresspb.GetClockResponse_Error{Error: fmt.Sprintf("stream with SID %s does not exist", req.Sid)}
+		writeError(w, res)
+		return
+	}
+
+	clock := stream.Clock()
+	if clock == nil {
+		res.Result = &skyegresspb.GetClockResponse_Error{Error: fmt.Sprintf("stream with SID %s has not sent any packets yet", req.Sid)}
+		writeError(w, res)
+		return
+	}
+
+	fmt.Println("Sending response")
+	res.Result = &skyegresspb.GetClockResponse_Clock{Clock: clock}
+	resb, err := proto.Marshal(res)
+	if err != nil {
+		res.Result = &skyegresspb.GetClockResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+	w.Write(resb)
+}
+
+func (sh *sessionHandler) kick(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Received kick request")
+	res := &skyegresspb.KickViewerResponse{}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		res.Result = &skyegresspb.KickViewerResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+
+	req := skyegresspb.KickViewerRequest{}
+	err = proto.Unmarshal(body, &req)
+	if err != nil {
+		res.Result = &skyegresspb.KickViewerResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+
+	owner, err := remoteOwner(r.Context(), sh.cluster, r, req.Sid)
+	if err != nil {
+		res.Result = &skyegresspb.KickViewerResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+	if owner != nil {
+		status, err := forward(sh.cluster, owner, "/session/viewers/kick", &req, res)
+		if err != nil {
+			res.Result = &skyegresspb.KickViewerResponse_Error{Error: err.Error()}
+			writeError(w, res)
+			return
+		}
+		writeStatus(w, status, res)
+		return
+	}
+
+	stream, ok := sh.manager.GetStream(req.Sid)
+	if !ok {
+		res.Result = &skyegresspb.KickViewerResponse_Error{Error: fmt.Sprintf("stream with SID %s does not exist", req.Sid)}
+		writeError(w, res)
+		return
+	}
+
+	viewer, err := stream.KickReader(req.ViewerId)
+	if err != nil {
+		res.Result = &skyegresspb.KickViewerResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+
+	fmt.Println("Sending response")
+	res.Result = &skyegresspb.KickViewerResponse_Viewer{Viewer: viewer}
+	resb, err := proto.Marshal(res)
+	if err != nil {
+		res.Result = &skyegresspb.KickViewerResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+	w.Write(resb)
+}
+
+func (sh *sessionHandler) capture(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Received capture request")
+	res := &skyegresspb.CaptureSessionResponse{}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		res.Result = &skyegresspb.CaptureSessionResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+
+	req := skyegresspb.CaptureSessionRequest{}
+	err = proto.Unmarshal(body, &req)
+	if err != nil {
+		res.Result = &skyegresspb.CaptureSessionResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+
+	owner, err := remoteOwner(r.Context(), sh.cluster, r, req.Sid)
+	if err != nil {
+		res.Result = &skyegresspb.CaptureSessionResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+	if owner != nil {
+		status, err := forward(sh.cluster, owner, "/session/capture", &req, res)
+		if err != nil {
+			res.Result = &skyegresspb.CaptureSessionResponse_Error{Error: err.Error()}
+			writeError(w, res)
+			return
+		}
+		writeStatus(w, status, res)
+		return
+	}
+
+	capture, err := sh.manager.CaptureStream(req.Sid, req.Enabled, req.MaxBytes)
+	if err != nil {
+		res.Result = &skyegresspb.CaptureSessionResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+
+	fmt.Println("Sending response")
+	res.Result = &skyegresspb.CaptureSessionResponse_Capture{Capture: capture}
+	resb, err := proto.Marshal(res)
+	if err != nil {
+		res.Result = &skyegresspb.CaptureSessionResponse_Error{Error: err.Error()}
+		writeError(w, res)
+		return
+	}
+	w.Write(resb)
+}
+
 func (sh *sessionHandler) Mount(mux *http.ServeMux) {
 	mux.HandleFunc("/session/start", sh.start)
 	mux.HandleFunc("/session/list", sh.list)
 	mux.HandleFunc("/session/stop", sh.stop)
+	mux.HandleFunc("/session/retarget", sh.retarget)
+	mux.HandleFunc("/session/viewers", sh.viewers)
+	mux.HandleFunc("/session/viewers/kick", sh.kick)
+	mux.HandleFunc("/session/clock", sh.clock)
+	mux.HandleFunc("/session/capture", sh.capture)
 }
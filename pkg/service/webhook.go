@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/livekit/protocol/auth"
+	lkproto "github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+	lksdk "github.com/livekit/server-sdk-go"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/cluster"
+	"github.com/treyhaknson/skyegress/pkg/config"
+	"github.com/treyhaknson/skyegress/pkg/rules"
+	"github.com/treyhaknson/skyegress/pkg/stream"
+)
+
+// receives LiveKit server webhooks, cleaning up sessions whose source went away and auto-starting
+// sessions for newly published tracks that match a rule
+type webhookHandler struct {
+	provider auth.KeyProvider
+	manager  *stream.SkyEgressStreamManager
+	cluster  *cluster.Cluster
+	rules    []*rules.Rule
+	// finds whether a session with a backup still has a source in its room
+	rooms participantLister
+}
+
+// lists the participants of a room and their tracks, as LiveKit's RoomService does
+type participantLister interface {
+	ListParticipants(ctx context.Context, req *lkproto.ListParticipantsRequest) (*lkproto.ListParticipantsResponse, error)
+}
+
+func NewWebhookHandler(cfg *config.Config, manager *stream.SkyEgressStreamManager, cluster *cluster.Cluster, autoRules []*rules.Rule) webhookHandler {
+	httpUrl := fmt.Sprintf("https://%s", cfg.LiveKitConfig.Host)
+	return webhookHandler{
+		provider: auth.NewSimpleKeyProvider(cfg.LiveKitConfig.ApiKey, cfg.LiveKitConfig.ApiSecret),
+		manager:  manager,
+		cluster:  cluster,
+		rules:    autoRules,
+		rooms:    lksdk.NewRoomServiceClient(httpUrl, cfg.LiveKitConfig.ApiKey, cfg.LiveKitConfig.ApiSecret),
+	}
+}
+
+func (wh *webhookHandler) receive(w http.ResponseWriter, r *http.Request) {
+	event, err := webhook.ReceiveWebhookEvent(r, wh.provider)
+	if err != nil {
+		fmt.Println("Rejected webhook", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	fmt.Println("Received webhook", event.Event, event.Room.GetName())
+
+	switch event.Event {
+	case webhook.EventRoomFinished:
+		wh.cleanup(r.Context(), event.Room.GetName(), "room finished", func(session *skyegresspb.Session) bool {
+			return true
+		}, nil)
+	case webhook.EventParticipantLeft:
+		identity := event.Participant.GetIdentity()
+		wh.cleanup(r.Context(), event.Room.GetName(), "publisher left the room", func(session *skyegresspb.Session) bool {
+			return publishedBy(session, identity) || backupPublishedBy(session, identity)
+		}, func(p *lkproto.ParticipantInfo, t *lkproto.TrackInfo) bool {
+			return p.Identity == identity
+		})
+	case webhook.EventTrackUnpublished:
+		identity := event.Participant.GetIdentity()
+		trackName := event.Track.GetName()
+		wh.cleanup(r.Context(), event.Room.GetName(), "track unpublished", func(session *skyegresspb.Session) bool {
+			return (session.TrackName == trackName && publishedBy(session, identity)) ||
+				(session.BackupTrackName == trackName && backupPublishedBy(session, identity))
+		}, func(p *lkproto.ParticipantInfo, t *lkproto.TrackInfo) bool {
+			return p.Identity == identity && t.Name == trackName
+		})
+	case webhook.EventTrackPublished:
+		wh.autoStart(event)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// stops the sessions in the room that match, on whichever node of the cluster runs them. A session with a backup keeps running while a participant other than
+// the one going away, as gone tells, still publishes its primary or backup track; gone is nil when the whole room is.
+func (wh *webhookHandler) cleanup(
+	ctx context.Context,
+	room string,
+	reason string,
+	match func(*skyegresspb.Session) bool,
+	gone func(*lkproto.ParticipantInfo, *lkproto.TrackInfo) bool,
+) {
+	// listed once, for the first session with a backup
+	var participants []*lkproto.ParticipantInfo
+	var listErr error
+	listed := false
+
+	shouldStop := func(session *skyegresspb.Session) bool {
+		if session.RoomName != room || !match(session) {
+			return false
+		}
+		if gone == nil || len(session.BackupTrackName) == 0 {
+			return true
+		}
+		if !listed {
+			listed = true
+			var res *lkproto.ListParticipantsResponse
+			res, listErr = wh.rooms.ListParticipants(ctx, &lkproto.ListParticipantsRequest{Room: room})
+			participants = res.GetParticipants()
+		}
+		if listErr != nil {
+			fmt.Println("Unable to tell whether session", session.Sid, "still has a source, leaving it running:", listErr)
+			return false
+		}
+		return !sourcePresent(session, participants, gone)
+	}
+
+	sessions, err := wh.cluster.Sessions(ctx)
+	if err != nil {
+		fmt.Println("Unable to list the sessions of the cluster, only stopping those on this node:", err)
+		sessions = wh.manager.Sessions()
+	}
+	removed := []string{}
+	for _, session := range sessions {
+		if !shouldStop(session) {
+			continue
+		}
+		if err := stopSession(ctx, wh.cluster, wh.manager, session.Sid, reason); err != nil {
+			fmt.Println("Failed to stop session after webhook", session.Sid, err)
+			continue
+		}
+		removed = append(removed, session.Sid)
+	}
+	if len(removed) > 0 {
+		fmt.Println("Stopped sessions after webhook", removed)
+	}
+}
+
+// starts a session for the published track if it matches a rule, on the node it's placed on
+func (wh *webhookHandler) autoStart(event *lkproto.WebhookEvent) {
+	if event.Room == nil || event.Participant == nil || event.Track == nil {
+		return
+	}
+
+	session, ok := rules.Match(wh.rules, event.Room.Name, event.Participant, event.Track)
+	if !ok {
+		return
+	}
+
+	// connecting to the room can take a while; don't hold up LiveKit's webhook delivery
+	go func() {
+		ctx := context.Background()
+		_, running, err := wh.cluster.Owner(ctx, session.Sid)
+		if err != nil {
+			fmt.Println("Failed to auto-start session", session.Sid, err)
+			return
+		}
+		if running {
+			return
+		}
+		node, err := wh.cluster.Place(ctx)
+		if err != nil {
+			fmt.Println("Failed to auto-start session", session.Sid, err)
+			return
+		}
+		// LiveKit may deliver the webhook to more than one node
+		holder, err := wh.cluster.Claim(ctx, session.Sid, node.Id)
+		if err != nil {
+			fmt.Println("Failed to auto-start session", session.Sid, err)
+			return
+		}
+		if holder != node.Id {
+			return
+		}
+
+		fmt.Println("Auto-starting session after webhook", session.Sid)
+		if !wh.cluster.IsLocal(node) {
+			req := &skyegresspb.StartSessionRequest{
+				RoomName:            session.RoomName,
+				TrackName:           session.TrackName,
+				Path:                session.Sid,
+				ParticipantIdentity: session.ParticipantIdentity,
+			}
+			res := &skyegresspb.StartSessionResponse{}
+			if _, err := forward(wh.cluster, node, "/session/start", req, res); err != nil {
+				wh.cluster.Release(ctx, session.Sid, node.Id)
+				fmt.Println("Failed to auto-start session", session.Sid, err)
+			} else if len(res.GetError()) > 0 {
+				wh.cluster.Release(ctx, session.Sid, node.Id)
+				fmt.Println("Failed to auto-start session", session.Sid, res.GetError())
+			}
+			return
+		}
+		if _, err := wh.manager.StartStream(session); err != nil {
+			if !wh.manager.HasSession(session.Sid) {
+				wh.cluster.Release(ctx, session.Sid, node.Id)
+			}
+			fmt.Println("Failed to auto-start session", session.Sid, err)
+		}
+	}()
+}
+
+func (wh *webhookHandler) Mount(mux *http.ServeMux) {
+	mux.HandleFunc("/webhook/livekit", wh.receive)
+}
+
+// whether the session egresses a track published by the participant
+func publishedBy(session *skyegresspb.Session, identity string) bool {
+	if len(session.ParticipantIdentity) > 0 {
+		return session.ParticipantIdentity == identity
+	}
+	return session.PublisherIdentity == identity
+}
+
+// whether the session's backup track is published by the participant
+func backupPublishedBy(session *skyegresspb.Session, identity string) bool {
+	if len(session.BackupTrackName) == 0 {
+		return false
+	}
+	if len(session.BackupParticipantIdentity) > 0 {
+		return session.BackupParticipantIdentity == identity
+	}
+	return session.PublisherIdentity == identity
+}
+
+// whether any of the participants publishes the session's primary or backup track, other than the tracks going away
+func sourcePresent(
+	session *skyegresspb.Session,
+	participants []*lkproto.ParticipantInfo,
+	gone func(*lkproto.ParticipantInfo, *lkproto.TrackInfo) bool,
+) bool {
+	for _, p := range participants {
+		for _, t := range p.Tracks {
+			if gone(p, t) {
+				continue
+			}
+			if isSource(p, t, session.TrackName, session.ParticipantIdentity) ||
+				isSource(p, t, session.BackupTrackName, session.BackupParticipantIdentity) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// whether the track is the one a session relays, as the stream's source would match it
+func isSource(p *lkproto.ParticipantInfo, t *lkproto.TrackInfo, trackName string, participantIdentity string) bool {
+	if len(trackName) == 0 || t.Name != trackName {
+		return false
+	}
+	return len(participantIdentity) == 0 || p.Identity == participantIdentity
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/livekit/protocol/auth"
+	lkproto "github.com/livekit/protocol/livekit"
+	"github.com/livekit/protocol/webhook"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/cluster"
+	"github.com/treyhaknson/skyegress/pkg/config"
+	"github.com/treyhaknson/skyegress/pkg/stream"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	testAPIKey    = "key"
+	testAPISecret = "secretsecretsecretsecretsecret"
+)
+
+// a room whose participants publish the tracks given
+type testRoom struct {
+	participants []*lkproto.ParticipantInfo
+}
+
+func (tr *testRoom) ListParticipants(ctx context.Context, req *lkproto.ListParticipantsRequest) (*lkproto.ListParticipantsResponse, error) {
+	return &lkproto.ListParticipantsResponse{Participants: tr.participants}, nil
+}
+
+func publishing(identity string, trackNames ...string) *lkproto.ParticipantInfo {
+	p := &lkproto.ParticipantInfo{Identity: identity}
+	for _, name := range trackNames {
+		p.Tracks = append(p.Tracks, &lkproto.TrackInfo{Name: name, Type: lkproto.TrackType_VIDEO})
+	}
+	return p
+}
+
+func newTestWebhookHandler(room *testRoom) (*webhookHandler, *stream.SkyEgressStreamManager) {
+	manager := stream.NewSkyEgressStreamManager("node", config.LiveKitConfig{}, config.CapacityConfig{}, config.CaptureConfig{}, nil)
+	nodes := cluster.NewCluster(config.ClusterConfig{Heartbeat: time.Second}, cluster.NewMemoryRegistry(), &manager)
+	return &webhookHandler{
+		provider: auth.NewSimpleKeyProvider(testAPIKey, testAPISecret),
+		manager:  &manager,
+		cluster:  nodes,
+		rooms:    room,
+	}, &manager
+}
+
+// delivers the event as LiveKit would, signed with the API secret
+func sendWebhook(t *testing.T, wh *webhookHandler, event *lkproto.WebhookEvent) {
+	t.Helper()
+	body, err := protojson.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(body)
+	token, err := auth.NewAccessToken(testAPIKey, testAPISecret).
+		SetValidFor(time.Minute).
+		SetSha256(base64.StdEncoding.EncodeToString(sum[:])).
+		ToJWT()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/webhook/livekit", bytes.NewReader(body))
+	r.Header.Set("Authorization", token)
+	w := httptest.NewRecorder()
+	wh.receive(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("webhook got status %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestWebhookParticipantLeftWithBackup(t *testing.T) {
+	room := &testRoom{participants: []*lkproto.ParticipantInfo{
+		publishing("camera-a", "main"),
+		publishing("camera-b", "main"),
+	}}
+	wh, manager := newTestWebhookHandler(room)
+
+	session := stream.NewSession(&skyegresspb.StartSessionRequest{
+		RoomName:                  "studio",
+		TrackName:                 "main",
+		ParticipantIdentity:       "camera-a",
+		BackupTrackName:           "main",
+		BackupParticipantIdentity: "camera-b",
+	})
+	if _, err := manager.AddStream(session); err != nil {
+		t.Fatal(err)
+	}
+	defer manager.StopAll(context.Background(), "test over")
+
+	// the primary leaves while the backup is still publishing
+	room.participants = room.participants[1:]
+	sendWebhook(t, wh, &lkproto.WebhookEvent{
+		Event:       webhook.EventParticipantLeft,
+		Room:        &lkproto.Room{Name: "studio"},
+		Participant: &lkproto.ParticipantInfo{Identity: "camera-a"},
+	})
+	if !manager.HasSession(session.Sid) {
+		t.Fatal("session stopped when its primary left, though its backup was still in the room")
+	}
+
+	// LiveKit may still list a participant that has just left
+	room.participants = []*lkproto.ParticipantInfo{publishing("camera-b", "main")}
+	sendWebhook(t, wh, &lkproto.WebhookEvent{
+		Event:       webhook.EventParticipantLeft,
+		Room:        &lkproto.Room{Name: "studio"},
+		Participant: &lkproto.ParticipantInfo{Identity: "camera-b"},
+	})
+	if manager.HasSession(session.Sid) {
+		t.Fatal("session still running once neither its primary nor its backup was in the room")
+	}
+}
+
+func TestWebhookCleanup(t *testing.T) {
+	tests := []struct {
+		name         string
+		request      *skyegresspb.StartSessionRequest
+		participants []*lkproto.ParticipantInfo
+		event        *lkproto.WebhookEvent
+		stopped      bool
+	}{
+		{
+			name:    "primary left without a backup",
+			request: &skyegresspb.StartSessionRequest{RoomName: "studio", TrackName: "main", ParticipantIdentity: "camera-a"},
+			event: &lkproto.WebhookEvent{
+				Event:       webhook.EventParticipantLeft,
+				Room:        &lkproto.Room{Name: "studio"},
+				Participant: &lkproto.ParticipantInfo{Identity: "camera-a"},
+			},
+			stopped: true,
+		},
+		{
+			name: "backup left while the primary publishes",
+			request: &skyegresspb.StartSessionRequest{
+				RoomName: "studio", TrackName: "main", ParticipantIdentity: "camera-a",
+				BackupTrackName: "wide", BackupParticipantIdentity: "camera-b",
+			},
+			participants: []*lkproto.ParticipantInfo{publishing("camera-a", "main")},
+			event: &lkproto.WebhookEvent{
+				Event:       webhook.EventParticipantLeft,
+				Room:        &lkproto.Room{Name: "studio"},
+				Participant: &lkproto.ParticipantInfo{Identity: "camera-b"},
+			},
+			stopped: false,
+		},
+		{
+			name: "primary unpublished while the backup publishes",
+			request: &skyegresspb.StartSessionRequest{
+				RoomName: "studio", TrackName: "main", ParticipantIdentity: "camera-a", BackupTrackName: "wide",
+			},
+			participants: []*lkproto.ParticipantInfo{publishing("camera-a", "main", "wide")},
+			event: &lkproto.WebhookEvent{
+				Event:       webhook.EventTrackUnpublished,
+				Room:        &lkproto.Room{Name: "studio"},
+				Participant: &lkproto.ParticipantInfo{Identity: "camera-a"},
+				Track:       &lkproto.TrackInfo{Name: "main"},
+			},
+			stopped: false,
+		},
+		{
+			name: "primary unpublished with the backup absent",
+			request: &skyegresspb.StartSessionRequest{
+				RoomName: "studio", TrackName: "main", ParticipantIdentity: "camera-a", BackupTrackName: "wide",
+			},
+			participants: []*lkproto.ParticipantInfo{publishing("camera-a", "main"), publishing("viewer")},
+			event: &lkproto.WebhookEvent{
+				Event:       webhook.EventTrackUnpublished,
+				Room:        &lkproto.Room{Name: "studio"},
+				Participant: &lkproto.ParticipantInfo{Identity: "camera-a"},
+				Track:       &lkproto.TrackInfo{Name: "main"},
+			},
+			stopped: true,
+		},
+		{
+			name: "room finished with the backup publishing",
+			request: &skyegresspb.StartSessionRequest{
+				RoomName: "studio", TrackName: "main", BackupTrackName: "wide",
+			},
+			participants: []*lkproto.ParticipantInfo{publishing("camera-b", "wide")},
+			event: &lkproto.WebhookEvent{
+				Event: webhook.EventRoomFinished,
+				Room:  &lkproto.Room{Name: "studio"},
+			},
+			stopped: true,
+		},
+		{
+			name:    "participant left another room",
+			request: &skyegresspb.StartSessionRequest{RoomName: "studio", TrackName: "main", ParticipantIdentity: "camera-a"},
+			event: &lkproto.WebhookEvent{
+				Event:       webhook.EventParticipantLeft,
+				Room:        &lkproto.Room{Name: "lobby"},
+				Participant: &lkproto.ParticipantInfo{Identity: "camera-a"},
+			},
+			stopped: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wh, manager := newTestWebhookHandler(&testRoom{participants: test.participants})
+			session := stream.NewSession(test.request)
+			if _, err := manager.AddStream(session); err != nil {
+				t.Fatal(err)
+			}
+			defer manager.StopAll(context.Background(), "test over")
+
+			sendWebhook(t, wh, test.event)
+			if stopped := !manager.HasSession(session.Sid); stopped != test.stopped {
+				t.Errorf("got stopped %t, want %t", stopped, test.stopped)
+			}
+		})
+	}
+}
+
+func TestWebhookStopsSessionsOnOtherNodes(t *testing.T) {
+	stopped := make(chan *skyegresspb.StopSessionRequest, 1)
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := &skyegresspb.StopSessionRequest{}
+		if r.URL.Path != "/session/stop" || proto.Unmarshal(body, req) != nil || len(r.Header.Get(forwardedHeader)) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		stopped <- req
+		resb, _ := proto.Marshal(&skyegresspb.StopSessionResponse{})
+		w.Write(resb)
+	}))
+	defer remote.Close()
+
+	manager := stream.NewSkyEgressStreamManager("local", config.LiveKitConfig{}, config.CapacityConfig{}, config.CaptureConfig{}, nil)
+	registry := cluster.NewMemoryRegistry()
+	wh := &webhookHandler{
+		provider: auth.NewSimpleKeyProvider(testAPIKey, testAPISecret),
+		manager:  &manager,
+		cluster:  cluster.NewCluster(config.ClusterConfig{Heartbeat: time.Second}, registry, &manager),
+		rooms:    &testRoom{},
+	}
+
+	session := stream.NewSession(&skyegresspb.StartSessionRequest{RoomName: "studio", TrackName: "main"})
+	session.NodeId = "remote"
+	err := registry.Publish(context.Background(), &skyegresspb.NodeInfo{Id: "remote", Url: remote.URL}, []*skyegresspb.Session{session}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sendWebhook(t, wh, &lkproto.WebhookEvent{Event: webhook.EventRoomFinished, Room: &lkproto.Room{Name: "studio"}})
+	select {
+	case req := <-stopped:
+		if req.Sid != session.Sid || req.Reason != "room finished" {
+			t.Errorf("got stop of %s for %q", req.Sid, req.Reason)
+		}
+	default:
+		t.Fatal("the stop wasn't forwarded to the node running the session")
+	}
+}
@@ -0,0 +1,61 @@
+package stream
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+)
+
+// files named after a session carry the time they were started, so a restarted session doesn't overwrite them
+const fileTimeFormat = "20060102T150405Z"
+
+// builds sinks recording each session's frames to <dir>/<sid>-<time>.h264. The file is appended to if the session
+// restarts within the same second, which leaves a playable stream as each recording starts at a keyframe.
+func NewAnnexBSinkFactory(dir string) SinkFactory {
+	return func(session *skyegresspb.Session) (Sink, SinkOptions, error) {
+		name := fmt.Sprintf("%s-%s.h264", sidFileName(session.Sid), time.Now().UTC().Format(fileTimeFormat))
+		file, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, SinkOptions{}, err
+		}
+		return &annexBSink{file: file}, SinkOptions{Policy: SinkDropUntilKeyframe}, nil
+	}
+}
+
+// the SID escaped for use in a file name, so that no two SIDs share one: "a/b" becomes "a%2Fb", and "a-b" stays as
+// it is
+func sidFileName(sid string) string {
+	return url.PathEscape(sid)
+}
+
+// writes frames to a file as an Annex B H264 elementary stream, from the first keyframe so it can be played
+type annexBSink struct {
+	file    *os.File
+	started bool
+}
+
+func (as *annexBSink) WriteFrame(frame *Frame) error {
+	if !as.started && !frame.Keyframe {
+		return nil
+	}
+	as.started = true
+
+	data, err := h264.AnnexBMarshal(frame.NALUs)
+	if err != nil {
+		return err
+	}
+	_, err = as.file.Write(data)
+	return err
+}
+
+func (as *annexBSink) Close() error {
+	if err := as.file.Close(); err != nil {
+		return fmt.Errorf("unable to close %s: %w", as.file.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aler9/gortsplib/v2"
+)
+
+var (
+	// returned when a session or reader is turned away because the server is at capacity
+	ErrResourceExhausted = errors.New("resource exhausted")
+	// returned when a session or reader is turned away because the server is shutting down
+	ErrDraining = errors.New("server is draining")
+)
+
+// what the server is currently doing, measured against its capacity limits
+type Usage struct {
+	Sessions int    `json:"sessions"`
+	Readers  int    `json:"readers"`
+	Bitrate  uint64 `json:"bitrate"`
+}
+
+func (sm *SkyEgressStreamManager) Usage() Usage {
+	sm.streamsLock.RLock()
+	defer sm.streamsLock.RUnlock()
+	return sm.usage()
+}
+
+// must be called with the streams lock held
+func (sm *SkyEgressStreamManager) usage() Usage {
+	usage := Usage{Sessions: len(sm.streams) + len(sm.ingresses)}
+	for _, stream := range sm.streams {
+		usage.Readers += stream.ReaderCount()
+		usage.Bitrate += stream.EgressBitrate()
+	}
+	return usage
+}
+
+// whether the server can take on another session or reader at all
+func (sm *SkyEgressStreamManager) AtCapacity() error {
+	usage := sm.Usage()
+	switch {
+	case sm.capacity.Sessions > 0 && usage.Sessions >= sm.capacity.Sessions:
+		return fmt.Errorf("%w: %d of %d sessions in use", ErrResourceExhausted, usage.Sessions, sm.capacity.Sessions)
+	case sm.capacity.Readers > 0 && usage.Readers >= sm.capacity.Readers:
+		return fmt.Errorf("%w: %d of %d readers in use", ErrResourceExhausted, usage.Readers, sm.capacity.Readers)
+	case sm.capacity.Bitrate > 0 && usage.Bitrate >= sm.capacity.Bitrate:
+		return fmt.Errorf("%w: %d of %d bps in use", ErrResourceExhausted, usage.Bitrate, sm.capacity.Bitrate)
+	}
+	return nil
+}
+
+// must be called with the streams lock held
+func (sm *SkyEgressStreamManager) admitSession() error {
+	if sm.draining {
+		return ErrDraining
+	}
+	usage := sm.usage()
+	if sm.capacity.Sessions > 0 && usage.Sessions >= sm.capacity.Sessions {
+		return fmt.Errorf("%w: already running %d of %d sessions", ErrResourceExhausted, usage.Sessions, sm.capacity.Sessions)
+	}
+	if sm.capacity.Bitrate > 0 && usage.Bitrate >= sm.capacity.Bitrate {
+		return fmt.Errorf("%w: already sending %d of %d bps", ErrResourceExhausted, usage.Bitrate, sm.capacity.Bitrate)
+	}
+	return nil
+}
+
+// admits the session as another RTSP reader of the stream, reserving its slot until it plays or closes
+func (sm *SkyEgressStreamManager) AdmitReader(stream *skyEgressStream, session *gortsplib.ServerSession) error {
+	// held for writing so concurrent SETUPs are admitted one at a time
+	sm.streamsLock.Lock()
+	defer sm.streamsLock.Unlock()
+
+	if sm.draining {
+		return ErrDraining
+	}
+
+	usage := sm.usage()
+	if readers := stream.ReaderCount(); sm.capacity.ReadersPerStream > 0 && readers >= sm.capacity.ReadersPerStream {
+		return fmt.Errorf("%w: stream already has %d of %d readers", ErrResourceExhausted, readers, sm.capacity.ReadersPerStream)
+	}
+	if sm.capacity.Readers > 0 && usage.Readers >= sm.capacity.Readers {
+		return fmt.Errorf("%w: already serving %d of %d readers", ErrResourceExhausted, usage.Readers, sm.capacity.Readers)
+	}
+	// another reader costs the stream's input bitrate again
+	if bitrate := usage.Bitrate + stream.InputBitrate(); sm.capacity.Bitrate > 0 && bitrate > sm.capacity.Bitrate {
+		return fmt.Errorf("%w: another reader would send %d of %d bps", ErrResourceExhausted, bitrate, sm.capacity.Bitrate)
+	}
+
+	stream.reserveReader(session)
+	return nil
+}
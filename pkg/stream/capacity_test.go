@@ -0,0 +1,67 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/aler9/gortsplib/v2"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/config"
+)
+
+func TestAdmitReaderReserves(t *testing.T) {
+	manager := NewSkyEgressStreamManager("node", config.LiveKitConfig{}, config.CapacityConfig{ReadersPerStream: 2, Readers: 3}, config.CaptureConfig{}, nil)
+	defer manager.StopAll(context.Background(), "test over")
+
+	first, err := manager.AddStream(NewSession(&skyegresspb.StartSessionRequest{RoomName: "studio", TrackName: "main"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := manager.AddStream(NewSession(&skyegresspb.StartSessionRequest{RoomName: "studio", TrackName: "wide"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// concurrent SETUPs that haven't played yet can't exceed the per-stream limit
+	sessions := make([]*gortsplib.ServerSession, 10)
+	admitted := make([]bool, len(sessions))
+	var wg sync.WaitGroup
+	for i := range sessions {
+		sessions[i] = &gortsplib.ServerSession{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			admitted[i] = manager.AdmitReader(first, sessions[i]) == nil
+		}(i)
+	}
+	wg.Wait()
+
+	var reserved []*gortsplib.ServerSession
+	for i, ok := range admitted {
+		if ok {
+			reserved = append(reserved, sessions[i])
+		}
+	}
+	if len(reserved) != 2 || first.ReaderCount() != 2 {
+		t.Fatalf("admitted %d readers holding %d slots, want 2", len(reserved), first.ReaderCount())
+	}
+
+	// nor the node-wide limit
+	if err := manager.AdmitReader(second, &gortsplib.ServerSession{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.AdmitReader(second, &gortsplib.ServerSession{}); !errors.Is(err, ErrResourceExhausted) {
+		t.Fatalf("got %v admitting a fourth reader to the node, want %v", err, ErrResourceExhausted)
+	}
+
+	// a session that closes without playing gives its slot back
+	first.RemoveReader(reserved[0])
+	if first.ReaderCount() != 1 {
+		t.Fatalf("got %d readers after one closed, want 1", first.ReaderCount())
+	}
+	if err := manager.AdmitReader(first, &gortsplib.ServerSession{}); err != nil {
+		t.Fatal(err)
+	}
+}
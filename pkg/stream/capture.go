@@ -0,0 +1,195 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/pcap"
+	"google.golang.org/protobuf/proto"
+)
+
+// UDP ports captured packets are written to, so tools can tell RTP from RTCP
+const (
+	captureRTPPort  = 5004
+	captureRTCPPort = 5005
+)
+
+// packets buffered for the capture's file; those received while it's full are dropped
+const captureBuffer = 1024
+
+// writes the packets a stream receives from its source to a pcapng file, until it's stopped or the file reaches its
+// size limit. Packets are written from a goroutine of the capture's own through a buffer, like a sink's, so a slow
+// disk doesn't hold up the relay.
+type packetCapture struct {
+	lock sync.Mutex
+	// nil while not capturing
+	items chan captureItem
+	// closed once the current or last capture's file is written and closed
+	done chan struct{}
+	// the current or last capture
+	stats *skyegresspb.CaptureStats
+}
+
+type captureItem struct {
+	at      time.Time
+	port    uint16
+	payload []byte
+}
+
+// starts capturing to a new file in the directory, named after the session and the time
+func (pc *packetCapture) start(sid string, dir string, maxBytes uint64) (*skyegresspb.CaptureStats, error) {
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
+	if pc.items != nil {
+		return nil, fmt.Errorf("already capturing to %s", pc.stats.Path)
+	}
+
+	name := fmt.Sprintf("%s-%s.pcapng", strings.ReplaceAll(sid, "/", "-"), time.Now().UTC().Format("20060102T150405Z"))
+	path := filepath.Join(dir, name)
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	comment := fmt.Sprintf("skyegress session %s: RTP on UDP port %d, RTCP on %d", sid, captureRTPPort, captureRTCPPort)
+	writer, err := pcap.NewWriter(file, comment)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	fmt.Printf("capturing stream %s to %s\n", sid, path)
+	pc.items = make(chan captureItem, captureBuffer)
+	pc.done = make(chan struct{})
+	pc.stats = &skyegresspb.CaptureStats{
+		Path:     path,
+		Active:   true,
+		Bytes:    uint64(writer.Written()),
+		MaxBytes: maxBytes,
+	}
+	go pc.run(pc.items, pc.done, file, writer, pc.stats)
+	return pc.snapshotLocked(), nil
+}
+
+// stops capturing, noting why, once what's buffered is written; an error if there's no capture to stop
+func (pc *packetCapture) stop(reason string) (*skyegresspb.CaptureStats, error) {
+	pc.lock.Lock()
+	if pc.items == nil {
+		pc.lock.Unlock()
+		return nil, errors.New("not capturing")
+	}
+	pc.stopLocked(reason)
+	done := pc.done
+	pc.lock.Unlock()
+
+	<-done
+	return pc.snapshot(), nil
+}
+
+func (pc *packetCapture) stopLocked(reason string) {
+	fmt.Printf("stopped capturing to %s: %s\n", pc.stats.Path, reason)
+	close(pc.items)
+	pc.items = nil
+	pc.stats.Active = false
+	pc.stats.StoppedReason = reason
+}
+
+// writes the buffered packets to the file until the capture stops, then closes it
+func (pc *packetCapture) run(items chan captureItem, done chan struct{}, file *os.File, writer *pcap.Writer, stats *skyegresspb.CaptureStats) {
+	defer close(done)
+
+	// the datagram's headers and block framing come to well under this
+	const overhead = 64
+	stopped := false
+	for item := range items {
+		// what was buffered once the capture stopped itself
+		if stopped {
+			continue
+		}
+		var reason string
+		if uint64(writer.Written())+uint64(len(item.payload))+overhead > stats.MaxBytes {
+			reason = fmt.Sprintf("reached the size limit of %d bytes", stats.MaxBytes)
+		} else if err := writer.WriteUDP(item.at, item.port, item.port, item.payload); err != nil {
+			reason = fmt.Sprintf("unable to write: %s", err)
+		}
+
+		pc.lock.Lock()
+		if len(reason) == 0 {
+			stats.Packets++
+			stats.Bytes = uint64(writer.Written())
+		} else if pc.items == items {
+			pc.stopLocked(reason)
+		}
+		pc.lock.Unlock()
+		stopped = len(reason) > 0
+	}
+
+	if err := file.Close(); err != nil {
+		fmt.Printf("unable to close capture %s: %s\n", stats.Path, err)
+		pc.lock.Lock()
+		stats.StoppedReason = fmt.Sprintf("unable to close: %s", err)
+		pc.lock.Unlock()
+	}
+}
+
+func (pc *packetCapture) writeRTP(pkt *rtp.Packet) {
+	pc.write(captureRTPPort, pkt.Marshal)
+}
+
+func (pc *packetCapture) writeRTCP(pkt rtcp.Packet) {
+	pc.write(captureRTCPPort, pkt.Marshal)
+}
+
+// buffers the packet as marshalled if capturing, only marshalling it then
+func (pc *packetCapture) write(port uint16, marshal func() ([]byte, error)) {
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
+	if pc.items == nil {
+		return
+	}
+	payload, err := marshal()
+	if err != nil {
+		return
+	}
+
+	select {
+	case pc.items <- captureItem{at: time.Now(), port: port, payload: payload}:
+	default:
+		pc.stats.Dropped++
+	}
+}
+
+// the current or last capture; nil if there's never been one
+func (pc *packetCapture) snapshot() *skyegresspb.CaptureStats {
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
+	return pc.snapshotLocked()
+}
+
+func (pc *packetCapture) snapshotLocked() *skyegresspb.CaptureStats {
+	if pc.stats == nil {
+		return nil
+	}
+	return proto.Clone(pc.stats).(*skyegresspb.CaptureStats)
+}
+
+// starts capturing the packets the stream receives to a pcapng file in the directory, stopping once it reaches the
+// size limit
+func (ss *skyEgressStream) StartCapture(dir string, maxBytes uint64) (*skyegresspb.CaptureStats, error) {
+	return ss.capture.start(ss.session.Sid, dir, maxBytes)
+}
+
+func (ss *skyEgressStream) StopCapture() (*skyegresspb.CaptureStats, error) {
+	return ss.capture.stop("stopped by request")
+}
+
+func (ss *skyEgressStream) ReceivedRTCP(source Source, pkt rtcp.Packet) {
+	ss.capture.writeRTCP(pkt)
+}
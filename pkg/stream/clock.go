@@ -0,0 +1,174 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+)
+
+// how often readers are sent RTCP sender reports
+const senderReportInterval = 5 * time.Second
+
+// seconds between the NTP epoch (1900) and the unix epoch (1970)
+const ntpEpochOffset = 2208988800
+
+// the capture time of a relay's packets, from the NTP/RTP mapping in the sender reports of the track it reads, or
+// the mapping a source producing its own video gives
+type senderClock struct {
+	lock      sync.Mutex
+	clockRate uint32
+
+	hasMapping bool
+	ntp        time.Time
+	rtp        uint32
+	// when the last sender report arrived; zero if the mapping is the source's own
+	receivedAt time.Time
+}
+
+func newSenderClock(clockRate uint32) *senderClock {
+	return &senderClock{clockRate: clockRate}
+}
+
+func (sc *senderClock) onSenderReport(sr *rtcp.SenderReport) {
+	sc.set(fromNTP(sr.NTPTime), sr.RTPTime, time.Now())
+}
+
+// maps the timestamp to its capture time, as a sender report does; sources that produce their own video know it.
+// The mapping isn't passed off as coming from a sender report.
+func (sc *senderClock) update(wallClock time.Time, timestamp uint32) {
+	sc.set(wallClock, timestamp, time.Time{})
+}
+
+func (sc *senderClock) set(wallClock time.Time, timestamp uint32, receivedAt time.Time) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	sc.hasMapping = true
+	sc.ntp = wallClock
+	sc.rtp = timestamp
+	sc.receivedAt = receivedAt
+}
+
+// the capture time of the packet with the timestamp, and when the sender report mapping it arrived, zero if the
+// mapping is the source's own; false before the first mapping
+func (sc *senderClock) wallClock(timestamp uint32) (time.Time, time.Time, bool) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	if !sc.hasMapping || sc.clockRate == 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	// timestamps wrap, so the packet may be either side of the report
+	ticks := int64(int32(timestamp - sc.rtp))
+	return sc.ntp.Add(time.Duration(ticks) * time.Second / time.Duration(sc.clockRate)), sc.receivedAt, true
+}
+
+// the wall-clock time of the RTP sent to readers, as of the last packet written
+type outputClock struct {
+	lock sync.Mutex
+
+	written   bool
+	ssrc      uint32
+	rtp       uint32
+	wallClock time.Time
+	origin    clockOrigin
+	// when the sender report the capture time comes from arrived
+	senderReportAt time.Time
+}
+
+// where the capture time of a packet comes from
+type clockOrigin int
+
+const (
+	// the time the packet arrived, without a mapping to go on
+	clockFromArrival clockOrigin = iota
+	// the source's RTCP sender reports
+	clockFromSenderReport
+	// the source itself, replaying or generating video with timing of its own
+	clockFromSource
+)
+
+// records a packet written to readers, after its timestamp has been rewritten
+func (oc *outputClock) onWritten(pkt *rtp.Packet, wallClock time.Time, origin clockOrigin, senderReportAt time.Time) {
+	oc.lock.Lock()
+	defer oc.lock.Unlock()
+	oc.written = true
+	oc.ssrc = pkt.SSRC
+	oc.rtp = pkt.Timestamp
+	oc.wallClock = wallClock
+	oc.origin = origin
+	oc.senderReportAt = senderReportAt
+}
+
+// the mapping as readers see it; nil before the first packet
+func (oc *outputClock) mapping() *skyegresspb.ClockMapping {
+	oc.lock.Lock()
+	defer oc.lock.Unlock()
+	if !oc.written {
+		return nil
+	}
+	mapping := &skyegresspb.ClockMapping{
+		RtpTimestamp:     oc.rtp,
+		Ssrc:             oc.ssrc,
+		ClockRate:        h264ClockRate,
+		WallClockNs:      oc.wallClock.UnixNano(),
+		FromSenderReport: oc.origin == clockFromSenderReport,
+		FromSource:       oc.origin == clockFromSource,
+	}
+	if !oc.senderReportAt.IsZero() {
+		mapping.SenderReportAt = oc.senderReportAt.UnixMilli()
+	}
+	return mapping
+}
+
+// a sender report for readers, with the RTP timestamp carried on from the last packet to the time it's sent
+func (oc *outputClock) senderReport(now time.Time, packets uint64, octets uint64) (*rtcp.SenderReport, bool) {
+	oc.lock.Lock()
+	defer oc.lock.Unlock()
+	if !oc.written {
+		return nil, false
+	}
+	elapsed := now.Sub(oc.wallClock)
+	return &rtcp.SenderReport{
+		SSRC:        oc.ssrc,
+		NTPTime:     toNTP(now),
+		RTPTime:     oc.rtp + uint32(int64(elapsed.Seconds()*h264ClockRate)),
+		PacketCount: uint32(packets),
+		OctetCount:  uint32(octets),
+	}, true
+}
+
+// sends readers sender reports until the stream stops. gortsplib's own reports write nanoseconds where the NTP
+// fraction belongs and take the time a packet was written as its capture time, so they are disabled in favour of
+// these.
+func (ss *skyEgressStream) sendSenderReports() {
+	ticker := time.NewTicker(senderReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ss.ctx.Done():
+			return
+		case now := <-ticker.C:
+			stats := ss.stats.snapshot()
+			sr, ok := ss.clock.senderReport(now, stats.PacketsRelayed, stats.BytesRelayed)
+			if !ok {
+				continue
+			}
+			ss.sinks.writeRTCP(sr)
+		}
+	}
+}
+
+func toNTP(t time.Time) uint64 {
+	seconds := uint64(t.Unix() + ntpEpochOffset)
+	fraction := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return seconds<<32 | fraction
+}
+
+func fromNTP(ntp uint64) time.Time {
+	seconds := int64(ntp>>32) - ntpEpochOffset
+	nanos := (ntp & 0xFFFFFFFF) * uint64(time.Second) >> 32
+	return time.Unix(seconds, int64(nanos))
+}
@@ -0,0 +1,118 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+func TestNTPKnownValues(t *testing.T) {
+	tests := []struct {
+		name string
+		time time.Time
+		ntp  uint64
+	}{
+		{"unix epoch", time.Unix(0, 0), 0x83aa7e80_00000000},
+		{"half a second past the unix epoch", time.Unix(0, 500_000_000), 0x83aa7e80_80000000},
+		{"a quarter second past the unix epoch", time.Unix(0, 250_000_000), 0x83aa7e80_40000000},
+		{"2000", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), 0xbc17c200_00000000},
+		{"2036, just before the era rolls over", time.Date(2036, 2, 7, 6, 28, 15, 0, time.UTC), 0xffffffff_00000000},
+	}
+	for _, test := range tests {
+		if got := toNTP(test.time); got != test.ntp {
+			t.Errorf("%s: toNTP got %#x, want %#x", test.name, got, test.ntp)
+		}
+		if got := fromNTP(test.ntp); !got.Equal(test.time) {
+			t.Errorf("%s: fromNTP got %s, want %s", test.name, got, test.time)
+		}
+	}
+}
+
+func TestNTPRoundTrip(t *testing.T) {
+	base := time.Date(2024, 5, 17, 12, 34, 56, 0, time.UTC)
+	for _, nanos := range []int{0, 1, 2, 999, 123_456_789, 500_000_000, 999_999_999} {
+		want := base.Add(time.Duration(nanos))
+		got := fromNTP(toNTP(want))
+		// the fraction holds a little under a nanosecond, and both directions round down
+		if diff := want.Sub(got); diff < 0 || diff > time.Nanosecond {
+			t.Errorf("%s came back as %s", want, got)
+		}
+	}
+}
+
+func TestSenderClockMapping(t *testing.T) {
+	reportedAt := time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		rtp       uint32
+		timestamp uint32
+		want      time.Time
+	}{
+		{"at the report", 5000, 5000, reportedAt},
+		{"a second after", 5000, 5000 + h264ClockRate, reportedAt.Add(time.Second)},
+		{"before the report", 9000, 0, reportedAt.Add(-100 * time.Millisecond)},
+		{"after the timestamp wraps", 0xffffffff - 4499, 4500, reportedAt.Add(100 * time.Millisecond)},
+		{"before the report across the wrap", 4500, 0xffffffff - 4499, reportedAt.Add(-100 * time.Millisecond)},
+	}
+	for _, test := range tests {
+		sc := newSenderClock(h264ClockRate)
+		if _, _, ok := sc.wallClock(test.timestamp); ok {
+			t.Fatalf("%s: mapped a timestamp before any sender report", test.name)
+		}
+		sc.onSenderReport(&rtcp.SenderReport{NTPTime: toNTP(reportedAt), RTPTime: test.rtp})
+		got, receivedAt, ok := sc.wallClock(test.timestamp)
+		if !ok || receivedAt.IsZero() {
+			t.Fatalf("%s: got no mapping from the sender report", test.name)
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+
+	// a source's own mapping isn't passed off as a sender report
+	sc := newSenderClock(h264ClockRate)
+	sc.update(reportedAt, 1000)
+	if got, receivedAt, ok := sc.wallClock(1000); !ok || !got.Equal(reportedAt) || !receivedAt.IsZero() {
+		t.Errorf("got %s received at %s, %t for the source's own mapping", got, receivedAt, ok)
+	}
+}
+
+func TestOutputClockSenderReport(t *testing.T) {
+	oc := &outputClock{}
+	writtenAt := time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)
+	if _, ok := oc.senderReport(writtenAt, 0, 0); ok {
+		t.Fatal("built a sender report before any packet was written")
+	}
+
+	pkt := &rtp.Packet{Header: rtp.Header{SSRC: 42, Timestamp: 0xffffffff - 44999}}
+	oc.onWritten(pkt, writtenAt, clockFromSenderReport, writtenAt.Add(-time.Second))
+
+	sr, ok := oc.senderReport(writtenAt.Add(time.Second), 300, 120000)
+	if !ok {
+		t.Fatal("got no sender report")
+	}
+	want := rtcp.SenderReport{
+		SSRC: 42,
+		// a second on from the capture time of the last packet, in the NTP format and in RTP ticks, wrapping
+		NTPTime:     toNTP(writtenAt.Add(time.Second)),
+		RTPTime:     45000,
+		PacketCount: 300,
+		OctetCount:  120000,
+	}
+	if sr.SSRC != want.SSRC || sr.NTPTime != want.NTPTime || sr.RTPTime != want.RTPTime ||
+		sr.PacketCount != want.PacketCount || sr.OctetCount != want.OctetCount {
+		t.Fatalf("got %+v, want %+v", *sr, want)
+	}
+	// the NTP fraction is a fraction of a second, not nanoseconds
+	half := toNTP(writtenAt.Add(500 * time.Millisecond))
+	if half&0xffffffff != 0x80000000 {
+		t.Fatalf("got NTP fraction %#x half a second in, want 0x80000000", half&0xffffffff)
+	}
+
+	mapping := oc.mapping()
+	if !mapping.FromSenderReport || mapping.FromSource || mapping.SenderReportAt != writtenAt.Add(-time.Second).UnixMilli() {
+		t.Errorf("got mapping %v", mapping)
+	}
+}
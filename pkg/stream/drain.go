@@ -0,0 +1,92 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// how often a draining node checks whether its sessions have ended
+const drainCheckInterval = time.Second
+
+// how often closing readers checks whether the server has torn down their sessions
+const readersCloseCheckInterval = 50 * time.Millisecond
+
+// stops admitting sessions and readers; existing streams keep running
+func (sm *SkyEgressStreamManager) Drain() {
+	sm.streamsLock.Lock()
+	defer sm.streamsLock.Unlock()
+	sm.draining = true
+}
+
+// stops admitting sessions and readers, and lets existing streams run until they end or the deadline passes, at
+// which point they're stopped. Drained is closed once no streams are left. Draining again keeps the first deadline.
+func (sm *SkyEgressStreamManager) DrainBy(deadline time.Time) {
+	sm.streamsLock.Lock()
+	defer sm.streamsLock.Unlock()
+
+	if !sm.drainDeadline.IsZero() {
+		return
+	}
+	sm.draining = true
+	sm.drainDeadline = deadline
+	go sm.waitDrained(deadline)
+}
+
+func (sm *SkyEgressStreamManager) waitDrained(deadline time.Time) {
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if time.Now().After(deadline) {
+			stopped := sm.StopAll(context.Background(), "drain deadline passed")
+			fmt.Printf("drain deadline passed, stopped %d sessions %v\n", len(stopped), stopped)
+			break
+		}
+		if sm.Usage().Sessions == 0 {
+			break
+		}
+	}
+	close(sm.drained)
+}
+
+// closed once a drain requested with DrainBy has no streams left
+func (sm *SkyEgressStreamManager) Drained() <-chan struct{} {
+	return sm.drained
+}
+
+// whether the node is draining, and when remaining streams will be stopped; the deadline is zero for a drain
+// without one
+func (sm *SkyEgressStreamManager) DrainStatus() (bool, time.Time) {
+	sm.streamsLock.RLock()
+	defer sm.streamsLock.RUnlock()
+	return sm.draining, sm.drainDeadline
+}
+
+// tears down the RTSP session of every reader, including those admitted that haven't played yet, and waits until
+// the server reports them closed or the context is done. Returns how many readers were torn down.
+func (sm *SkyEgressStreamManager) CloseReaders(ctx context.Context) int {
+	sm.streamsLock.RLock()
+	streams := make([]*skyEgressStream, 0, len(sm.streams))
+	for _, stream := range sm.streams {
+		streams = append(streams, stream)
+	}
+	sm.streamsLock.RUnlock()
+
+	closed := 0
+	for _, stream := range streams {
+		closed += stream.closeReaders()
+	}
+
+	ticker := time.NewTicker(readersCloseCheckInterval)
+	defer ticker.Stop()
+	for sm.Usage().Readers > 0 {
+		select {
+		case <-ctx.Done():
+			fmt.Printf("%d RTSP readers didn't close in time\n", sm.Usage().Readers)
+			return closed
+		case <-ticker.C:
+		}
+	}
+	return closed
+}
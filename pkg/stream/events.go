@@ -0,0 +1,41 @@
+package stream
+
+import (
+	"time"
+
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+)
+
+// receives session lifecycle events; called synchronously, so it must not block
+type EventListener func(event *skyegresspb.SessionEvent)
+
+func NewSessionEvent(
+	eventType skyegresspb.SessionEventType,
+	session *skyegresspb.Session,
+	reason string,
+) *skyegresspb.SessionEvent {
+	return &skyegresspb.SessionEvent{
+		Type:      eventType,
+		Session:   session,
+		Reason:    reason,
+		Timestamp: time.Now().UnixMilli(),
+	}
+}
+
+// the lifecycle event implied by a session changing state, if any
+func eventForTransition(from skyegresspb.SessionState, to skyegresspb.SessionState) (skyegresspb.SessionEventType, bool) {
+	switch to {
+	case skyegresspb.SessionState_SESSION_STATE_ACTIVE:
+		if from == skyegresspb.SessionState_SESSION_STATE_SOURCE_LOST {
+			return skyegresspb.SessionEventType_SESSION_EVENT_RECONNECTED, true
+		}
+		return skyegresspb.SessionEventType_SESSION_EVENT_STARTED, true
+	case skyegresspb.SessionState_SESSION_STATE_SOURCE_LOST:
+		return skyegresspb.SessionEventType_SESSION_EVENT_SOURCE_LOST, true
+	case skyegresspb.SessionState_SESSION_STATE_STOPPED:
+		return skyegresspb.SessionEventType_SESSION_EVENT_STOPPED, true
+	case skyegresspb.SessionState_SESSION_STATE_FAILED:
+		return skyegresspb.SessionEventType_SESSION_EVENT_FAILED, true
+	}
+	return skyegresspb.SessionEventType_SESSION_EVENT_UNSPECIFIED, false
+}
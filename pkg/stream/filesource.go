@@ -0,0 +1,305 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/mp4"
+	"github.com/treyhaknson/skyegress/pkg/pcap"
+	"github.com/treyhaknson/skyegress/pkg/testpattern"
+)
+
+// frame rate Annex B files are replayed at unless the URL says otherwise; they carry no timing of their own
+const defaultAnnexBFrameRate = 30
+
+// file formats by extension, for URLs that don't name one
+var fileFormats = map[string]string{
+	".h264":   "annexb",
+	".264":    "annexb",
+	".mp4":    "mp4",
+	".m4v":    "mp4",
+	".mov":    "mp4",
+	".pcap":   "pcap",
+	".pcapng": "pcap",
+}
+
+// builds sources replaying files inside the media root as though they were live: file:///root/path/to/clip.mp4, or
+// file:path/to/clip.mp4 relative to the root. Captures can be replayed from the capture directory as well, so it
+// needn't be inside the media root. Files outside both, including through .. or symlinks, are refused. The format is
+// taken from the extension unless given as format=annexb|mp4|pcap, the file loops unless loop=false, and Annex B
+// files play at fps=30 by default. Captures are replayed with their original timing, from the RTP stream with ssrc=,
+// or the first in the capture.
+func NewFileSourceFactory(root string, captureDir string) SourceFactory {
+	return func(session *skyegresspb.Session) (Source, error) {
+		return newFileSource(root, captureDir, session)
+	}
+}
+
+func newFileSource(root string, captureDir string, session *skyegresspb.Session) (Source, error) {
+	u, err := url.Parse(session.Source)
+	if err != nil {
+		return nil, err
+	}
+	path := u.Path
+	if len(path) == 0 {
+		// file:relative/path
+		path = u.Opaque
+	}
+	if len(path) == 0 {
+		return nil, errors.New("no file given")
+	}
+	query := u.Query()
+	format := query.Get("format")
+	if len(format) == 0 {
+		format = fileFormats[strings.ToLower(filepath.Ext(path))]
+	}
+	path, err = resolveSourcePath(root, captureDir, path, format)
+	if err != nil {
+		return nil, err
+	}
+	loop := query.Get("loop") != "false"
+
+	var play func(ctx context.Context, track *queuedTrack) error
+	switch format {
+	case "annexb":
+		var fps int
+		fps, err = queryInt(query, "fps", defaultAnnexBFrameRate)
+		if err != nil {
+			return nil, err
+		}
+		play, err = annexBReplay(path, fps, loop)
+	case "mp4":
+		play, err = mp4Replay(path, loop)
+	case "pcap":
+		var ssrc uint64
+		if value := query.Get("ssrc"); len(value) > 0 {
+			ssrc, err = strconv.ParseUint(value, 0, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SSRC %s", value)
+			}
+		}
+		play, err = pcapReplay(path, uint32(ssrc), len(query.Get("ssrc")) > 0, loop)
+	default:
+		return nil, fmt.Errorf("unknown format of %s; give one with format=annexb|mp4|pcap", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newReplaySource(session.Source, play), nil
+}
+
+// plays the access units of an Annex B elementary stream at the frame rate
+func annexBReplay(path string, fps int, loop bool) (func(ctx context.Context, track *queuedTrack) error, error) {
+	clip, err := testpattern.LoadClip(path)
+	if err != nil {
+		return nil, err
+	}
+	frames, duration := steadyFrames(clip, fps)
+	return func(ctx context.Context, track *queuedTrack) error {
+		return playFrames(ctx, track, frames, duration, loop, nil)
+	}, nil
+}
+
+// plays the samples of the file's H264 track with their own timing, reading each as it's due
+func mp4Replay(path string, loop bool) (func(ctx context.Context, track *queuedTrack) error, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	video, err := mp4.ReadH264(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+
+	return func(ctx context.Context, track *queuedTrack) error {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		// frames are sent in decode order at their decode time; a reordered clip may show its first before then
+		var shift int64
+		for _, sample := range video.Samples {
+			if pts := int64(sample.DTS) + int64(sample.CTSOffset); pts < -shift {
+				shift = -pts
+			}
+		}
+		frames := make([]timedFrame, len(video.Samples))
+		for i, sample := range video.Samples {
+			sample := sample
+			frames[i] = timedFrame{
+				read: func() ([][]byte, error) { return video.AccessUnit(file, sample) },
+				dts:  video.Time(sample.DTS + uint64(shift)),
+				pts:  video.Time(uint64(int64(sample.DTS) + int64(sample.CTSOffset) + shift)),
+			}
+		}
+		return playFrames(ctx, track, frames, video.Time(video.Duration), loop, nil)
+	}, nil
+}
+
+// replays an RTP stream from a capture with the timing it was captured with, numbering each loop on from the last
+func pcapReplay(path string, ssrc uint32, hasSSRC bool, loop bool) (func(ctx context.Context, track *queuedTrack) error, error) {
+	// find the stream now, so a capture without one fails the session rather than its replay
+	if !hasSSRC {
+		err := readCapture(context.Background(), path, func(pkt *rtp.Packet, _ time.Time) bool {
+			ssrc = pkt.SSRC
+			hasSSRC = true
+			return false
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !hasSSRC {
+			return nil, fmt.Errorf("no RTP packets in %s", path)
+		}
+	}
+
+	return func(ctx context.Context, track *queuedTrack) error {
+		start := time.Now()
+		// how far each loop's packets are carried on from the capture's own
+		var seqOffset uint16
+		var tsOffset uint32
+		var elapsed time.Duration
+
+		for {
+			var first, last time.Time
+			var firstSeq, lastSeq uint16
+			var firstTs, lastTs uint32
+			var frameGap time.Duration
+			packets := 0
+
+			err := readCapture(ctx, path, func(pkt *rtp.Packet, at time.Time) bool {
+				if pkt.SSRC != ssrc {
+					return true
+				}
+				if packets == 0 {
+					first, firstSeq, firstTs = at, pkt.SequenceNumber, pkt.Timestamp
+				} else if pkt.Timestamp != lastTs {
+					frameGap = at.Sub(last)
+				}
+				last, lastSeq, lastTs = at, pkt.SequenceNumber, pkt.Timestamp
+				packets++
+
+				due := start.Add(elapsed + at.Sub(first))
+				if sleepUntil(ctx, due) != nil {
+					return false
+				}
+				pkt.SequenceNumber += seqOffset
+				pkt.Timestamp += tsOffset
+				track.clock.update(due, pkt.Timestamp)
+				return track.push(ctx, pkt)
+			})
+			if err != nil {
+				return err
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if packets == 0 {
+				return fmt.Errorf("no RTP packets with SSRC %d in %s", ssrc, path)
+			}
+			if !loop {
+				return nil
+			}
+
+			// the next loop starts a frame after this one's last
+			if frameGap <= 0 {
+				frameGap = time.Second / defaultAnnexBFrameRate
+			}
+			loopDuration := last.Sub(first) + frameGap
+			elapsed += loopDuration
+			seqOffset += lastSeq - firstSeq + 1
+			tsOffset += lastTs - firstTs + uint32(frameGap.Seconds()*h264ClockRate)
+		}
+	}, nil
+}
+
+// calls fn with each RTP packet in the capture and when it was captured, until fn returns false
+func readCapture(ctx context.Context, path string, fn func(pkt *rtp.Packet, at time.Time) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader, err := pcap.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %w", path, err)
+	}
+
+	for ctx.Err() == nil {
+		captured, err := reader.ReadPacket()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %w", path, err)
+		}
+		payload, ok := captured.UDPPayload()
+		if !ok {
+			continue
+		}
+		// RTCP multiplexed on the same port has a payload type of 72 to 76 when read as RTP
+		if len(payload) < 2 || payload[1]&0x7f >= 64 && payload[1]&0x7f < 96 {
+			continue
+		}
+		pkt := &rtp.Packet{}
+		if err := pkt.Unmarshal(payload); err != nil || pkt.Version != 2 {
+			continue
+		}
+		if !fn(pkt, captured.Timestamp) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// the file the path names inside the root, with any symlinks resolved; an error if it's anywhere else
+// resolves the path of a file source inside the media root, or of a capture inside the capture directory
+func resolveSourcePath(root string, captureDir string, path string, format string) (string, error) {
+	resolved, err := resolveMediaPath(root, path)
+	if err == nil || format != "pcap" {
+		return resolved, err
+	}
+	if resolved, captureErr := resolveMediaPath(captureDir, path); captureErr == nil {
+		return resolved, nil
+	}
+	return "", err
+}
+
+func resolveMediaPath(root string, path string) (string, error) {
+	if len(root) == 0 {
+		return "", errors.New("file sources are disabled")
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the media root", path)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,53 @@
+package stream
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveSourcePath(t *testing.T) {
+	dir := t.TempDir()
+	root, captureDir := filepath.Join(dir, "media"), filepath.Join(dir, "captures")
+	for _, path := range []string{
+		filepath.Join(root, "clip.mp4"),
+		filepath.Join(root, "call.pcapng"),
+		filepath.Join(captureDir, "devroom-demo.pcapng"),
+		filepath.Join(captureDir, "clip.mp4"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		captureDir string
+		path       string
+		format     string
+		want       string
+	}{
+		{"inside the media root", captureDir, "clip.mp4", "mp4", filepath.Join(root, "clip.mp4")},
+		{"a capture inside the media root", captureDir, filepath.Join(root, "call.pcapng"), "pcap", filepath.Join(root, "call.pcapng")},
+		{"a capture in the capture directory", captureDir, filepath.Join(captureDir, "devroom-demo.pcapng"), "pcap", filepath.Join(captureDir, "devroom-demo.pcapng")},
+		{"a capture relative to the capture directory", captureDir, "devroom-demo.pcapng", "pcap", filepath.Join(captureDir, "devroom-demo.pcapng")},
+		{"another file in the capture directory", captureDir, filepath.Join(captureDir, "clip.mp4"), "mp4", ""},
+		{"outside both", captureDir, filepath.Join(captureDir, "..", "other.pcapng"), "pcap", ""},
+		{"captures without a capture directory", "", filepath.Join(captureDir, "devroom-demo.pcapng"), "pcap", ""},
+	}
+	for _, test := range tests {
+		got, err := resolveSourcePath(root, test.captureDir, test.path, test.format)
+		if len(test.want) == 0 {
+			if err == nil {
+				t.Errorf("%s: got %s, want it refused", test.name, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("%s: got %s, %v, want %s", test.name, got, err, test.want)
+		}
+	}
+}
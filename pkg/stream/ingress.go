@@ -0,0 +1,453 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/v2"
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+	"github.com/aler9/gortsplib/v2/pkg/format"
+	"github.com/aler9/gortsplib/v2/pkg/formatdecenc/rtph264"
+	"github.com/aler9/gortsplib/v2/pkg/media"
+	"github.com/aler9/gortsplib/v2/pkg/url"
+	lksdk "github.com/livekit/server-sdk-go"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/config"
+)
+
+const (
+	// the track an ingress session publishes its video as unless the request names one
+	defaultIngressTrackName = "camera"
+	// video is published in packets no larger than this, to fit the WebRTC MTU whatever the camera sent
+	ingressMaxPayload = 1200
+	// how far presentation times carry on past the last frame when the RTSP source reconnects
+	ingressReconnectGap = time.Second / 30
+	// the profile and level video is announced as when the source doesn't describe its SPS: constrained baseline 3.1
+	defaultProfileLevelID = "42e01f"
+	// the H264 profile that can't have B-frames
+	h264ProfileBaseline = 66
+)
+
+// the codec audio is published to LiveKit in
+var ingressAudioCodec = webrtc.RTPCodecCapability{
+	MimeType:  webrtc.MimeTypeOpus,
+	ClockRate: 48000,
+	Channels:  2,
+}
+
+// the codec video is published to LiveKit in, announcing the profile and level of the SPS so subscribers pick a
+// decoder that can play it
+func ingressVideoCodec(sps []byte) webrtc.RTPCodecCapability {
+	profileLevelID := defaultProfileLevelID
+	if len(sps) >= 4 {
+		profileLevelID = fmt.Sprintf("%02x%02x%02x", sps[1], sps[2], sps[3])
+	}
+	return webrtc.RTPCodecCapability{
+		MimeType:    webrtc.MimeTypeH264,
+		ClockRate:   h264ClockRate,
+		SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=" + profileLevelID,
+	}
+}
+
+// rejects video whose SPS says it reorders frames, as WebRTC subscribers play frames in the order they arrive and
+// B-frames would stutter or fail to decode. Video that may reorder but doesn't say is only warned about; frames
+// arriving out of order are caught as they're played.
+func checkReordering(sps []byte, from string) error {
+	if len(sps) == 0 {
+		return nil
+	}
+	parsed := h264.SPS{}
+	if err := parsed.Unmarshal(sps); err != nil {
+		return fmt.Errorf("unable to parse the SPS of %s: %w", from, err)
+	}
+	if parsed.ProfileIdc == h264ProfileBaseline {
+		return nil
+	}
+	if parsed.VUI != nil && parsed.VUI.BitstreamRestriction != nil {
+		if parsed.VUI.BitstreamRestriction.MaxNumReorderFrames > 0 {
+			return fmt.Errorf("%s has B-frames, which WebRTC can't play; configure it for the baseline profile or without B-frames", from)
+		}
+		return nil
+	}
+	fmt.Printf("%s uses H264 profile %d, which may have B-frames that WebRTC can't play\n", from, parsed.ProfileIdc)
+	return nil
+}
+
+// pulls the H264 video and any Opus audio of an RTSP server, such as an IP camera, and publishes them into a LiveKit
+// room. The RTSP connection is made again whenever it drops, and the tracks stay published meanwhile, so subscribers
+// carry on once the video resumes. Publish sessions take the same media from an RTSP publisher pushing to the server
+// instead, for as long as it stays connected.
+type ingressSession struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	// nil for publish sessions
+	pull    *rtspPull
+	lkHost  string
+	lkInfo  lksdk.ConnectInfo
+	onEvent EventListener
+
+	sessionLock sync.RWMutex
+	session     *skyegresspb.Session
+	// received is what the RTSP server sent, relayed what was published to LiveKit
+	stats relayStats
+
+	lock  sync.Mutex
+	room  *lksdk.Room
+	video *webrtc.TrackLocalStaticRTP
+	audio *webrtc.TrackLocalStaticRTP
+	// the RTSP publisher of a publish session, and the media it announced
+	published *ingressConnection
+	publisher *gortsplib.ServerSession
+
+	// repacketizes the video for LiveKit, numbering packets on across RTSP connections. Only used by the connection
+	// being played.
+	encoder *rtph264.Encoder
+	// how far the presentation times of the current connection are carried on from the last one's, and the last sent
+	ptsOffset time.Duration
+	lastPTS   time.Duration
+}
+
+// one connection to the RTSP server, or an RTSP publisher, and the media being played from it
+type ingressConnection struct {
+	videoMedia *media.Media
+	video      *format.H264
+	// nil if the server has no Opus audio
+	audioMedia *media.Media
+	audio      *format.Opus
+
+	decoder *rtph264.Decoder
+	params  parameterSets
+	// the presentation time of the last access unit, to catch frames arriving out of order
+	lastPTS   time.Duration
+	reordered bool
+}
+
+func newIngressSession(session *skyegresspb.Session, lkCfg config.LiveKitConfig, onEvent EventListener) (*ingressSession, error) {
+	var u *url.URL
+	// publish sessions are pushed to the server rather than pulled
+	if session.Kind == skyegresspb.SessionKind_SESSION_KIND_INGRESS {
+		var err error
+		u, err = url.Parse(session.Source)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme != "rtsp" && u.Scheme != "rtsps" {
+			return nil, fmt.Errorf("ingress sessions pull from rtsp:// or rtsps:// URLs, not %s", RedactSource(session.Source))
+		}
+	}
+
+	encoder := &rtph264.Encoder{
+		PayloadType:       h264PayloadType,
+		PacketizationMode: h264PacketizationMode,
+		PayloadMaxSize:    ingressMaxPayload,
+	}
+	encoder.Init()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	is := &ingressSession{
+		ctx:     ctx,
+		cancel:  cancel,
+		lkHost:  lkCfg.Host,
+		onEvent: onEvent,
+		lkInfo: lksdk.ConnectInfo{
+			APIKey:              lkCfg.ApiKey,
+			APISecret:           lkCfg.ApiSecret,
+			RoomName:            session.RoomName,
+			ParticipantIdentity: session.EgressIdentity,
+		},
+		session: session,
+		encoder: encoder,
+	}
+	if u != nil {
+		is.pull = newRTSPPull(ctx, u)
+		is.pull.play = is.play
+		is.pull.onEnd = func(err error) {
+			if ctx.Err() == nil {
+				is.loseSource(fmt.Sprintf("RTSP source disconnected: %s", err))
+			}
+		}
+	}
+	return is, nil
+}
+
+// returns a snapshot of the session and its stats, safe to hand to other goroutines
+func (is *ingressSession) Session() *skyegresspb.Session {
+	is.sessionLock.RLock()
+	session := cloneSession(is.session)
+	is.sessionLock.RUnlock()
+
+	session.Stats = is.stats.snapshot()
+	return session
+}
+
+func (is *ingressSession) State() skyegresspb.SessionState {
+	is.sessionLock.RLock()
+	defer is.sessionLock.RUnlock()
+	return is.session.State
+}
+
+func (is *ingressSession) setState(state skyegresspb.SessionState, reason string) {
+	is.sessionLock.Lock()
+	from := is.session.State
+	if from == state {
+		is.sessionLock.Unlock()
+		return
+	}
+	fmt.Printf("ingress %s changed state %s -> %s (%s)\n", is.session.Sid, from, state, reason)
+	is.session.State = state
+	session := cloneSession(is.session)
+	is.sessionLock.Unlock()
+	session.Stats = is.stats.snapshot()
+
+	if eventType, ok := eventForTransition(from, state); ok && is.onEvent != nil {
+		is.onEvent(NewSessionEvent(eventType, session, reason))
+	}
+}
+
+// flags an active session as having lost its source; a session that never published packets stays starting
+func (is *ingressSession) loseSource(reason string) {
+	if is.State() != skyegresspb.SessionState_SESSION_STATE_ACTIVE {
+		return
+	}
+	is.setState(skyegresspb.SessionState_SESSION_STATE_SOURCE_LOST, reason)
+}
+
+// joins the room, then connects to the RTSP server and publishes its video, connecting again whenever it drops
+func (is *ingressSession) Start() error {
+	if err := is.joinRoom(); err != nil {
+		return err
+	}
+	return is.pull.start()
+}
+
+// joins the room and publishes the media the RTSP publisher announced, which is sent once it records. Stopping the
+// session disconnects the publisher.
+func (is *ingressSession) StartPublish(publisher *gortsplib.ServerSession, medias media.Medias) error {
+	conn, err := newIngressConnection(medias, fmt.Sprintf("RTSP publisher of %s", is.session.Sid))
+	if err != nil {
+		return err
+	}
+	if err := is.joinRoom(); err != nil {
+		return err
+	}
+	if err := is.prepare(conn); err != nil {
+		return err
+	}
+	is.lock.Lock()
+	is.publisher = publisher
+	is.published = conn
+	is.lock.Unlock()
+	return nil
+}
+
+// sends on the packets the RTSP publisher records
+func (is *ingressSession) Record() {
+	is.lock.Lock()
+	conn, publisher := is.published, is.publisher
+	is.lock.Unlock()
+	is.receive(conn, publisher.OnPacketRTP)
+}
+
+// whether the session takes its media from the RTSP publisher's session
+func (is *ingressSession) PublishedBy(publisher *gortsplib.ServerSession) bool {
+	is.lock.Lock()
+	defer is.lock.Unlock()
+	return is.publisher == publisher
+}
+
+// joins the room the tracks are published into
+func (is *ingressSession) joinRoom() error {
+	wsURL := fmt.Sprintf("wss://%s", is.lkHost)
+	room, err := lksdk.ConnectToRoom(wsURL, is.lkInfo, &lksdk.RoomCallback{
+		OnDisconnected: func() {
+			// a disconnect we asked for is not a failure
+			if is.ctx.Err() == nil {
+				is.setState(skyegresspb.SessionState_SESSION_STATE_FAILED, "disconnected from LiveKit room")
+			}
+		},
+		OnReconnecting: func() { is.loseSource("reconnecting to LiveKit room") },
+	})
+	if err != nil {
+		return err
+	}
+	is.lock.Lock()
+	is.room = room
+	is.lock.Unlock()
+	// stopped while it was connecting
+	if is.ctx.Err() != nil {
+		room.Disconnect()
+		return is.ctx.Err()
+	}
+	return nil
+}
+
+func (is *ingressSession) Stop(reason string) {
+	is.cancel()
+	is.setState(skyegresspb.SessionState_SESSION_STATE_STOPPED, reason)
+
+	is.lock.Lock()
+	publisher, room := is.publisher, is.room
+	is.lock.Unlock()
+	if is.pull != nil {
+		is.pull.close()
+	}
+	if publisher != nil {
+		publisher.Close()
+	}
+	if room != nil {
+		room.Disconnect()
+	}
+}
+
+// finds the H264 video among the media, and the audio if it's Opus
+func newIngressConnection(medias media.Medias, from string) (*ingressConnection, error) {
+	conn := &ingressConnection{}
+	conn.videoMedia = medias.FindFormat(&conn.video)
+	if conn.videoMedia == nil {
+		return nil, fmt.Errorf("%s has no H264 video", from)
+	}
+	if err := checkReordering(conn.video.SPS, from); err != nil {
+		return nil, err
+	}
+	conn.audioMedia = medias.FindFormat(&conn.audio)
+	if conn.audioMedia == nil {
+		for _, medi := range medias {
+			if medi.Type == media.TypeAudio && len(medi.Formats) > 0 {
+				fmt.Printf("audio of %s is %s; only Opus is published\n", from, medi.Formats[0])
+			}
+		}
+	}
+	return conn, nil
+}
+
+// sets up the server's H264 video, and its audio if it's Opus, publishing them into the room if not already
+// published, and plays them
+func (is *ingressSession) play(client *gortsplib.Client, medias media.Medias, baseURL *url.URL) error {
+	conn, err := newIngressConnection(medias, fmt.Sprintf("RTSP source %s", is.pull.name))
+	if err != nil {
+		return err
+	}
+	if err := setupMedias(client, baseURL, conn.videoMedia, conn.audioMedia); err != nil {
+		return err
+	}
+	if err := is.prepare(conn); err != nil {
+		return err
+	}
+	is.receive(conn, client.OnPacketRTP)
+
+	_, err = client.Play(nil)
+	return err
+}
+
+// publishes the connection's media into the room, if not already published, and readies it to be sent
+func (is *ingressSession) prepare(conn *ingressConnection) error {
+	if err := is.publish(conn); err != nil {
+		return err
+	}
+
+	conn.decoder = &rtph264.Decoder{PacketizationMode: h264PacketizationMode}
+	conn.decoder.Init()
+	conn.params = parameterSets{sps: conn.video.SPS, pps: conn.video.PPS}
+	// presentation times start from zero with each connection
+	is.ptsOffset = is.lastPTS + ingressReconnectGap
+	is.stats.resetSequence()
+	return nil
+}
+
+// sends the connection's packets to the published tracks as they arrive, registering with onPacketRTP of the
+// client or server session they come from
+func (is *ingressSession) receive(conn *ingressConnection, onPacketRTP func(*media.Media, format.Format, func(*rtp.Packet))) {
+	onPacketRTP(conn.videoMedia, conn.video, func(pkt *rtp.Packet) {
+		is.onVideoPacket(conn, pkt)
+	})
+	if conn.audioMedia != nil {
+		audio := is.audioTrack()
+		onPacketRTP(conn.audioMedia, conn.audio, func(pkt *rtp.Packet) {
+			audio.WriteRTP(pkt)
+		})
+	}
+}
+
+// publishes the video track the first time, and the audio track the first time the server has audio
+func (is *ingressSession) publish(conn *ingressConnection) error {
+	is.lock.Lock()
+	defer is.lock.Unlock()
+
+	is.sessionLock.RLock()
+	trackName := is.session.TrackName
+	is.sessionLock.RUnlock()
+
+	if is.video == nil {
+		video, err := webrtc.NewTrackLocalStaticRTP(ingressVideoCodec(conn.video.SPS), "video", is.lkInfo.ParticipantIdentity)
+		if err != nil {
+			return err
+		}
+		options := &lksdk.TrackPublicationOptions{Name: trackName}
+		sps := h264.SPS{}
+		if err := sps.Unmarshal(conn.video.SPS); err == nil {
+			options.VideoWidth, options.VideoHeight = sps.Width(), sps.Height()
+		}
+		if _, err := is.room.LocalParticipant.PublishTrack(video, options); err != nil {
+			return fmt.Errorf("unable to publish video: %w", err)
+		}
+		is.video = video
+	}
+
+	if is.audio == nil && conn.audioMedia != nil {
+		audio, err := webrtc.NewTrackLocalStaticRTP(ingressAudioCodec, "audio", is.lkInfo.ParticipantIdentity)
+		if err != nil {
+			return err
+		}
+		options := &lksdk.TrackPublicationOptions{Name: trackName + "-audio"}
+		if _, err := is.room.LocalParticipant.PublishTrack(audio, options); err != nil {
+			return fmt.Errorf("unable to publish audio: %w", err)
+		}
+		is.audio = audio
+	}
+	return nil
+}
+
+func (is *ingressSession) audioTrack() *webrtc.TrackLocalStaticRTP {
+	is.lock.Lock()
+	defer is.lock.Unlock()
+	return is.audio
+}
+
+// repacketizes each access unit of the camera's video for LiveKit, with parameter sets ahead of every IDR
+func (is *ingressSession) onVideoPacket(conn *ingressConnection, pkt *rtp.Packet) {
+	is.stats.onReceived(pkt)
+	au, pts, err := conn.decoder.DecodeUntilMarker(pkt)
+	if err != nil {
+		return
+	}
+
+	if !conn.params.update(au) && h264.IDRPresent(au) {
+		if params := conn.params.nalus(); params != nil {
+			au = append(params, au...)
+		}
+	}
+	if pts < conn.lastPTS && !conn.reordered {
+		conn.reordered = true
+		fmt.Printf("video of ingress %s arrives out of presentation order; B-frames won't play smoothly over WebRTC\n", is.session.Sid)
+	}
+	conn.lastPTS = pts
+
+	pts += is.ptsOffset
+	is.lastPTS = pts
+	pkts, err := is.encoder.Encode(au, pts)
+	if err != nil {
+		return
+	}
+	for _, out := range pkts {
+		if err := is.video.WriteRTP(out); err != nil {
+			return
+		}
+		is.stats.onRelayed(out)
+	}
+	is.stats.onFrame(au)
+	is.setState(skyegresspb.SessionState_SESSION_STATE_ACTIVE, "publishing packets")
+}
@@ -0,0 +1,218 @@
+package stream
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	lksdk "github.com/livekit/server-sdk-go"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v3"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+)
+
+// a connection to the LiveKit room a stream relays the session's primary and backup tracks from
+type liveKitSource struct {
+	host string
+	info lksdk.ConnectInfo
+
+	lock    sync.Mutex
+	stream  SourceStream
+	room    *lksdk.Room
+	stopped bool
+	// tracks are wrapped once, so a track subscribed again is recognised as the one already being relayed; removed
+	// once unsubscribed
+	tracks map[*webrtc.TrackRemote]*liveKitTrack
+}
+
+func newLiveKitSource(host string, info lksdk.ConnectInfo) *liveKitSource {
+	return &liveKitSource{
+		host:   host,
+		info:   info,
+		tracks: make(map[*webrtc.TrackRemote]*liveKitTrack),
+	}
+}
+
+func (ls *liveKitSource) Start(stream SourceStream) error {
+	ls.lock.Lock()
+	ls.stream = stream
+	ls.lock.Unlock()
+
+	wsURL := fmt.Sprintf("wss://%s", ls.host)
+	room, err := lksdk.ConnectToRoom(
+		wsURL,
+		ls.info,
+		&lksdk.RoomCallback{
+			OnDisconnected:            ls.onDisconnected,
+			OnReconnecting:            func() { stream.SourceLost(ls, "reconnecting to LiveKit room") },
+			OnParticipantDisconnected: ls.onParticipantDisconnected,
+			ParticipantCallback: lksdk.ParticipantCallback{
+				OnTrackSubscribed:   ls.onTrackSubscribed,
+				OnTrackUnsubscribed: ls.onTrackUnsubscribed,
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	ls.lock.Lock()
+	ls.room = room
+	stopped := ls.stopped
+	ls.lock.Unlock()
+
+	// stopped while it was still connecting
+	if stopped {
+		room.Disconnect()
+	}
+	return nil
+}
+
+// leaves the room; the stream no longer relays from it, so the disconnect isn't a failure
+func (ls *liveKitSource) Stop() {
+	ls.lock.Lock()
+	ls.stopped = true
+	room := ls.room
+	ls.lock.Unlock()
+
+	if room != nil {
+		room.Disconnect()
+	}
+}
+
+func (ls *liveKitSource) isStopped() bool {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+	return ls.stopped
+}
+
+func (ls *liveKitSource) onDisconnected() {
+	if ls.isStopped() {
+		return
+	}
+	ls.stream.SourceFailed(ls, "disconnected from LiveKit room")
+}
+
+func (ls *liveKitSource) onTrackSubscribed(
+	track *webrtc.TrackRemote,
+	publication *lksdk.RemoteTrackPublication,
+	rp *lksdk.RemoteParticipant,
+) {
+	session := ls.stream.Session()
+
+	// every track in the room is auto-subscribed; only relay the ones we were asked for
+	var role skyegresspb.SessionSource
+	switch {
+	case matchesTrack(publication, rp, session.TrackName, session.ParticipantIdentity):
+		role = skyegresspb.SessionSource_SESSION_SOURCE_PRIMARY
+	case matchesTrack(publication, rp, session.BackupTrackName, session.BackupParticipantIdentity):
+		role = skyegresspb.SessionSource_SESSION_SOURCE_BACKUP
+	default:
+		return
+	}
+	if !strings.EqualFold(track.Codec().MimeType, "video/h264") {
+		return
+	}
+
+	ls.lock.Lock()
+	lt, ok := ls.tracks[track]
+	if !ok {
+		lt = &liveKitTrack{
+			track: track,
+			rp:    rp,
+			clock: newSenderClock(track.Codec().ClockRate),
+		}
+		ls.tracks[track] = lt
+	}
+	ls.lock.Unlock()
+
+	if !ok {
+		// the publisher's sender reports give the capture time of each packet
+		publication.OnRTCP(func(pkt rtcp.Packet) {
+			ls.stream.ReceivedRTCP(ls, pkt)
+			if sr, ok := pkt.(*rtcp.SenderReport); ok && sr.SSRC == uint32(track.SSRC()) {
+				lt.clock.onSenderReport(sr)
+			}
+		})
+	}
+	ls.stream.AddTrack(ls, lt, role)
+}
+
+// forgets the track; its relay ends by itself once reading it fails, and a track subscribed again is new
+func (ls *liveKitSource) onTrackUnsubscribed(
+	track *webrtc.TrackRemote,
+	publication *lksdk.RemoteTrackPublication,
+	rp *lksdk.RemoteParticipant,
+) {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+	delete(ls.tracks, track)
+}
+
+// forgets the tracks of a participant that left, in case they weren't all reported unsubscribed
+func (ls *liveKitSource) onParticipantDisconnected(rp *lksdk.RemoteParticipant) {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+	for track, lt := range ls.tracks {
+		if lt.rp == rp {
+			delete(ls.tracks, track)
+		}
+	}
+}
+
+// relays the matching tracks that were subscribed before the stream targeted them
+func (ls *liveKitSource) relayExisting() {
+	ls.lock.Lock()
+	room := ls.room
+	ls.lock.Unlock()
+	if room == nil {
+		return
+	}
+
+	for _, rp := range room.GetParticipants() {
+		for _, publication := range rp.Tracks() {
+			remote, ok := publication.(*lksdk.RemoteTrackPublication)
+			if !ok || remote.TrackRemote() == nil {
+				continue
+			}
+			ls.onTrackSubscribed(remote.TrackRemote(), remote, rp)
+		}
+	}
+}
+
+func matchesTrack(publication *lksdk.RemoteTrackPublication, rp *lksdk.RemoteParticipant, trackName string, participantIdentity string) bool {
+	if len(trackName) == 0 || publication.Name() != trackName {
+		return false
+	}
+	return len(participantIdentity) == 0 || rp.Identity() == participantIdentity
+}
+
+// a track subscribed in a LiveKit room
+type liveKitTrack struct {
+	track *webrtc.TrackRemote
+	rp    *lksdk.RemoteParticipant
+	clock *senderClock
+}
+
+func (lt *liveKitTrack) ReadRTP() (*rtp.Packet, error) {
+	pkt, _, err := lt.track.ReadRTP()
+	return pkt, err
+}
+
+func (lt *liveKitTrack) ClockRate() uint32 {
+	return lt.track.Codec().ClockRate
+}
+
+func (lt *liveKitTrack) Publisher() (string, string) {
+	return lt.rp.Identity(), lt.rp.Metadata()
+}
+
+func (lt *liveKitTrack) RequestKeyframe() {
+	lt.rp.WritePLI(lt.track.SSRC())
+}
+
+func (lt *liveKitTrack) CaptureTime(timestamp uint32) (time.Time, time.Time, bool) {
+	return lt.clock.wallClock(timestamp)
+}
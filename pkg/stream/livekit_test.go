@@ -0,0 +1,30 @@
+package stream
+
+import (
+	"testing"
+
+	lksdk "github.com/livekit/server-sdk-go"
+	"github.com/pion/webrtc/v3"
+)
+
+func TestLiveKitSourceForgetsTracks(t *testing.T) {
+	ls := newLiveKitSource("localhost", lksdk.ConnectInfo{})
+	left, stayed := &lksdk.RemoteParticipant{}, &lksdk.RemoteParticipant{}
+	unsubscribed, ofLeft, ofStayed := &webrtc.TrackRemote{}, &webrtc.TrackRemote{}, &webrtc.TrackRemote{}
+	ls.tracks[unsubscribed] = &liveKitTrack{track: unsubscribed, rp: stayed}
+	ls.tracks[ofLeft] = &liveKitTrack{track: ofLeft, rp: left}
+	ls.tracks[ofStayed] = &liveKitTrack{track: ofStayed, rp: stayed}
+
+	ls.onTrackUnsubscribed(unsubscribed, nil, stayed)
+	if _, ok := ls.tracks[unsubscribed]; ok {
+		t.Fatal("kept an unsubscribed track")
+	}
+
+	ls.onParticipantDisconnected(left)
+	if _, ok := ls.tracks[ofLeft]; ok {
+		t.Fatal("kept the track of a participant that left")
+	}
+	if _, ok := ls.tracks[ofStayed]; !ok || len(ls.tracks) != 1 {
+		t.Fatalf("got %d tracks, want only the one still subscribed", len(ls.tracks))
+	}
+}
@@ -0,0 +1,121 @@
+package stream
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+	"github.com/pion/rtp"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+)
+
+// what a processor knows about the packet it's given
+type PacketInfo struct {
+	SID               string
+	PublisherIdentity string
+	PublisherMetadata string
+	// wall-clock time the packet's frame was captured, from the publisher's sender reports when there are any
+	CaptureTime time.Time
+	ClockRate   uint32
+	// the packet starts a keyframe
+	Keyframe bool
+}
+
+// a stage in the pipeline packets take from LiveKit to readers. Process is given each packet the session relays, in
+// order and never concurrently, and returns the packets to send on in its place: the packet, possibly modified, with
+// any packets to insert around it, or nothing to drop it. The packets are renumbered afterwards, so returned packets
+// needn't have sequence numbers of their own.
+type Processor interface {
+	Process(pkt *rtp.Packet, info *PacketInfo) []*rtp.Packet
+}
+
+// builds a processor for a session; called once for every session it's configured for
+type ProcessorFactory func(session *skyegresspb.Session) (Processor, error)
+
+var processorFactories = newRegistry(map[string]ProcessorFactory{
+	"strip-sei": func(session *skyegresspb.Session) (Processor, error) { return seiStripper{}, nil },
+})
+
+// makes a processor available to sessions listing the name among their processors
+func RegisterProcessor(name string, factory ProcessorFactory) {
+	processorFactories.register(name, factory)
+}
+
+// the processors of a session, in the order they see packets
+type processorChain struct {
+	lock   sync.Mutex
+	stages []Processor
+}
+
+// the chain of the processors the session names, followed by the SEI injector so it sees the frames as readers will
+func newProcessorChain(session *skyegresspb.Session) (*processorChain, error) {
+	chain := &processorChain{}
+	for _, name := range session.Processors {
+		factory, ok := processorFactories.get(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown processor %s", name)
+		}
+		processor, err := factory(session)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create processor %s: %w", name, err)
+		}
+		chain.stages = append(chain.stages, processor)
+	}
+	if session.SeiMode != skyegresspb.SeiMode_SEI_MODE_OFF {
+		chain.stages = append(chain.stages, newSEIInjector(session.Sid, session.SeiMode))
+	}
+	return chain, nil
+}
+
+// passes the packet through every stage, each given every packet the one before returned
+func (pc *processorChain) process(pkt *rtp.Packet, info *PacketInfo) []*rtp.Packet {
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
+
+	pkts := []*rtp.Packet{pkt}
+	for _, stage := range pc.stages {
+		out := []*rtp.Packet{}
+		for _, p := range pkts {
+			out = append(out, stage.Process(p, info)...)
+		}
+		pkts = out
+	}
+	return pkts
+}
+
+// drops the SEI NAL units the publisher sends, whether in packets of their own, fragmented, or aggregated with other
+// NAL units
+type seiStripper struct{}
+
+func (seiStripper) Process(pkt *rtp.Packet, info *PacketInfo) []*rtp.Packet {
+	if len(pkt.Payload) == 0 {
+		return []*rtp.Packet{pkt}
+	}
+	switch h264.NALUType(pkt.Payload[0] & 0x1F) {
+	case h264.NALUTypeSEI:
+		return nil
+
+	case h264.NALUTypeFUA:
+		if len(pkt.Payload) > 1 && h264.NALUType(pkt.Payload[1]&0x1F) == h264.NALUTypeSEI {
+			return nil
+		}
+
+	case h264.NALUTypeSTAPA:
+		nalus := packetNALUs(pkt.Payload)
+		kept := make([][]byte, 0, len(nalus))
+		for _, n := range nalus {
+			if h264.NALUType(n[0]&0x1F) != h264.NALUTypeSEI {
+				kept = append(kept, n)
+			}
+		}
+		if len(kept) == len(nalus) {
+			break
+		}
+		if len(kept) == 0 {
+			return nil
+		}
+		pkt.Payload = aggregate(kept)
+	}
+	return []*rtp.Packet{pkt}
+}
@@ -0,0 +1,44 @@
+package stream
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pion/rtp"
+)
+
+func TestSEIStripper(t *testing.T) {
+	sei := []byte{0x06, 0x05, 0x01, 0xaa, 0x80}
+	tests := []struct {
+		name    string
+		payload []byte
+		// the payload sent on; nil if the packet is dropped
+		want []byte
+	}{
+		{"SEI", sei, nil},
+		{"slice", testIDR, testIDR},
+		{"fragmented SEI", []byte{0x7c, 0x86, 0x05, 0x01}, nil},
+		{"fragmented slice", []byte{0x7c, 0x85, 0x88, 0x84}, []byte{0x7c, 0x85, 0x88, 0x84}},
+		{"STAP-A with an SEI among parameter sets", stapA(testSPS, testPPS, sei, testIDR), stapA(testSPS, testPPS, testIDR)},
+		{"STAP-A leaving a single NAL unit", stapA(sei, testIDR), testIDR},
+		{"STAP-A of SEI alone", stapA(sei, sei), nil},
+		{"STAP-A without SEI", stapA(testSPS, testPPS), stapA(testSPS, testPPS)},
+	}
+	for _, test := range tests {
+		pkt := &rtp.Packet{Header: rtp.Header{Marker: true}, Payload: append([]byte(nil), test.payload...)}
+		out := seiStripper{}.Process(pkt, &PacketInfo{})
+		if test.want == nil {
+			if len(out) != 0 {
+				t.Errorf("%s: sent %d packets, want it dropped", test.name, len(out))
+			}
+			continue
+		}
+		if len(out) != 1 || !bytes.Equal(out[0].Payload, test.want) {
+			t.Errorf("%s: got %s, want %x", test.name, describePackets(out), test.want)
+			continue
+		}
+		if !out[0].Marker {
+			t.Errorf("%s: lost the marker", test.name)
+		}
+	}
+}
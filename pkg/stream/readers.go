@@ -0,0 +1,153 @@
+package stream
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aler9/gortsplib/v2"
+	"github.com/livekit/protocol/utils"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+)
+
+// an RTSP client playing the stream
+type rtspReader struct {
+	id          string
+	session     *gortsplib.ServerSession
+	address     string
+	connectedAt time.Time
+}
+
+func (r *rtspReader) stats() *skyegresspb.ReaderStats {
+	transport := ""
+	if t := r.session.SetuppedTransport(); t != nil {
+		transport = t.String()
+	}
+	return &skyegresspb.ReaderStats{
+		Id:          r.id,
+		Address:     r.address,
+		Transport:   transport,
+		BytesSent:   r.session.BytesSent(),
+		ConnectedAt: r.connectedAt.UnixMilli(),
+	}
+}
+
+// holds a reader's slot from SETUP until it plays
+func (ss *skyEgressStream) reserveReader(session *gortsplib.ServerSession) {
+	ss.readersLock.Lock()
+	defer ss.readersLock.Unlock()
+	ss.reserved[session] = struct{}{}
+}
+
+func (ss *skyEgressStream) AddReader(session *gortsplib.ServerSession, conn *gortsplib.ServerConn) {
+	ss.readersLock.Lock()
+	defer ss.readersLock.Unlock()
+
+	delete(ss.reserved, session)
+
+	// a paused reader that plays again is the same reader
+	if _, ok := ss.readers[session]; ok {
+		return
+	}
+	ss.readers[session] = &rtspReader{
+		id:          utils.NewGuid("RD_"),
+		session:     session,
+		address:     conn.NetConn().RemoteAddr().String(),
+		connectedAt: time.Now(),
+	}
+	ss.idleSince = time.Time{}
+}
+
+// removes the reader, or releases its slot if it never played
+func (ss *skyEgressStream) RemoveReader(session *gortsplib.ServerSession) {
+	ss.readersLock.Lock()
+	defer ss.readersLock.Unlock()
+
+	delete(ss.reserved, session)
+
+	if _, ok := ss.readers[session]; !ok {
+		return
+	}
+	delete(ss.readers, session)
+	if len(ss.readers) == 0 {
+		ss.idleSince = time.Now()
+	}
+}
+
+// tears down the session of every reader, returning how many there were; each is removed when gortsplib reports
+// its session closed
+func (ss *skyEgressStream) closeReaders() int {
+	ss.readersLock.RLock()
+	sessions := make([]*gortsplib.ServerSession, 0, len(ss.readers)+len(ss.reserved))
+	for session := range ss.readers {
+		sessions = append(sessions, session)
+	}
+	for session := range ss.reserved {
+		sessions = append(sessions, session)
+	}
+	ss.readersLock.RUnlock()
+
+	for _, session := range sessions {
+		session.Close()
+	}
+	return len(sessions)
+}
+
+// disconnects the reader with the given ID, returning its final stats
+func (ss *skyEgressStream) KickReader(id string) (*skyegresspb.ReaderStats, error) {
+	ss.readersLock.RLock()
+	var reader *rtspReader
+	for _, r := range ss.readers {
+		if r.id == id {
+			reader = r
+			break
+		}
+	}
+	ss.readersLock.RUnlock()
+
+	if reader == nil {
+		return nil, fmt.Errorf("reader %s is not connected to stream %s", id, ss.session.Sid)
+	}
+
+	stats := reader.stats()
+	// the reader is removed when gortsplib reports the session closed
+	err := reader.session.Close()
+	return stats, err
+}
+
+// bits per second received from LiveKit
+func (ss *skyEgressStream) InputBitrate() uint64 {
+	return ss.stats.snapshot().InputBitrate
+}
+
+// bits per second sent to RTSP readers; every reader is sent its own copy of the input
+func (ss *skyEgressStream) EgressBitrate() uint64 {
+	return ss.InputBitrate() * uint64(ss.ReaderCount())
+}
+
+// readers playing the stream, and those admitted that haven't played yet
+func (ss *skyEgressStream) ReaderCount() int {
+	ss.readersLock.RLock()
+	defer ss.readersLock.RUnlock()
+	return len(ss.readers) + len(ss.reserved)
+}
+
+// how long the stream has gone without readers; 0 while any are connected
+func (ss *skyEgressStream) IdleFor() time.Duration {
+	ss.readersLock.RLock()
+	defer ss.readersLock.RUnlock()
+	if ss.idleSince.IsZero() {
+		return 0
+	}
+	return time.Since(ss.idleSince)
+}
+
+func (ss *skyEgressStream) Readers() []*skyegresspb.ReaderStats {
+	ss.readersLock.RLock()
+	defer ss.readersLock.RUnlock()
+
+	stats := make([]*skyegresspb.ReaderStats, 0, len(ss.readers))
+	for _, reader := range ss.readers {
+		stats = append(stats, reader.stats())
+	}
+	return stats
+}
@@ -0,0 +1,27 @@
+package stream
+
+import "sync"
+
+// the factories of processors, sinks or sources sessions can name. Registering replaces whatever was registered
+// under the name before, and only affects sessions started afterwards.
+type registry[F any] struct {
+	lock      sync.RWMutex
+	factories map[string]F
+}
+
+func newRegistry[F any](factories map[string]F) *registry[F] {
+	return &registry[F]{factories: factories}
+}
+
+func (r *registry[F]) register(name string, factory F) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.factories[name] = factory
+}
+
+func (r *registry[F]) get(name string) (F, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	factory, ok := r.factories[name]
+	return factory, ok
+}
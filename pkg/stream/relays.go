@@ -0,0 +1,144 @@
+package stream
+
+import (
+	"time"
+
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+)
+
+// the relays of a stream. Only the active relay writes to readers. The target is the relay of the track the stream
+// should be showing, which takes over from the active one on its first keyframe: the primary, or the backup while the
+// primary isn't delivering packets. Any other relay stops once it isn't active.
+type relaySet struct {
+	nextID uint64
+	active uint64
+	// relays of the primary and backup tracks; 0 while the track isn't subscribed
+	primary uint64
+	backup  uint64
+
+	failoverTimeout time.Duration
+	lastPacketAt    map[uint64]time.Time
+	tracks          map[Track]uint64
+}
+
+func newRelaySet(failoverTimeout time.Duration) relaySet {
+	return relaySet{
+		failoverTimeout: failoverTimeout,
+		lastPacketAt:    make(map[uint64]time.Time),
+		tracks:          make(map[Track]uint64),
+	}
+}
+
+// must be called with the source lock held
+func (rs *relaySet) target() uint64 {
+	if rs.backup == 0 {
+		return rs.primary
+	}
+	if rs.primary == 0 || time.Since(rs.lastPacketAt[rs.primary]) > rs.failoverTimeout {
+		return rs.backup
+	}
+	return rs.primary
+}
+
+// registers a relay for the primary or backup track, unless one is already reading it
+func (ss *skyEgressStream) addRelay(track Track, role skyegresspb.SessionSource) (uint64, bool) {
+	ss.sourceLock.Lock()
+	defer ss.sourceLock.Unlock()
+
+	id, ok := ss.relays.tracks[track]
+	if !ok {
+		ss.relays.nextID++
+		id = ss.relays.nextID
+		ss.relays.tracks[track] = id
+		// a new relay gets as long as the failover timeout to deliver its first packet
+		ss.relays.lastPacketAt[id] = time.Now()
+	}
+
+	switch role {
+	case skyegresspb.SessionSource_SESSION_SOURCE_BACKUP:
+		ss.relays.backup = id
+	default:
+		ss.relays.primary = id
+	}
+	return id, !ok
+}
+
+func (ss *skyEgressStream) removeRelay(id uint64, track Track) {
+	ss.sourceLock.Lock()
+	defer ss.sourceLock.Unlock()
+
+	if ss.relays.tracks[track] == id {
+		delete(ss.relays.tracks, track)
+	}
+	delete(ss.relays.lastPacketAt, id)
+	if ss.relays.active == id {
+		ss.relays.active = 0
+	}
+	if ss.relays.primary == id {
+		ss.relays.primary = 0
+	}
+	if ss.relays.backup == id {
+		ss.relays.backup = 0
+	}
+}
+
+// forgets the primary and backup tracks, so the relays of the tracks the stream is switched to become the target
+func (ss *skyEgressStream) resetRelayTargets(failoverTimeout time.Duration) {
+	ss.sourceLock.Lock()
+	defer ss.sourceLock.Unlock()
+	ss.relays.primary = 0
+	ss.relays.backup = 0
+	ss.relays.failoverTimeout = failoverTimeout
+}
+
+func (ss *skyEgressStream) isActiveRelay(id uint64) bool {
+	ss.sourceLock.Lock()
+	defer ss.sourceLock.Unlock()
+	return ss.relays.active == id
+}
+
+// records a packet received by the relay, and returns whether it's the active relay, the target, and still reading
+// the primary or backup track
+func (ss *skyEgressStream) onRelayPacket(id uint64) (active bool, target bool, wanted bool) {
+	ss.sourceLock.Lock()
+	defer ss.sourceLock.Unlock()
+
+	ss.relays.lastPacketAt[id] = time.Now()
+	active = ss.relays.active == id
+	target = ss.relays.target() == id
+	wanted = ss.relays.primary == id || ss.relays.backup == id
+	return active, target, wanted
+}
+
+// makes the target relay the active one. The first relay of a stream takes over straight away; any later one only at
+// a keyframe, even once the previous relay is gone, so readers can decode from the first packet of the new source.
+func (ss *skyEgressStream) takeOver(id uint64, source Source, identity string, keyframe bool) bool {
+	written := ss.rewriter.hasStarted()
+	ss.sourceLock.Lock()
+	if ss.relays.target() != id || ((ss.relays.active != 0 || written) && !keyframe) {
+		ss.sourceLock.Unlock()
+		return false
+	}
+	ss.relays.active = id
+	role := skyegresspb.SessionSource_SESSION_SOURCE_PRIMARY
+	if id == ss.relays.backup {
+		role = skyegresspb.SessionSource_SESSION_SOURCE_BACKUP
+	}
+
+	// the switch to another source is complete; stop the old one
+	var retired Source
+	if source != ss.source {
+		retired = ss.source
+		ss.source = source
+		ss.pendingSource = nil
+	}
+	ss.sourceLock.Unlock()
+
+	ss.rewriter.switchSource(id)
+	ss.stats.resetSequence()
+	ss.setSource(identity, role)
+	if retired != nil {
+		go retired.Stop()
+	}
+	return true
+}
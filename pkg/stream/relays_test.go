@@ -0,0 +1,98 @@
+package stream
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+)
+
+// a track that never delivers; the tests drive the relays by hand
+type testTrack struct {
+	identity string
+}
+
+func (tt *testTrack) ReadRTP() (*rtp.Packet, error) { return nil, errors.New("no packets") }
+func (tt *testTrack) ClockRate() uint32             { return h264ClockRate }
+func (tt *testTrack) RequestKeyframe()              {}
+
+func (tt *testTrack) Publisher() (string, string) {
+	return tt.identity, ""
+}
+
+func (tt *testTrack) CaptureTime(timestamp uint32) (time.Time, time.Time, bool) {
+	return time.Time{}, time.Time{}, false
+}
+
+type testSource struct{}
+
+func (ts *testSource) Start(stream SourceStream) error { return nil }
+func (ts *testSource) Stop()                           {}
+
+func TestTakeOverWaitsForKeyframe(t *testing.T) {
+	var (
+		idr        = []byte{0x65, 0x88}
+		nonIDR     = []byte{0x41, 0x9a}
+		fuaIDR     = []byte{0x7c, 0x85, 0x88}
+		fuaMiddle  = []byte{0x7c, 0x05, 0x88}
+		stapSPS    = []byte{0x78, 0x00, 0x02, 0x67, 0x42, 0x00, 0x02, 0x68, 0xce}
+		stapNonIDR = []byte{0x78, 0x00, 0x02, 0x06, 0x05, 0x00, 0x02, 0x41, 0x9a}
+	)
+	tests := []struct {
+		name string
+		// payloads the backup relays once the primary stops delivering, and whether each takes over
+		payloads [][]byte
+		want     []bool
+	}{
+		{"single IDR", [][]byte{nonIDR, idr}, []bool{false, true}},
+		{"FU-A start", [][]byte{fuaMiddle, nonIDR, fuaIDR}, []bool{false, false, true}},
+		{"STAP-A of parameter sets", [][]byte{stapNonIDR, stapSPS}, []bool{false, true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ss := newSkyEgressStream(&skyegresspb.Session{Sid: "studio/main", FailoverTimeoutMs: 1000}, nil, nil, nil)
+			primarySource, backupSource := &testSource{}, &testSource{}
+			primary, _ := ss.addRelay(&testTrack{identity: "camera-a"}, skyegresspb.SessionSource_SESSION_SOURCE_PRIMARY)
+			ss.source = primarySource
+
+			// the first relay takes over at once, keyframe or not
+			if !ss.takeOver(primary, primarySource, "camera-a", false) {
+				t.Fatal("the first relay didn't take over")
+			}
+			ss.rewriter.rewrite(testPacket(10, 100, 0), h264ClockRate, primary)
+
+			backup, _ := ss.addRelay(&testTrack{identity: "camera-b"}, skyegresspb.SessionSource_SESSION_SOURCE_BACKUP)
+			// the backup isn't the target while the primary delivers
+			if ss.takeOver(backup, backupSource, "camera-b", true) {
+				t.Fatal("the backup took over from a primary still delivering")
+			}
+
+			ss.sourceLock.Lock()
+			ss.relays.lastPacketAt[primary] = time.Now().Add(-time.Hour)
+			ss.sourceLock.Unlock()
+			for i, payload := range test.payloads {
+				if got := ss.takeOver(backup, backupSource, "camera-b", isKeyframeStart(payload)); got != test.want[i] {
+					t.Fatalf("payload %d: got take over %t, want %t", i, got, test.want[i])
+				}
+			}
+
+			if !ss.isActiveRelay(backup) {
+				t.Fatal("the backup isn't the active relay")
+			}
+			session := ss.Session()
+			if session.ActiveSource != skyegresspb.SessionSource_SESSION_SOURCE_BACKUP || session.PublisherIdentity != "camera-b" {
+				t.Errorf("got source %s from %s", session.ActiveSource, session.PublisherIdentity)
+			}
+			// its first packet carries on from the primary's last
+			pkt := testPacket(20, 9000, 0)
+			if !ss.rewriter.rewrite(pkt, h264ClockRate, backup) || pkt.SequenceNumber != 101 {
+				t.Errorf("got sequence number %d after the switch, want 101", pkt.SequenceNumber)
+			}
+			if ss.rewriter.rewrite(testPacket(10, 101, 3000), h264ClockRate, primary) {
+				t.Error("the primary still writes after the backup took over")
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+	"github.com/aler9/gortsplib/v2/pkg/formatdecenc/rtph264"
+	"github.com/pion/rtp"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+)
+
+// packets queued for the relay of a track a source pushes to
+const queuedTrackBuffer = 256
+
+var errReplayFinished = errors.New("replay finished")
+
+// a track of the packets a source pushes from a goroutine of its own, for sources that read or generate video rather
+// than receive it from a peer
+type queuedTrack struct {
+	identity string
+	clock    *senderClock
+	packets  chan *rtp.Packet
+
+	endOnce sync.Once
+	ended   chan struct{}
+	err     error
+}
+
+func newQueuedTrack(identity string) *queuedTrack {
+	return &queuedTrack{
+		identity: identity,
+		clock:    newSenderClock(h264ClockRate),
+		packets:  make(chan *rtp.Packet, queuedTrackBuffer),
+		ended:    make(chan struct{}),
+	}
+}
+
+// queues the packet for the relay, waiting while the queue is full; false once the track has ended or the context
+// is cancelled
+func (qt *queuedTrack) push(ctx context.Context, pkt *rtp.Packet) bool {
+	select {
+	case qt.packets <- pkt:
+		return true
+	case <-qt.ended:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// ends the track; the relay reads the error once it has read what's queued
+func (qt *queuedTrack) end(err error) {
+	qt.endOnce.Do(func() {
+		qt.err = err
+		close(qt.ended)
+	})
+}
+
+func (qt *queuedTrack) ReadRTP() (*rtp.Packet, error) {
+	select {
+	case pkt := <-qt.packets:
+		return pkt, nil
+	case <-qt.ended:
+		select {
+		case pkt := <-qt.packets:
+			return pkt, nil
+		default:
+			return nil, qt.err
+		}
+	}
+}
+
+func (qt *queuedTrack) ClockRate() uint32 {
+	return h264ClockRate
+}
+
+func (qt *queuedTrack) Publisher() (string, string) {
+	return qt.identity, ""
+}
+
+// the video is played from the start of a loop, with keyframes as often as the clip has them
+func (qt *queuedTrack) RequestKeyframe() {}
+
+func (qt *queuedTrack) CaptureTime(timestamp uint32) (time.Time, time.Time, bool) {
+	return qt.clock.wallClock(timestamp)
+}
+
+// a source playing video it reads or generates to the stream in real time, as though it were live
+type replaySource struct {
+	identity string
+	// plays the video to the track until it ends or the context is cancelled
+	play func(ctx context.Context, track *queuedTrack) error
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newReplaySource(identity string, play func(ctx context.Context, track *queuedTrack) error) *replaySource {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &replaySource{
+		identity: identity,
+		play:     play,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func (rs *replaySource) Start(stream SourceStream) error {
+	track := newQueuedTrack(rs.identity)
+	stream.AddTrack(rs, track, skyegresspb.SessionSource_SESSION_SOURCE_PRIMARY)
+	go func() {
+		err := rs.play(rs.ctx, track)
+		if err == nil {
+			err = errReplayFinished
+		}
+		track.end(err)
+	}()
+	return nil
+}
+
+func (rs *replaySource) Stop() {
+	rs.cancel()
+}
+
+// an access unit of a clip, and when it's sent and shown relative to the start of the clip
+type timedFrame struct {
+	nalus [][]byte
+	// reads the NAL units as the frame is due, for clips too large to hold in memory
+	read func() ([][]byte, error)
+	dts  time.Duration
+	pts  time.Duration
+}
+
+// times access units without timing of their own at a steady frame rate, returning the frames and their duration
+func steadyFrames(clip [][][]byte, fps int) ([]timedFrame, time.Duration) {
+	interval := time.Second / time.Duration(fps)
+	frames := make([]timedFrame, len(clip))
+	for i, au := range clip {
+		at := time.Duration(i) * interval
+		frames[i] = timedFrame{nalus: au, dts: at, pts: at}
+	}
+	return frames, time.Duration(len(frames)) * interval
+}
+
+// plays the frames to the track at the times they're due, over and over if looping, each loop carrying on from the
+// one before. The frames' capture time is when they're due to be shown. If sei is given, the SEI NAL unit it returns
+// for each frame, numbered from the first played, is sent ahead of the frame's slices.
+func playFrames(ctx context.Context, track *queuedTrack, frames []timedFrame, duration time.Duration, loop bool,
+	sei func(frame uint64, captured time.Time) []byte) error {
+	if len(frames) == 0 {
+		return errors.New("clip has no frames")
+	}
+	encoder := &rtph264.Encoder{
+		PayloadType:       h264PayloadType,
+		PacketizationMode: h264PacketizationMode,
+	}
+	encoder.Init()
+
+	start := time.Now()
+	var played uint64
+	for offset := time.Duration(0); ; offset += duration {
+		for _, frame := range frames {
+			if err := sleepUntil(ctx, start.Add(offset+frame.dts)); err != nil {
+				return err
+			}
+
+			nalus := frame.nalus
+			if frame.read != nil {
+				var err error
+				if nalus, err = frame.read(); err != nil {
+					return err
+				}
+			}
+			captured := start.Add(offset + frame.pts)
+			if sei != nil {
+				if nalu := sei(played, captured); len(nalu) > 0 {
+					nalus = insertBeforeSlices(nalus, nalu)
+				}
+			}
+			played++
+
+			pkts, err := encoder.Encode(nalus, offset+frame.pts)
+			if err != nil {
+				return fmt.Errorf("unable to packetize frame: %w", err)
+			}
+			track.clock.update(captured, pkts[0].Timestamp)
+			for _, pkt := range pkts {
+				if !track.push(ctx, pkt) {
+					return ctx.Err()
+				}
+			}
+		}
+		if !loop {
+			return nil
+		}
+	}
+}
+
+// the access unit with the NAL unit inserted ahead of its first slice, after any parameter sets, as SEI must be
+func insertBeforeSlices(nalus [][]byte, nalu []byte) [][]byte {
+	at := len(nalus)
+	for i, n := range nalus {
+		if typ := h264.NALUType(n[0] & 0x1F); typ >= h264.NALUTypeNonIDR && typ <= h264.NALUTypeIDR {
+			at = i
+			break
+		}
+	}
+	inserted := make([][]byte, 0, len(nalus)+1)
+	inserted = append(inserted, nalus[:at]...)
+	inserted = append(inserted, nalu)
+	return append(inserted, nalus[at:]...)
+}
+
+func sleepUntil(ctx context.Context, t time.Time) error {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,190 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+	"github.com/pion/rtp"
+)
+
+// a sequence number further ahead or behind than this starts a new sequence, as in RFC 3550 appendix A.1
+const (
+	maxDropout  = 3000
+	maxMisorder = 100
+)
+
+// rewrites the packets of every source a stream relays into one continuous RTP stream, so RTSP readers don't see
+// the sequence numbers, timestamps or SSRC jump when the source changes, or when a source's own sequence restarts
+type rtpRewriter struct {
+	lock sync.Mutex
+
+	started   bool
+	switching bool
+
+	// the relay packets are currently accepted from
+	source    uint64
+	clockRate uint32
+	ssrc      uint32
+
+	seqOffset uint16
+	tsOffset  uint32
+
+	lastSeq uint16
+	lastTs  uint32
+	lastAt  time.Time
+
+	// the last packet accepted, as it arrived
+	inSSRC uint32
+	inSeq  uint16
+
+	// times the output was carried on from a new input sequence
+	rebases uint64
+}
+
+// only packets from the relay are accepted from now on; its first continues on from the last of the previous one
+func (rw *rtpRewriter) switchSource(source uint64) {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	rw.source = source
+	rw.switching = rw.started
+}
+
+// rewrites the packet in place, returning false for packets from a relay that has been switched away from
+func (rw *rtpRewriter) rewrite(pkt *rtp.Packet, clockRate uint32, source uint64) bool {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+
+	if source != rw.source {
+		return false
+	}
+	rw.rewriteLocked(pkt, clockRate)
+	return true
+}
+
+// rewrites the packets processors turned a packet from the source into. They're numbered on from one another in its
+// place, so the packets after them follow on; if there are none, the next packet takes its sequence number.
+func (rw *rtpRewriter) rewriteProcessed(seq uint16, ssrc uint32, pkts []*rtp.Packet, clockRate uint32, source uint64) bool {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+
+	if source != rw.source {
+		return false
+	}
+	if len(pkts) == 0 {
+		rw.seqOffset--
+		if rw.started && !rw.switching {
+			rw.inSeq = seq
+		}
+		return true
+	}
+	for i, pkt := range pkts {
+		pkt.SequenceNumber = seq
+		pkt.SSRC = ssrc
+		rw.rewriteLocked(pkt, clockRate)
+		if i < len(pkts)-1 {
+			rw.seqOffset++
+		}
+	}
+	return true
+}
+
+// must be called with the lock held
+func (rw *rtpRewriter) rewriteLocked(pkt *rtp.Packet, clockRate uint32) {
+	now := time.Now()
+	if !rw.started {
+		// the first source sets the SSRC every later one is written with
+		rw.started = true
+		rw.ssrc = pkt.SSRC
+		rw.clockRate = clockRate
+	} else if rw.switching || rw.restarted(pkt) {
+		rw.switching = false
+		rw.rebases++
+		rw.seqOffset = rw.lastSeq + 1 - pkt.SequenceNumber
+		// carry the timestamp on by the time that passed between the sources, so playback timing holds
+		elapsed := uint32(now.Sub(rw.lastAt).Seconds() * float64(rw.clockRate))
+		if elapsed == 0 {
+			elapsed = 1
+		}
+		rw.tsOffset = rw.lastTs + elapsed - pkt.Timestamp
+	}
+
+	rw.inSSRC = pkt.SSRC
+	rw.inSeq = pkt.SequenceNumber
+	// readers are served the one H264 format, whatever payload type the source used
+	pkt.PayloadType = h264PayloadType
+	pkt.SSRC = rw.ssrc
+	pkt.SequenceNumber += rw.seqOffset
+	pkt.Timestamp += rw.tsOffset
+
+	rw.lastSeq = pkt.SequenceNumber
+	if pkt.Timestamp != rw.lastTs {
+		rw.lastAt = now
+	}
+	rw.lastTs = pkt.Timestamp
+}
+
+// whether the source's packets no longer follow on from its last one, as when the subscription it's read from was
+// rebuilt; must be called with the lock held
+func (rw *rtpRewriter) restarted(pkt *rtp.Packet) bool {
+	if pkt.SSRC != rw.inSSRC {
+		return true
+	}
+	jump := pkt.SequenceNumber - rw.inSeq
+	return jump > maxDropout && jump < 1<<16-maxMisorder
+}
+
+func (rw *rtpRewriter) hasStarted() bool {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	return rw.started
+}
+
+func (rw *rtpRewriter) rebaseCount() uint64 {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	return rw.rebases
+}
+
+// when the last frame was written; zero before the first
+func (rw *rtpRewriter) lastWrite() time.Time {
+	rw.lock.Lock()
+	defer rw.lock.Unlock()
+	return rw.lastAt
+}
+
+// whether the H264 packet begins a keyframe, so a reader can start decoding from it
+func isKeyframeStart(payload []byte) bool {
+	if len(payload) == 0 {
+		return false
+	}
+
+	switch typ := h264.NALUType(payload[0] & 0x1F); typ {
+	case h264.NALUTypeSPS, h264.NALUTypeIDR:
+		return true
+
+	case h264.NALUTypeSTAPA:
+		// aggregation of several NALUs, each prefixed with its 16 bit size
+		for rest := payload[1:]; len(rest) > 2; {
+			size := int(rest[0])<<8 | int(rest[1])
+			rest = rest[2:]
+			if size == 0 || size > len(rest) {
+				return false
+			}
+			switch h264.NALUType(rest[0] & 0x1F) {
+			case h264.NALUTypeSPS, h264.NALUTypeIDR:
+				return true
+			}
+			rest = rest[size:]
+		}
+
+	case h264.NALUTypeFUA:
+		// fragment of a NALU; only the first fragment starts it
+		if len(payload) < 2 || payload[1]&0x80 == 0 {
+			return false
+		}
+		return h264.NALUType(payload[1]&0x1F) == h264.NALUTypeIDR
+	}
+
+	return false
+}
@@ -0,0 +1,171 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+)
+
+func testPacket(ssrc uint32, seq uint16, ts uint32) *rtp.Packet {
+	return &rtp.Packet{Header: rtp.Header{PayloadType: 96, SSRC: ssrc, SequenceNumber: seq, Timestamp: ts}}
+}
+
+// a packet as written, so a test can check what the rewriter made of it
+type written struct {
+	seq  uint16
+	ts   uint32
+	ssrc uint32
+}
+
+func TestRewriterSequences(t *testing.T) {
+	type in struct {
+		source uint64
+		ssrc   uint32
+		seq    uint16
+		ts     uint32
+	}
+	tests := []struct {
+		name    string
+		packets []in
+		// relay switched to before the packet at the same index is written
+		switches map[int]uint64
+		want     []uint16
+		rebases  uint64
+	}{
+		{
+			name:    "one source passes its sequence through",
+			packets: []in{{1, 10, 100, 0}, {1, 10, 101, 3000}, {1, 10, 102, 6000}},
+			want:    []uint16{100, 101, 102},
+		},
+		{
+			name:    "the source's sequence wraps",
+			packets: []in{{1, 10, 65534, 0}, {1, 10, 65535, 3000}, {1, 10, 0, 6000}, {1, 10, 1, 9000}},
+			want:    []uint16{65534, 65535, 0, 1},
+		},
+		{
+			name:    "a reordered packet isn't a new sequence",
+			packets: []in{{1, 10, 200, 0}, {1, 10, 202, 6000}, {1, 10, 201, 3000}, {1, 10, 203, 9000}},
+			want:    []uint16{200, 202, 201, 203},
+		},
+		{
+			name:     "a switch carries on from the last packet",
+			packets:  []in{{1, 10, 100, 0}, {1, 10, 101, 3000}, {2, 20, 40000, 123}, {2, 20, 40001, 3123}},
+			switches: map[int]uint64{2: 2},
+			want:     []uint16{100, 101, 102, 103},
+			rebases:  1,
+		},
+		{
+			name:     "a switch carries on across the wrap",
+			packets:  []in{{1, 10, 65535, 0}, {2, 20, 500, 0}, {2, 20, 501, 3000}},
+			switches: map[int]uint64{1: 2},
+			want:     []uint16{65535, 0, 1},
+			rebases:  1,
+		},
+		{
+			name:    "a restarted source carries on",
+			packets: []in{{1, 10, 100, 0}, {1, 10, 101, 3000}, {1, 10, 30000, 0}, {1, 10, 30001, 3000}},
+			want:    []uint16{100, 101, 102, 103},
+			rebases: 1,
+		},
+		{
+			name:    "a new SSRC carries on",
+			packets: []in{{1, 10, 100, 0}, {1, 11, 101, 3000}, {1, 11, 102, 6000}},
+			want:    []uint16{100, 101, 102},
+			rebases: 1,
+		},
+		{
+			name:     "the relay switched away from is dropped",
+			packets:  []in{{1, 10, 100, 0}, {1, 10, 101, 3000}, {2, 20, 7, 0}},
+			switches: map[int]uint64{1: 2},
+			want:     []uint16{100, 101},
+			rebases:  1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rw := &rtpRewriter{}
+			rw.switchSource(1)
+
+			var out []written
+			for i, p := range test.packets {
+				if source, ok := test.switches[i]; ok {
+					rw.switchSource(source)
+				}
+				pkt := testPacket(p.ssrc, p.seq, p.ts)
+				if rw.rewrite(pkt, h264ClockRate, p.source) {
+					out = append(out, written{pkt.SequenceNumber, pkt.Timestamp, pkt.SSRC})
+				}
+			}
+
+			if len(out) != len(test.want) {
+				t.Fatalf("wrote %d packets, want %d", len(out), len(test.want))
+			}
+			for i, w := range out {
+				if w.seq != test.want[i] {
+					t.Errorf("packet %d: got sequence number %d, want %d", i, w.seq, test.want[i])
+				}
+				// every source is written as the first one
+				if w.ssrc != 10 {
+					t.Errorf("packet %d: got SSRC %d, want 10", i, w.ssrc)
+				}
+			}
+			if rw.rebaseCount() != test.rebases {
+				t.Errorf("got %d rebases, want %d", rw.rebaseCount(), test.rebases)
+			}
+		})
+	}
+}
+
+func TestRewriterTimestampsAcrossSwitch(t *testing.T) {
+	rw := &rtpRewriter{}
+	rw.switchSource(1)
+
+	first := testPacket(10, 100, 4294967000)
+	rw.rewrite(first, h264ClockRate, 1)
+
+	rw.switchSource(2)
+	second := testPacket(20, 7, 1000)
+	rw.rewrite(second, h264ClockRate, 2)
+	// the new source's timestamps move on from the last one written, wrapping with it
+	if elapsed := second.Timestamp - first.Timestamp; elapsed == 0 || elapsed > h264ClockRate {
+		t.Fatalf("timestamp moved %d across the switch, want a little over 0", elapsed)
+	}
+
+	third := testPacket(20, 8, 4000)
+	rw.rewrite(third, h264ClockRate, 2)
+	if third.Timestamp-second.Timestamp != 3000 {
+		t.Fatalf("timestamp moved %d after the switch, want the source's own 3000", third.Timestamp-second.Timestamp)
+	}
+	if second.PayloadType != h264PayloadType || third.PayloadType != h264PayloadType {
+		t.Fatalf("got payload types %d, %d, want %d", second.PayloadType, third.PayloadType, h264PayloadType)
+	}
+}
+
+func TestIsKeyframeStart(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		want    bool
+	}{
+		{"empty", nil, false},
+		{"IDR", []byte{0x65, 0x88}, true},
+		{"SPS", []byte{0x67, 0x42}, true},
+		{"PPS", []byte{0x68, 0xce}, false},
+		{"non-IDR slice", []byte{0x41, 0x9a}, false},
+		{"FU-A start of an IDR", []byte{0x7c, 0x85, 0x88}, true},
+		{"FU-A middle of an IDR", []byte{0x7c, 0x05, 0x88}, false},
+		{"FU-A end of an IDR", []byte{0x7c, 0x45, 0x88}, false},
+		{"FU-A start of a non-IDR slice", []byte{0x7c, 0x81, 0x9a}, false},
+		{"FU-A without its header", []byte{0x7c}, false},
+		{"STAP-A of SPS and PPS", []byte{0x78, 0x00, 0x02, 0x67, 0x42, 0x00, 0x02, 0x68, 0xce}, true},
+		{"STAP-A with an IDR after an SEI", []byte{0x78, 0x00, 0x02, 0x06, 0x05, 0x00, 0x02, 0x65, 0x88}, true},
+		{"STAP-A of SEI and a non-IDR slice", []byte{0x78, 0x00, 0x02, 0x06, 0x05, 0x00, 0x02, 0x41, 0x9a}, false},
+		{"STAP-A with a size past its end", []byte{0x78, 0x00, 0x09, 0x67, 0x42}, false},
+		{"STAP-A with a zero size", []byte{0x78, 0x00, 0x00, 0x00, 0x02, 0x67, 0x42}, false},
+	}
+	for _, test := range tests {
+		if got := isKeyframeStart(test.payload); got != test.want {
+			t.Errorf("%s: got %t, want %t", test.name, got, test.want)
+		}
+	}
+}
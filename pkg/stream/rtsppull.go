@@ -0,0 +1,181 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/v2"
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+	"github.com/aler9/gortsplib/v2/pkg/media"
+	"github.com/aler9/gortsplib/v2/pkg/url"
+)
+
+// how long to wait before connecting to an RTSP source again once it has disconnected
+const rtspReconnectInterval = 2 * time.Second
+
+// pulls the media of an RTSP server, connecting again whenever the server disconnects until stopped. Shared by
+// sources, which relay the video to sinks, and ingress sessions, which publish it into LiveKit.
+type rtspPull struct {
+	url *url.URL
+	// the URL without credentials, for logs
+	name string
+	ctx  context.Context
+
+	// sets up the described media of each connection and plays it
+	play func(client *gortsplib.Client, medias media.Medias, baseURL *url.URL) error
+	// called as each connection ends, including when stopped
+	onEnd func(err error)
+
+	lock   sync.Mutex
+	client *gortsplib.Client
+}
+
+func newRTSPPull(ctx context.Context, u *url.URL) *rtspPull {
+	return &rtspPull{
+		url:  u,
+		name: RedactSource(u.String()),
+		ctx:  ctx,
+	}
+}
+
+// connects and plays, then keeps the media playing in the background until the context ends
+func (rp *rtspPull) start() error {
+	client, err := rp.connect()
+	if err != nil {
+		return err
+	}
+	go rp.run(client)
+	return nil
+}
+
+// closes the current connection; the context must already be done so it isn't made again
+func (rp *rtspPull) close() {
+	rp.lock.Lock()
+	client := rp.client
+	rp.lock.Unlock()
+	if client != nil {
+		client.Close()
+	}
+}
+
+// waits for the connection to end, then connects again, until the context ends
+func (rp *rtspPull) run(client *gortsplib.Client) {
+	for {
+		err := client.Wait()
+		rp.onEnd(err)
+		if rp.ctx.Err() != nil {
+			return
+		}
+
+		for {
+			if sleepUntil(rp.ctx, time.Now().Add(rtspReconnectInterval)) != nil {
+				return
+			}
+			client, err = rp.connect()
+			if err == nil {
+				break
+			}
+			fmt.Printf("unable to reconnect to RTSP source %s: %s\n", rp.name, err)
+		}
+	}
+}
+
+// connects to the server, describes its media and has them played
+func (rp *rtspPull) connect() (*gortsplib.Client, error) {
+	client := &gortsplib.Client{}
+	err := client.Start(rp.url.Scheme, rp.url.Host)
+	if err != nil {
+		return nil, err
+	}
+	rp.lock.Lock()
+	rp.client = client
+	rp.lock.Unlock()
+	// stopped while it was connecting
+	if rp.ctx.Err() != nil {
+		client.Close()
+		return nil, rp.ctx.Err()
+	}
+
+	medias, baseURL, _, err := client.Describe(rp.url)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	if err := rp.play(client, medias, baseURL); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// sets up each of the media that isn't nil
+func setupMedias(client *gortsplib.Client, baseURL *url.URL, medias ...*media.Media) error {
+	for _, medi := range medias {
+		if medi == nil {
+			continue
+		}
+		if _, err := client.Setup(medi, baseURL, 0, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the latest SPS and PPS of a video. Servers often only describe them in the SDP, which readers of the stream won't
+// see, so they're sent ahead of any IDR not already preceded by them.
+type parameterSets struct {
+	sps []byte
+	pps []byte
+}
+
+// keeps any parameter sets among the NALUs, returning whether there was an SPS
+func (ps *parameterSets) update(nalus [][]byte) bool {
+	hasSPS := false
+	for _, nalu := range nalus {
+		if len(nalu) == 0 {
+			continue
+		}
+		switch h264.NALUType(nalu[0] & 0x1F) {
+		case h264.NALUTypeSPS:
+			ps.sps, hasSPS = nalu, true
+		case h264.NALUTypePPS:
+			ps.pps = nalu
+		}
+	}
+	return hasSPS
+}
+
+// the SPS and PPS to send ahead of an IDR, or nil until both are known
+func (ps *parameterSets) nalus() [][]byte {
+	if len(ps.sps) == 0 || len(ps.pps) == 0 {
+		return nil
+	}
+	return [][]byte{ps.sps, ps.pps}
+}
+
+// the whole NALUs an RTP packet carries, on its own or aggregated; none for fragments
+func packetNALUs(payload []byte) [][]byte {
+	if len(payload) == 0 {
+		return nil
+	}
+	switch h264.NALUType(payload[0] & 0x1F) {
+	case h264.NALUTypeFUA:
+		return nil
+
+	case h264.NALUTypeSTAPA:
+		var nalus [][]byte
+		for rest := payload[1:]; len(rest) > 2; {
+			size := int(rest[0])<<8 | int(rest[1])
+			rest = rest[2:]
+			if size == 0 || size > len(rest) {
+				break
+			}
+			nalus = append(nalus, rest[:size])
+			rest = rest[size:]
+		}
+		return nalus
+	}
+	return [][]byte{payload}
+}
@@ -0,0 +1,36 @@
+package stream
+
+import (
+	"time"
+
+	"github.com/aler9/gortsplib/v2"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+// how many packets are buffered for RTSP readers; gortsplib buffers for each reader on top of this
+const rtspSinkBuffer = 1024
+
+// serves a stream's packets to its RTSP readers
+type rtspSink struct {
+	stream *gortsplib.ServerStream
+}
+
+func (rs *rtspSink) WriteRTP(pkt *rtp.Packet, captureTime time.Time) error {
+	for _, medi := range rs.stream.Medias() {
+		rs.stream.WritePacketRTP(medi, pkt)
+	}
+	return nil
+}
+
+func (rs *rtspSink) WriteRTCP(pkt rtcp.Packet) error {
+	for _, medi := range rs.stream.Medias() {
+		rs.stream.WritePacketRTCP(medi, pkt)
+	}
+	return nil
+}
+
+// closing the stream closes the session of every reader
+func (rs *rtspSink) Close() error {
+	return rs.stream.Close()
+}
@@ -0,0 +1,130 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aler9/gortsplib/v2"
+	"github.com/aler9/gortsplib/v2/pkg/format"
+	"github.com/aler9/gortsplib/v2/pkg/media"
+	"github.com/aler9/gortsplib/v2/pkg/url"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+)
+
+// pulls the H264 video of an RTSP server, such as a camera: rtsp://camera/stream. Once connected, the source
+// reconnects whenever the server disconnects, until the session stops.
+type rtspSource struct {
+	pull   *rtspPull
+	cancel context.CancelFunc
+
+	// the track of the current connection, ended as it drops
+	track *rtspTrack
+}
+
+func newRTSPSource(session *skyegresspb.Session) (Source, error) {
+	u, err := url.Parse(session.Source)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &rtspSource{
+		pull:   newRTSPPull(ctx, u),
+		cancel: cancel,
+	}, nil
+}
+
+func (rs *rtspSource) Start(stream SourceStream) error {
+	rs.pull.play = func(client *gortsplib.Client, medias media.Medias, baseURL *url.URL) error {
+		return rs.play(stream, client, medias, baseURL)
+	}
+	rs.pull.onEnd = func(err error) {
+		rs.track.end(err)
+		if rs.pull.ctx.Err() == nil {
+			stream.SourceLost(rs, fmt.Sprintf("RTSP source disconnected: %s", err))
+		}
+	}
+	return rs.pull.start()
+}
+
+func (rs *rtspSource) Stop() {
+	rs.cancel()
+	rs.pull.close()
+}
+
+// plays the server's H264 media to a new track
+func (rs *rtspSource) play(stream SourceStream, client *gortsplib.Client, medias media.Medias, baseURL *url.URL) error {
+	var forma *format.H264
+	medi := medias.FindFormat(&forma)
+	if medi == nil {
+		return errors.New("RTSP source has no H264 video")
+	}
+	if err := setupMedias(client, baseURL, medi); err != nil {
+		return err
+	}
+
+	track := &rtspTrack{
+		queuedTrack: newQueuedTrack(rs.pull.name),
+		ctx:         rs.pull.ctx,
+		params:      parameterSets{sps: forma.SPS, pps: forma.PPS},
+	}
+	client.OnPacketRTP(medi, forma, track.onPacket)
+	client.OnPacketRTCP(medi, func(pkt rtcp.Packet) {
+		stream.ReceivedRTCP(rs, pkt)
+		if sr, ok := pkt.(*rtcp.SenderReport); ok {
+			track.clock.onSenderReport(sr)
+		}
+	})
+	stream.AddTrack(rs, track, skyegresspb.SessionSource_SESSION_SOURCE_PRIMARY)
+
+	if _, err := client.Play(nil); err != nil {
+		track.end(err)
+		return err
+	}
+	rs.track = track
+	return nil
+}
+
+// the video of one connection to an RTSP source, with the parameter sets sent ahead of any IDR not already preceded
+// by them
+type rtspTrack struct {
+	*queuedTrack
+	ctx context.Context
+
+	params parameterSets
+
+	// packets inserted so far, which every later packet is numbered on by
+	seqOffset uint16
+	// the timestamp of the last frame that carried parameter sets
+	paramsTs  uint32
+	hasParams bool
+}
+
+func (rt *rtspTrack) onPacket(pkt *rtp.Packet) {
+	switch {
+	case rt.params.update(packetNALUs(pkt.Payload)):
+		rt.paramsTs, rt.hasParams = pkt.Timestamp, true
+
+	case isKeyframeStart(pkt.Payload) && rt.params.nalus() != nil &&
+		(!rt.hasParams || rt.paramsTs != pkt.Timestamp):
+		for _, nalu := range rt.params.nalus() {
+			inserted := &rtp.Packet{
+				Header: rtp.Header{
+					Version:        2,
+					PayloadType:    pkt.PayloadType,
+					SequenceNumber: pkt.SequenceNumber + rt.seqOffset,
+					Timestamp:      pkt.Timestamp,
+					SSRC:           pkt.SSRC,
+				},
+				Payload: nalu,
+			}
+			rt.seqOffset++
+			rt.queuedTrack.push(rt.ctx, inserted)
+		}
+	}
+
+	pkt.SequenceNumber += rt.seqOffset
+	rt.queuedTrack.push(rt.ctx, pkt)
+}
@@ -0,0 +1,171 @@
+package stream
+
+import (
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+	"github.com/pion/rtp"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/nalu"
+	"google.golang.org/protobuf/proto"
+)
+
+// identifies the FrameInfo carried in SEI NAL units: 4efa9cf4-a8b1-495f-84ea-9cb1be7feb8b
+var frameInfoUUID = [16]byte{0x4e, 0xfa, 0x9c, 0xf4, 0xa8, 0xb1, 0x49, 0x5f, 0x84, 0xea, 0x9c, 0xb1, 0xbe, 0x7f, 0xeb, 0x8b}
+
+// inserted NAL units larger than this are fragmented into FU-A packets
+const maxInsertedPayload = 1200
+
+// processor inserting an SEI NAL unit with the frame's capture time and the session's details into frames. Each SEI
+// is sent in packets of its own ahead of the frame's first slice, after any access unit delimiter and parameter sets
+// as H264 requires, so the source's FU-A fragments are never split.
+type seiInjector struct {
+	mode skyegresspb.SeiMode
+	sid  string
+
+	frames     uint64
+	started    bool
+	lastMarker bool
+	lastTs     uint32
+	// whether the current frame has had its SEI, or been found not to get one
+	done bool
+	// whether any packet of the current frame so far starts a keyframe
+	keyframe bool
+}
+
+func newSEIInjector(sid string, mode skyegresspb.SeiMode) *seiInjector {
+	return &seiInjector{
+		mode: mode,
+		sid:  sid,
+	}
+}
+
+// sends the packet preceded by an SEI, if it starts a frame that gets one
+func (si *seiInjector) Process(pkt *rtp.Packet, info *PacketInfo) []*rtp.Packet {
+	// a frame starts after the last one's marker, or with a new timestamp when the last was cut short by a switch
+	frameStart := !si.started || si.lastMarker || pkt.Timestamp != si.lastTs
+	si.started = true
+	si.lastMarker = pkt.Marker
+	si.lastTs = pkt.Timestamp
+	if frameStart {
+		si.frames++
+		si.done = false
+		si.keyframe = false
+	}
+	// a keyframe may start with a delimiter in a packet of its own, so it's only known by the time its first slice is
+	si.keyframe = si.keyframe || info.Keyframe
+	if si.done {
+		return []*rtp.Packet{pkt}
+	}
+	nalus := packetNALUs(pkt.Payload)
+	at := 0
+	for at < len(nalus) && precedesSEI(nalus[at]) {
+		at++
+	}
+	if len(nalus) > 0 && at == len(nalus) {
+		return []*rtp.Packet{pkt}
+	}
+	si.done = true
+	sei := si.frameSEI(info)
+	if len(sei) == 0 {
+		return []*rtp.Packet{pkt}
+	}
+	if at == 0 {
+		return append(packetizeInserted(sei, pkt), pkt)
+	}
+
+	// an aggregation packet starting with parameter sets is split around the SEI
+	before := &rtp.Packet{Header: pkt.Header, Payload: aggregate(nalus[:at])}
+	before.Marker = false
+	after := &rtp.Packet{Header: pkt.Header, Payload: aggregate(nalus[at:])}
+	return append(append([]*rtp.Packet{before}, packetizeInserted(sei, pkt)...), after)
+}
+
+// the SEI for the current frame, whose first slice is in the packet described, if it gets one
+func (si *seiInjector) frameSEI(info *PacketInfo) []byte {
+	frame := si.frames - 1
+
+	switch si.mode {
+	case skyegresspb.SeiMode_SEI_MODE_ALL_FRAMES:
+	case skyegresspb.SeiMode_SEI_MODE_KEYFRAMES:
+		if !si.keyframe {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	frameInfo, err := proto.Marshal(&skyegresspb.FrameInfo{
+		CaptureTimeNs:     info.CaptureTime.UnixNano(),
+		Sid:               si.sid,
+		PublisherIdentity: info.PublisherIdentity,
+		PublisherMetadata: info.PublisherMetadata,
+		FrameNumber:       frame,
+	})
+	if err != nil {
+		return nil
+	}
+	return nalu.UserDataSEI(frameInfoUUID, frameInfo)
+}
+
+// whether the NAL unit goes ahead of SEI in an access unit: a delimiter or parameter set
+func precedesSEI(nalu []byte) bool {
+	switch h264.NALUType(nalu[0] & 0x1F) {
+	case h264.NALUTypeAccessUnitDelimiter, h264.NALUTypeSPS, h264.NALUTypePPS:
+		return true
+	}
+	return false
+}
+
+// the payload of a packet carrying the NAL units: the NAL unit itself if there's one, otherwise a STAP-A
+func aggregate(nalus [][]byte) []byte {
+	if len(nalus) == 1 {
+		return nalus[0]
+	}
+	// the aggregate takes the highest nal_ref_idc of its NAL units
+	var nri byte
+	for _, n := range nalus {
+		if n[0]&0x60 > nri {
+			nri = n[0] & 0x60
+		}
+	}
+	payload := []byte{nri | byte(h264.NALUTypeSTAPA)}
+	for _, n := range nalus {
+		payload = append(payload, byte(len(n)>>8), byte(len(n)))
+		payload = append(payload, n...)
+	}
+	return payload
+}
+
+// packets carrying the NAL unit ahead of the packet: a single NAL unit packet if it fits, otherwise FU-A fragments
+func packetizeInserted(nalu []byte, next *rtp.Packet) []*rtp.Packet {
+	header := rtp.Header{
+		Version:        2,
+		PayloadType:    next.PayloadType,
+		SequenceNumber: next.SequenceNumber,
+		Timestamp:      next.Timestamp,
+		SSRC:           next.SSRC,
+	}
+	if len(nalu) <= maxInsertedPayload {
+		return []*rtp.Packet{{Header: header, Payload: nalu}}
+	}
+
+	pkts := []*rtp.Packet{}
+	indicator := nalu[0]&0xE0 | byte(h264.NALUTypeFUA)
+	typ := nalu[0] & 0x1F
+	for rest := nalu[1:]; len(rest) > 0; {
+		size := len(rest)
+		if size > maxInsertedPayload-2 {
+			size = maxInsertedPayload - 2
+		}
+		fuHeader := typ
+		if len(pkts) == 0 {
+			fuHeader |= 0x80 // start
+		}
+		if size == len(rest) {
+			fuHeader |= 0x40 // end
+		}
+		payload := append([]byte{indicator, fuHeader}, rest[:size]...)
+		pkts = append(pkts, &rtp.Packet{Header: header, Payload: payload})
+		rest = rest[size:]
+	}
+	return pkts
+}
@@ -0,0 +1,258 @@
+package stream
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+	"github.com/pion/rtp"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/nalu"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	testAUD    = []byte{0x09, 0xf0}
+	testSPS    = []byte{0x67, 0x42, 0xc0, 0x1f}
+	testPPS    = []byte{0x68, 0xce, 0x3c, 0x80}
+	testIDR    = []byte{0x65, 0x88, 0x84, 0x00}
+	testNonIDR = []byte{0x41, 0x9a, 0x02, 0x28}
+)
+
+// the packets of one frame as a source sends them, the last marked
+func framePackets(ts uint32, payloads ...[]byte) []*rtp.Packet {
+	pkts := make([]*rtp.Packet, len(payloads))
+	for i, payload := range payloads {
+		pkts[i] = &rtp.Packet{Header: rtp.Header{Version: 2, PayloadType: 96, SSRC: 10, Timestamp: ts}, Payload: payload}
+	}
+	pkts[len(pkts)-1].Marker = true
+	return pkts
+}
+
+func stapA(nalus ...[]byte) []byte {
+	return aggregate(nalus)
+}
+
+// the NAL unit types carried by each packet, STAP-A units listed within brackets and FU-A fragments by the type
+// they carry
+func describePackets(pkts []*rtp.Packet) string {
+	names := make([]string, len(pkts))
+	for i, pkt := range pkts {
+		switch typ := h264.NALUType(pkt.Payload[0] & 0x1F); typ {
+		case h264.NALUTypeSTAPA:
+			types := []string{}
+			for _, n := range packetNALUs(pkt.Payload) {
+				types = append(types, fmt.Sprint(int(n[0]&0x1F)))
+			}
+			names[i] = "[" + strings.Join(types, " ") + "]"
+		case h264.NALUTypeFUA:
+			names[i] = fmt.Sprintf("fu%d", pkt.Payload[1]&0x1F)
+		default:
+			names[i] = fmt.Sprint(int(typ))
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+// the FrameInfo carried by an SEI NAL unit injected by the server
+func decodeFrameInfo(t *testing.T, sei []byte) *skyegresspb.FrameInfo {
+	t.Helper()
+	rbsp := h264.EmulationPreventionRemove(sei[1:])
+	if rbsp[0] != 5 {
+		t.Fatalf("got SEI payload type %d, want user data unregistered", rbsp[0])
+	}
+	size := 0
+	rest := rbsp[1:]
+	for rest[0] == 0xff {
+		size += 255
+		rest = rest[1:]
+	}
+	size += int(rest[0])
+	rest = rest[1:]
+	if len(rest) != size+1 || rest[size] != 0x80 {
+		t.Fatalf("SEI payload of %d bytes doesn't fit the %d left", size, len(rest))
+	}
+	if !bytes.Equal(rest[:16], frameInfoUUID[:]) {
+		t.Fatalf("got UUID %x", rest[:16])
+	}
+	info := &skyegresspb.FrameInfo{}
+	if err := proto.Unmarshal(rest[16:size], info); err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func inject(si *seiInjector, frames [][]*rtp.Packet, info *PacketInfo) []*rtp.Packet {
+	out := []*rtp.Packet{}
+	for _, frame := range frames {
+		for _, pkt := range frame {
+			frameInfo := *info
+			frameInfo.Keyframe = isKeyframeStart(pkt.Payload)
+			out = append(out, si.Process(pkt, &frameInfo)...)
+		}
+	}
+	return out
+}
+
+func TestSEIInjectorPlacement(t *testing.T) {
+	fuaIDRStart := []byte{0x7c, 0x85, 0x88, 0x84}
+	fuaIDREnd := []byte{0x7c, 0x45, 0x00, 0x01}
+	tests := []struct {
+		name   string
+		mode   skyegresspb.SeiMode
+		frames [][]*rtp.Packet
+		want   string
+	}{
+		{
+			name:   "after the delimiter and parameter sets",
+			mode:   skyegresspb.SeiMode_SEI_MODE_ALL_FRAMES,
+			frames: [][]*rtp.Packet{framePackets(0, testAUD, testSPS, testPPS, testIDR)},
+			want:   "9 7 8 6 5",
+		},
+		{
+			name:   "a STAP-A of parameter sets and the slice is split around it",
+			mode:   skyegresspb.SeiMode_SEI_MODE_ALL_FRAMES,
+			frames: [][]*rtp.Packet{framePackets(0, stapA(testAUD, testSPS, testPPS, testIDR))},
+			want:   "[9 7 8] 6 5",
+		},
+		{
+			name:   "a STAP-A of parameter sets alone is left whole",
+			mode:   skyegresspb.SeiMode_SEI_MODE_ALL_FRAMES,
+			frames: [][]*rtp.Packet{framePackets(0, stapA(testSPS, testPPS), testIDR)},
+			want:   "[7 8] 6 5",
+		},
+		{
+			name:   "a STAP-A starting with a slice goes after it",
+			mode:   skyegresspb.SeiMode_SEI_MODE_ALL_FRAMES,
+			frames: [][]*rtp.Packet{framePackets(0, stapA(testNonIDR, testNonIDR))},
+			want:   "6 [1 1]",
+		},
+		{
+			name:   "ahead of the first fragment only",
+			mode:   skyegresspb.SeiMode_SEI_MODE_ALL_FRAMES,
+			frames: [][]*rtp.Packet{framePackets(0, testSPS, testPPS, fuaIDRStart, fuaIDREnd)},
+			want:   "7 8 6 fu5 fu5",
+		},
+		{
+			name: "every frame",
+			mode: skyegresspb.SeiMode_SEI_MODE_ALL_FRAMES,
+			frames: [][]*rtp.Packet{
+				framePackets(0, stapA(testSPS, testPPS), testIDR),
+				framePackets(3000, testNonIDR),
+			},
+			want: "[7 8] 6 5 6 1",
+		},
+		{
+			name: "keyframes only",
+			mode: skyegresspb.SeiMode_SEI_MODE_KEYFRAMES,
+			frames: [][]*rtp.Packet{
+				framePackets(0, testNonIDR),
+				framePackets(3000, stapA(testSPS, testPPS), testIDR),
+				framePackets(6000, testNonIDR),
+			},
+			want: "1 [7 8] 6 5 1",
+		},
+		{
+			name:   "off",
+			mode:   skyegresspb.SeiMode_SEI_MODE_OFF,
+			frames: [][]*rtp.Packet{framePackets(0, testSPS, testPPS, testIDR)},
+			want:   "7 8 5",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			si := newSEIInjector("studio/main", test.mode)
+			out := inject(si, test.frames, &PacketInfo{CaptureTime: time.Unix(1700000000, 0)})
+			if got := describePackets(out); got != test.want {
+				t.Fatalf("got %s, want %s", got, test.want)
+			}
+
+			// each packet of a frame keeps the frame's timestamp, and only the frame's last packet is marked
+			for i, pkt := range out {
+				last := i == len(out)-1 || out[i+1].Timestamp != pkt.Timestamp
+				if pkt.Marker != last {
+					t.Errorf("packet %d: got marker %t, want %t", i, pkt.Marker, last)
+				}
+			}
+		})
+	}
+}
+
+func TestSEIInjectorPayload(t *testing.T) {
+	si := newSEIInjector("studio/main", skyegresspb.SeiMode_SEI_MODE_KEYFRAMES)
+	// the metadata holds what would read as start codes, so the SEI needs emulation prevention
+	metadata := "\x00\x00\x01\x00\x00\x03"
+	captureTime := time.Unix(1700000000, 0)
+	info := &PacketInfo{PublisherIdentity: "camera-a", PublisherMetadata: metadata, CaptureTime: captureTime}
+	out := inject(si, [][]*rtp.Packet{
+		framePackets(0, testAUD, testSPS, testPPS, testIDR),
+		framePackets(3000, testNonIDR),
+		framePackets(6000, stapA(testSPS, testPPS), testIDR),
+	}, info)
+
+	seis := [][]byte{}
+	for _, pkt := range out {
+		if h264.NALUType(pkt.Payload[0]&0x1F) == h264.NALUTypeSEI {
+			seis = append(seis, pkt.Payload)
+		}
+	}
+	if len(seis) != 2 {
+		t.Fatalf("got %d SEI, want one for each keyframe", len(seis))
+	}
+	for i, frame := range []uint64{0, 2} {
+		want := &skyegresspb.FrameInfo{
+			CaptureTimeNs:     captureTime.UnixNano(),
+			Sid:               "studio/main",
+			PublisherIdentity: "camera-a",
+			PublisherMetadata: metadata,
+			FrameNumber:       frame,
+		}
+		if got := decodeFrameInfo(t, seis[i]); !proto.Equal(got, want) {
+			t.Errorf("SEI %d: got %v, want %v", i, got, want)
+		}
+
+		// the payload is escaped exactly as a user data SEI is
+		data, err := proto.Marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rbsp := append([]byte{5, byte(16 + len(data))}, frameInfoUUID[:]...)
+		rbsp = append(append(rbsp, data...), 0x80)
+		wantSEI := append([]byte{0x06}, nalu.EmulationPrevention(rbsp)...)
+		if len(wantSEI) != 1+len(rbsp)+2 {
+			t.Fatalf("frame info %x doesn't need escaping twice", data)
+		}
+		if !bytes.Equal(seis[i], wantSEI) {
+			t.Errorf("SEI %d: got %x, want %x", i, seis[i], wantSEI)
+		}
+	}
+}
+
+func TestSEIInjectorFragmentsLargeSEI(t *testing.T) {
+	si := newSEIInjector("studio/main", skyegresspb.SeiMode_SEI_MODE_ALL_FRAMES)
+	metadata := strings.Repeat("m", 3*maxInsertedPayload)
+	out := inject(si, [][]*rtp.Packet{framePackets(0, testSPS, testPPS, testIDR)},
+		&PacketInfo{PublisherMetadata: metadata, CaptureTime: time.Unix(1700000000, 0)})
+
+	if got := describePackets(out); got != "7 8 fu6 fu6 fu6 fu6 5" {
+		t.Fatalf("got %s", got)
+	}
+	// reassemble the fragments
+	sei := []byte{out[2].Payload[0]&0xE0 | out[2].Payload[1]&0x1F}
+	for i, pkt := range out[2:6] {
+		if len(pkt.Payload) > maxInsertedPayload {
+			t.Errorf("fragment %d is %d bytes", i, len(pkt.Payload))
+		}
+		start, end := pkt.Payload[1]&0x80 != 0, pkt.Payload[1]&0x40 != 0
+		if start != (i == 0) || end != (i == 3) {
+			t.Errorf("fragment %d: got start %t, end %t", i, start, end)
+		}
+		sei = append(sei, pkt.Payload[2:]...)
+	}
+	if got := decodeFrameInfo(t, sei); got.PublisherMetadata != metadata {
+		t.Errorf("got %d bytes of metadata, want %d", len(got.PublisherMetadata), len(metadata))
+	}
+}
@@ -0,0 +1,374 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+	"github.com/aler9/gortsplib/v2/pkg/formatdecenc/rtph264"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+)
+
+// an output of a session. A sink takes RTP by implementing RTPSink, whole frames by implementing FrameSink, or both.
+// Each sink is written from a goroutine of its own through a buffer, so one that's slow to write holds up neither
+// the relay nor the session's other sinks.
+type Sink interface {
+	// called once nothing more will be written, after everything buffered has been
+	Close() error
+}
+
+// a sink for the session's packets as readers are sent them: processed, with continuous sequence numbers and
+// timestamps. Packets are shared between sinks, so must not be modified.
+type RTPSink interface {
+	Sink
+	WriteRTP(pkt *rtp.Packet, captureTime time.Time) error
+	// sender reports mapping the packets' timestamps to their capture time
+	WriteRTCP(pkt rtcp.Packet) error
+}
+
+// a sink for the session's frames, depacketized from the packets RTP sinks are sent
+type FrameSink interface {
+	Sink
+	WriteFrame(frame *Frame) error
+}
+
+// an H264 access unit
+type Frame struct {
+	NALUs [][]byte
+	// RTP timestamp of the frame as sent to RTP sinks
+	Timestamp uint32
+	// wall-clock time the frame was captured
+	CaptureTime time.Time
+	Keyframe    bool
+}
+
+// what happens to what a sink is sent while its buffer is full
+type SinkPolicy int
+
+const (
+	// drop it, and everything after until the next keyframe, so the sink resumes with a decodable stream
+	SinkDropUntilKeyframe SinkPolicy = iota
+	// drop it, resuming as soon as there's room
+	SinkDropNewest
+	// close the sink; for sinks that must be sent everything or nothing
+	SinkCloseOnOverflow
+)
+
+type SinkOptions struct {
+	// packets, frames and RTCP buffered for the sink; defaults to 512
+	BufferSize int
+	Policy     SinkPolicy
+}
+
+const (
+	defaultSinkBuffer = 512
+	// how long a stopping stream waits for its sinks to write what's buffered before abandoning them
+	sinkCloseTimeout = 5 * time.Second
+)
+
+// builds a sink for a session; called once for every session it's configured for
+type SinkFactory func(session *skyegresspb.Session) (Sink, SinkOptions, error)
+
+var sinkFactories = newRegistry(map[string]SinkFactory{})
+
+// makes a sink available to sessions listing the name among their sinks
+func RegisterSink(name string, factory SinkFactory) {
+	sinkFactories.register(name, factory)
+}
+
+// adds a sink to the stream; everything written from now on is sent to it as well
+func (ss *skyEgressStream) AddSink(name string, sink Sink, opts SinkOptions) error {
+	return ss.sinks.add(name, sink, opts)
+}
+
+// adds the sinks the session names, closing them all again if any can't be created
+func (ss *skyEgressStream) addSessionSinks() error {
+	for _, name := range ss.session.Sinks {
+		factory, ok := sinkFactories.get(name)
+		if !ok {
+			ss.sinks.close()
+			return fmt.Errorf("unknown sink %s", name)
+		}
+		sink, opts, err := factory(ss.session)
+		if err == nil {
+			err = ss.sinks.add(name, sink, opts)
+		}
+		if err != nil {
+			ss.sinks.close()
+			return fmt.Errorf("unable to create sink %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+type sinkItem struct {
+	pkt         *rtp.Packet
+	captureTime time.Time
+	rtcp        rtcp.Packet
+	frame       *Frame
+	keyframe    bool
+}
+
+// writes to a sink from its buffer
+type sinkWriter struct {
+	name  string
+	sink  Sink
+	opts  SinkOptions
+	items chan sinkItem
+	done  chan struct{}
+
+	// only accessed with the sink set's lock held
+	dropping bool
+	closed   bool
+	// set once the stream stops waiting for the sink, which then discards what's left
+	abandoned atomic.Bool
+
+	written atomic.Uint64
+	dropped atomic.Uint64
+	errors  atomic.Uint64
+}
+
+func (sw *sinkWriter) run() {
+	defer close(sw.done)
+
+	rtpSink, _ := sw.sink.(RTPSink)
+	frameSink, _ := sw.sink.(FrameSink)
+	for item := range sw.items {
+		if sw.abandoned.Load() {
+			sw.dropped.Add(1)
+			continue
+		}
+		var err error
+		switch {
+		case item.pkt != nil:
+			err = rtpSink.WriteRTP(item.pkt, item.captureTime)
+		case item.rtcp != nil:
+			err = rtpSink.WriteRTCP(item.rtcp)
+		case item.frame != nil:
+			err = frameSink.WriteFrame(item.frame)
+		}
+		if err != nil {
+			sw.errors.Add(1)
+			continue
+		}
+		sw.written.Add(1)
+	}
+}
+
+// buffers the item unless the sink is behind; must be called with the sink set's lock held
+func (sw *sinkWriter) offer(item sinkItem) {
+	if sw.closed {
+		return
+	}
+	if sw.dropping && item.rtcp == nil {
+		if !item.keyframe {
+			sw.dropped.Add(1)
+			return
+		}
+		sw.dropping = false
+	}
+
+	select {
+	case sw.items <- item:
+		return
+	default:
+	}
+
+	sw.dropped.Add(1)
+	switch sw.opts.Policy {
+	case SinkDropUntilKeyframe:
+		sw.dropping = true
+	case SinkCloseOnOverflow:
+		fmt.Printf("sink %s fell behind, closing it\n", sw.name)
+		sw.stop()
+	}
+}
+
+// closes the sink once it has written what's buffered; must be called with the sink set's lock held
+func (sw *sinkWriter) stop() {
+	if sw.closed {
+		return
+	}
+	sw.closed = true
+	close(sw.items)
+	go func() {
+		<-sw.done
+		if err := sw.sink.Close(); err != nil {
+			fmt.Printf("error closing sink %s: %s\n", sw.name, err)
+		}
+	}()
+}
+
+// waits for the sink to write what's buffered, returning whether it did before the context ended
+func (sw *sinkWriter) wait(ctx context.Context) bool {
+	select {
+	case <-sw.done:
+		return true
+	case <-ctx.Done():
+		// it may have finished just as the context ended
+		select {
+		case <-sw.done:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// gives up waiting for the sink, which discards what's left buffered and is closed once its last write returns
+func (sw *sinkWriter) abandon() {
+	fmt.Printf("sink %s didn't finish writing within %s, abandoning it\n", sw.name, sinkCloseTimeout)
+	sw.errors.Add(1)
+	sw.abandoned.Store(true)
+	go func() {
+		<-sw.done
+		if err := sw.sink.Close(); err != nil {
+			fmt.Printf("error closing sink %s: %s\n", sw.name, err)
+		}
+	}()
+}
+
+func (sw *sinkWriter) stats() *skyegresspb.SinkStats {
+	return &skyegresspb.SinkStats{
+		Name:     sw.name,
+		Written:  sw.written.Load(),
+		Dropped:  sw.dropped.Load(),
+		Errors:   sw.errors.Load(),
+		Buffered: uint32(len(sw.items)),
+		Closed:   sw.closed,
+	}
+}
+
+// the sinks of a stream, which everything sent to readers is fanned out to
+type sinkSet struct {
+	lock    sync.Mutex
+	writers []*sinkWriter
+	closed  bool
+
+	// assembles frames for frame sinks from the packets written
+	decoder *rtph264.Decoder
+}
+
+func (s *sinkSet) add(name string, sink Sink, opts SinkOptions) error {
+	_, isRTP := sink.(RTPSink)
+	_, isFrame := sink.(FrameSink)
+	if !isRTP && !isFrame {
+		return fmt.Errorf("sink %s takes neither RTP nor frames", name)
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultSinkBuffer
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return errors.New("stream is stopped")
+	}
+
+	sw := &sinkWriter{
+		name:  name,
+		sink:  sink,
+		opts:  opts,
+		items: make(chan sinkItem, opts.BufferSize),
+		done:  make(chan struct{}),
+	}
+	if isFrame && s.decoder == nil {
+		s.decoder = &rtph264.Decoder{PacketizationMode: h264PacketizationMode}
+		s.decoder.Init()
+	}
+	s.writers = append(s.writers, sw)
+	go sw.run()
+	return nil
+}
+
+func (s *sinkSet) writeRTP(pkt *rtp.Packet, captureTime time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	item := sinkItem{pkt: pkt, captureTime: captureTime, keyframe: isKeyframeStart(pkt.Payload)}
+	for _, sw := range s.writers {
+		if _, ok := sw.sink.(RTPSink); ok {
+			sw.offer(item)
+		}
+	}
+
+	if s.decoder == nil {
+		return
+	}
+	// the frame's NALUs may share memory with the packet, so sinks mustn't modify them either
+	au, _, err := s.decoder.DecodeUntilMarker(pkt)
+	if err != nil {
+		return
+	}
+	frame := &Frame{
+		NALUs:       au,
+		Timestamp:   pkt.Timestamp,
+		CaptureTime: captureTime,
+		Keyframe:    h264.IDRPresent(au),
+	}
+	for _, sw := range s.writers {
+		if _, ok := sw.sink.(FrameSink); ok {
+			sw.offer(sinkItem{frame: frame, keyframe: frame.Keyframe})
+		}
+	}
+}
+
+func (s *sinkSet) writeRTCP(pkt rtcp.Packet) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, sw := range s.writers {
+		if _, ok := sw.sink.(RTPSink); ok {
+			sw.offer(sinkItem{rtcp: pkt})
+		}
+	}
+}
+
+// closes every sink once it has written what's buffered, returning the first error. Sinks still writing after
+// sinkCloseTimeout are abandoned, counting as an error, and closed whenever their last write returns.
+func (s *sinkSet) close() error {
+	// sinks already closed for falling behind close themselves
+	s.lock.Lock()
+	s.closed = true
+	closing := []*sinkWriter{}
+	for _, sw := range s.writers {
+		if !sw.closed {
+			sw.closed = true
+			close(sw.items)
+			closing = append(closing, sw)
+		}
+	}
+	s.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), sinkCloseTimeout)
+	defer cancel()
+	var firstErr error
+	for _, sw := range closing {
+		if !sw.wait(ctx) {
+			sw.abandon()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("sink %s didn't finish writing within %s", sw.name, sinkCloseTimeout)
+			}
+			continue
+		}
+		if err := sw.sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (s *sinkSet) stats() []*skyegresspb.SinkStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	stats := make([]*skyegresspb.SinkStats, 0, len(s.writers))
+	for _, sw := range s.writers {
+		stats = append(stats, sw.stats())
+	}
+	return stats
+}
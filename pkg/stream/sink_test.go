@@ -0,0 +1,251 @@
+package stream
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+)
+
+// an RTP sink whose writes block until it's released, so a test can fill its buffer
+type blockingSink struct {
+	started  chan struct{}
+	release  chan struct{}
+	startOne sync.Once
+
+	lock    sync.Mutex
+	written []uint16
+	closed  int
+}
+
+func newBlockingSink() *blockingSink {
+	return &blockingSink{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (bs *blockingSink) WriteRTP(pkt *rtp.Packet, captureTime time.Time) error {
+	bs.startOne.Do(func() { close(bs.started) })
+	<-bs.release
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+	bs.written = append(bs.written, pkt.SequenceNumber)
+	return nil
+}
+
+func (bs *blockingSink) WriteRTCP(pkt rtcp.Packet) error { return nil }
+
+func (bs *blockingSink) Close() error {
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+	bs.closed++
+	return nil
+}
+
+func (bs *blockingSink) writes() []uint16 {
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+	return append([]uint16(nil), bs.written...)
+}
+
+// waits until the sink has written the number of packets
+func (bs *blockingSink) waitWritten(t *testing.T, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); len(bs.writes()) < n; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("sink wrote %v, want %d packets", bs.writes(), n)
+		}
+	}
+}
+
+func sinkPacket(seq uint16, keyframe bool) *rtp.Packet {
+	payload := testNonIDR
+	if keyframe {
+		payload = testIDR
+	}
+	return &rtp.Packet{Header: rtp.Header{SequenceNumber: seq, Marker: true}, Payload: payload}
+}
+
+func equalSeqs(a []uint16, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSinkPolicies(t *testing.T) {
+	type write struct {
+		seq      uint16
+		keyframe bool
+	}
+	tests := []struct {
+		name   string
+		policy SinkPolicy
+		// written while the sink is stuck on the first packet, with room for two more
+		whileBlocked []write
+		// written once the sink has caught up
+		afterwards []write
+		want       []uint16
+		dropped    uint64
+		closed     bool
+	}{
+		{
+			name:         "drop until keyframe",
+			policy:       SinkDropUntilKeyframe,
+			whileBlocked: []write{{0, true}, {1, false}, {2, false}, {3, false}, {4, false}},
+			afterwards:   []write{{5, false}, {6, true}, {7, false}},
+			want:         []uint16{0, 1, 2, 6, 7},
+			dropped:      3,
+		},
+		{
+			name:         "drop newest",
+			policy:       SinkDropNewest,
+			whileBlocked: []write{{0, true}, {1, false}, {2, false}, {3, false}, {4, false}},
+			afterwards:   []write{{5, false}, {6, true}},
+			want:         []uint16{0, 1, 2, 5, 6},
+			dropped:      2,
+		},
+		{
+			name:         "close on overflow",
+			policy:       SinkCloseOnOverflow,
+			whileBlocked: []write{{0, true}, {1, false}, {2, false}, {3, false}},
+			afterwards:   []write{{4, true}},
+			want:         []uint16{0, 1, 2},
+			dropped:      1,
+			closed:       true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sinks := &sinkSet{}
+			sink := newBlockingSink()
+			if err := sinks.add("test", sink, SinkOptions{BufferSize: 2, Policy: test.policy}); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, w := range test.whileBlocked {
+				sinks.writeRTP(sinkPacket(w.seq, w.keyframe), time.Now())
+				if i == 0 {
+					// the sink is stuck writing the first packet, which leaves its buffer empty
+					<-sink.started
+				}
+			}
+			close(sink.release)
+			sink.waitWritten(t, 3)
+
+			for _, w := range test.afterwards {
+				sinks.writeRTP(sinkPacket(w.seq, w.keyframe), time.Now())
+			}
+			stats := sinks.stats()[0]
+			if err := sinks.close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := sink.writes(); !equalSeqs(got, test.want) {
+				t.Errorf("sink wrote %v, want %v", got, test.want)
+			}
+			if stats.Dropped != test.dropped || stats.Closed != test.closed {
+				t.Errorf("got %d dropped, closed %t, want %d, %t", stats.Dropped, stats.Closed, test.dropped, test.closed)
+			}
+			// closed exactly once, whether it overflowed or the set was closed
+			for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+				sink.lock.Lock()
+				closed := sink.closed
+				sink.lock.Unlock()
+				if closed == 1 {
+					break
+				}
+				if closed > 1 || time.Now().After(deadline) {
+					t.Fatalf("sink closed %d times", closed)
+				}
+			}
+		})
+	}
+}
+
+func TestSinkSetClose(t *testing.T) {
+	sinks := &sinkSet{}
+	sink := newBlockingSink()
+	close(sink.release)
+	if err := sinks.add("test", sink, SinkOptions{BufferSize: 16}); err != nil {
+		t.Fatal(err)
+	}
+	for seq := uint16(0); seq < 10; seq++ {
+		sinks.writeRTP(sinkPacket(seq, seq == 0), time.Now())
+	}
+	// everything buffered is written before the sink is closed
+	if err := sinks.close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := sink.writes(); len(got) != 10 || sink.closed != 1 {
+		t.Fatalf("sink wrote %v and was closed %d times", got, sink.closed)
+	}
+
+	sinks.writeRTP(sinkPacket(10, true), time.Now())
+	if err := sinks.add("late", newBlockingSink(), SinkOptions{}); err == nil {
+		t.Fatal("added a sink to a closed set")
+	}
+	if got := sink.writes(); len(got) != 10 {
+		t.Fatalf("sink was written %d packets after closing", len(got)-10)
+	}
+}
+
+func TestSIDFileName(t *testing.T) {
+	names := map[string]string{}
+	for _, sid := range []string{"a/b", "a-b", "a%2Fb", "a b", "a/b/c", "devroom/demo"} {
+		name := sidFileName(sid)
+		if strings.ContainsAny(name, "/ ") {
+			t.Errorf("%s: got %s, not a single file name", sid, name)
+		}
+		if other, ok := names[name]; ok {
+			t.Errorf("%s and %s both write to %s", sid, other, name)
+		}
+		names[name] = sid
+	}
+}
+
+func TestAnnexBSink(t *testing.T) {
+	dir := t.TempDir()
+	factory := NewAnnexBSinkFactory(dir)
+	sink, _, err := factory(&skyegresspb.Session{Sid: "devroom/demo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frameSink := sink.(FrameSink)
+	for _, frame := range []*Frame{
+		{NALUs: [][]byte{testNonIDR}},
+		{NALUs: [][]byte{testSPS, testPPS, testIDR}, Keyframe: true},
+		{NALUs: [][]byte{testNonIDR}},
+	} {
+		if err := frameSink.WriteFrame(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "devroom%2Fdemo-*.h264"))
+	if err != nil || len(paths) != 1 {
+		t.Fatalf("got files %v", paths)
+	}
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// from the first keyframe
+	want, _ := h264.AnnexBMarshal([][]byte{testSPS, testPPS, testIDR, testNonIDR})
+	if string(data) != string(want) {
+		t.Fatalf("got %x, want %x", data, want)
+	}
+}
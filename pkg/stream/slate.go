@@ -0,0 +1,227 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+	"github.com/aler9/gortsplib/v2/pkg/formatdecenc/rtph264"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/config"
+	"github.com/treyhaknson/skyegress/pkg/testpattern"
+)
+
+const (
+	slateTestPattern = "testpattern"
+
+	// relay ID the slate writes as; it's active like any relay, so a relay only replaces it at a keyframe
+	slateRelay = ^uint64(0)
+
+	// payload type, packetization mode and clock rate of the H264 format every RTSP stream is served with
+	h264PayloadType       = 125
+	h264PacketizationMode = 1
+	h264ClockRate         = 90000
+)
+
+// the video looped to RTSP readers while a stream's source is missing: a clip, or colour bars at the resolution of the
+// source
+type Slate struct {
+	cfg  config.SlateConfig
+	clip [][][]byte
+	// parsed from the clip's first access unit; the clip is only sent to sources it matches
+	clipSPS *h264.SPS
+
+	patternsLock sync.Mutex
+	patterns     map[[2]int][][][]byte
+}
+
+// the slate configured for the server; nil if none is
+func NewSlate(cfg config.SlateConfig) (*Slate, error) {
+	if len(cfg.Source) == 0 {
+		return nil, nil
+	}
+	if cfg.FrameRate <= 0 {
+		return nil, errors.New("slate frame rate must be positive")
+	}
+
+	slate := &Slate{
+		cfg:      cfg,
+		patterns: make(map[[2]int][][][]byte),
+	}
+	if cfg.Source != slateTestPattern {
+		clip, err := testpattern.LoadClip(cfg.Source)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load slate %s: %w", cfg.Source, err)
+		}
+		sps, err := clipSPS(clip)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load slate %s: %w", cfg.Source, err)
+		}
+		slate.clip = clip
+		slate.clipSPS = sps
+	}
+	return slate, nil
+}
+
+// the SPS heading the clip's first access unit
+func clipSPS(clip [][][]byte) (*h264.SPS, error) {
+	for _, nalu := range clip[0] {
+		if h264.NALUType(nalu[0]&0x1F) != h264.NALUTypeSPS {
+			continue
+		}
+		sps := &h264.SPS{}
+		if err := sps.Unmarshal(nalu); err != nil {
+			return nil, fmt.Errorf("invalid SPS: %w", err)
+		}
+		return sps, nil
+	}
+	return nil, errors.New("clip must carry an SPS in its first access unit")
+}
+
+// whether the clip can be spliced into a source with the given SPS: decoders can't follow a change of profile or
+// resolution mid-stream. A source whose SPS hasn't been seen yet has nothing to contradict.
+func (s *Slate) clipMatches(sourceSPS []byte) (bool, string) {
+	if sourceSPS == nil {
+		return true, ""
+	}
+	var sps h264.SPS
+	if err := sps.Unmarshal(sourceSPS); err != nil {
+		return true, ""
+	}
+	if sps.ProfileIdc != s.clipSPS.ProfileIdc {
+		return false, fmt.Sprintf("clip has profile %d, source %d", s.clipSPS.ProfileIdc, sps.ProfileIdc)
+	}
+	if sps.Width() != s.clipSPS.Width() || sps.Height() != s.clipSPS.Height() {
+		return false, fmt.Sprintf("clip is %dx%d, source %dx%d",
+			s.clipSPS.Width(), s.clipSPS.Height(), sps.Width(), sps.Height())
+	}
+	return true, ""
+}
+
+// the access units of one loop of the slate for a source with the given SPS and resolution: the clip if it matches
+// the source, otherwise colour bars sized to the source if its resolution is known
+func (s *Slate) frames(sid string, sourceSPS []byte, width uint32, height uint32) ([][][]byte, error) {
+	if s.clip != nil {
+		matches, mismatch := s.clipMatches(sourceSPS)
+		if matches {
+			return s.clip, nil
+		}
+		fmt.Printf("slate %s doesn't match stream %s (%s), sending colour bars instead\n", s.cfg.Source, sid, mismatch)
+	}
+
+	size := [2]int{s.cfg.Width, s.cfg.Height}
+	if width > 0 && height > 0 {
+		size = [2]int{int(width), int(height)}
+	}
+	// chroma is subsampled, so the pattern is a whole number of chroma samples
+	size[0] &^= 1
+	size[1] &^= 1
+
+	s.patternsLock.Lock()
+	defer s.patternsLock.Unlock()
+	if pattern, ok := s.patterns[size]; ok {
+		return pattern, nil
+	}
+	pattern, err := testpattern.ColourBars(size[0], size[1], s.cfg.FrameRate)
+	if err != nil {
+		return nil, err
+	}
+	s.patterns[size] = pattern
+	return pattern, nil
+}
+
+// sends the slate to readers whenever no packets have been written for the slate timeout, until the stream stops. A
+// relay replaces it at its first keyframe.
+func (ss *skyEgressStream) runSlate() {
+	interval := time.Second / time.Duration(ss.slate.cfg.FrameRate)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	startedAt := time.Now()
+	var encoder *rtph264.Encoder
+	var frames [][][]byte
+	frame := 0
+
+	for {
+		select {
+		case <-ss.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if encoder != nil && !ss.isActiveRelay(slateRelay) {
+			encoder = nil
+			ss.setSlate(false, "source replaced the slate")
+		}
+
+		if encoder == nil {
+			lastWrite := ss.rewriter.lastWrite()
+			if lastWrite.IsZero() {
+				lastWrite = startedAt
+			}
+			if time.Since(lastWrite) < ss.slate.cfg.Timeout {
+				continue
+			}
+
+			stats := ss.stats.snapshot()
+			var err error
+			frames, err = ss.slate.frames(ss.session.Sid, ss.stats.lastSPS(), stats.Width, stats.Height)
+			if err != nil {
+				fmt.Printf("unable to build slate for stream %s: %s\n", ss.session.Sid, err)
+				return
+			}
+
+			ss.sourceLock.Lock()
+			ss.relays.active = slateRelay
+			ss.sourceLock.Unlock()
+			ss.rewriter.switchSource(slateRelay)
+
+			encoder = &rtph264.Encoder{
+				PayloadType:       h264PayloadType,
+				PacketizationMode: h264PacketizationMode,
+			}
+			encoder.Init()
+			frame = 0
+			ss.setSlate(true, fmt.Sprintf("no source packets for %s", ss.slate.cfg.Timeout))
+		}
+
+		// the slate loops, but its timestamps carry on from one loop to the next
+		pkts, err := encoder.Encode(frames[frame%len(frames)], time.Duration(frame)*interval)
+		frame++
+		if err != nil {
+			fmt.Printf("unable to packetize slate for stream %s: %s\n", ss.session.Sid, err)
+			continue
+		}
+		for _, p := range pkts {
+			if !ss.rewriter.rewrite(p, h264ClockRate, slateRelay) {
+				// a relay took over since the last frame
+				break
+			}
+			now := time.Now()
+			ss.stats.onRelayed(p)
+			ss.clock.onWritten(p, now, clockFromArrival, time.Time{})
+			ss.sinks.writeRTP(p, now)
+		}
+	}
+}
+
+func (ss *skyEgressStream) setSlate(active bool, reason string) {
+	ss.stats.onSlate(active)
+	fmt.Printf("stream %s slate active: %t (%s)\n", ss.session.Sid, active, reason)
+	if ss.onEvent == nil {
+		return
+	}
+
+	ss.sessionLock.RLock()
+	session := cloneSession(ss.session)
+	ss.sessionLock.RUnlock()
+	session.Stats = ss.Stats()
+
+	eventType := skyegresspb.SessionEventType_SESSION_EVENT_SLATE_STOPPED
+	if active {
+		eventType = skyegresspb.SessionEventType_SESSION_EVENT_SLATE_STARTED
+	}
+	ss.onEvent(NewSessionEvent(eventType, session, reason))
+}
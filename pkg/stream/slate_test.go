@@ -0,0 +1,107 @@
+package stream
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+	"github.com/treyhaknson/skyegress/pkg/config"
+	"github.com/treyhaknson/skyegress/pkg/testpattern"
+)
+
+// writes colour bars of the given size as an Annex B clip
+func writeClip(t *testing.T, width int, height int) string {
+	t.Helper()
+	aus, err := testpattern.ColourBars(width, height, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nalus [][]byte
+	for _, au := range aus {
+		nalus = append(nalus, au...)
+	}
+	annexB, err := h264.AnnexBMarshal(nalus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "slate.h264")
+	if err := os.WriteFile(path, annexB, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// the SPS of colour bars of the given size, with its profile_idc replaced if profile is nonzero
+func barsSPS(t *testing.T, width int, height int, profile byte) []byte {
+	t.Helper()
+	aus, err := testpattern.ColourBars(width, height, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sps := append([]byte(nil), aus[0][0]...)
+	if profile != 0 {
+		sps[1] = profile
+	}
+	return sps
+}
+
+func TestSlateClipMatchesSource(t *testing.T) {
+	slate, err := NewSlate(config.SlateConfig{Source: writeClip(t, 64, 48), FrameRate: 10, Width: 320, Height: 240})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		sourceSPS []byte
+		width     uint32
+		height    uint32
+		wantClip  bool
+	}{
+		{"source SPS not seen yet", nil, 0, 0, true},
+		{"same profile and resolution", barsSPS(t, 64, 48, 0), 64, 48, true},
+		{"other resolution", barsSPS(t, 128, 96, 0), 128, 96, false},
+		{"other profile", barsSPS(t, 64, 48, 77), 64, 48, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			frames, err := slate.frames("studio/main", test.sourceSPS, test.width, test.height)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sentClip := &frames[0] == &slate.clip[0]; sentClip != test.wantClip {
+				t.Fatalf("got clip %t, want %t", sentClip, test.wantClip)
+			}
+			if test.wantClip {
+				return
+			}
+			// the bars stand in at the source's resolution
+			var sps h264.SPS
+			if err := sps.Unmarshal(frames[0][0]); err != nil {
+				t.Fatal(err)
+			}
+			if sps.Width() != int(test.width) || sps.Height() != int(test.height) {
+				t.Errorf("got %dx%d bars, want %dx%d", sps.Width(), sps.Height(), test.width, test.height)
+			}
+		})
+	}
+}
+
+func TestSlateClipWithoutSPS(t *testing.T) {
+	aus, err := testpattern.ColourBars(64, 48, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// just the IDR
+	annexB, err := h264.AnnexBMarshal([][]byte{aus[0][2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "slate.h264")
+	if err := os.WriteFile(path, annexB, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSlate(config.SlateConfig{Source: path, FrameRate: 10}); err == nil {
+		t.Fatal("expected an error loading a clip without an SPS")
+	}
+}
@@ -0,0 +1,153 @@
+package stream
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"google.golang.org/protobuf/proto"
+)
+
+// where a stream's video comes from: a LiveKit room, or one of the sources registered by URL scheme. A source is
+// started once, and delivers its video to the stream as tracks until it's stopped.
+type Source interface {
+	// connects to the source, returning once it's connected; tracks may be added to the stream before it returns
+	Start(stream SourceStream) error
+	// disconnects; nothing more is reported to the stream afterwards
+	Stop()
+}
+
+// the stream a source delivers its video to
+type SourceStream interface {
+	// the session, for the details of the video the source should deliver
+	Session() *skyegresspb.Session
+	// relays the track as the stream's primary or backup video, until reading it fails, the stream stops or another
+	// track replaces it. Adding a track already being relayed only changes its role.
+	AddTrack(source Source, track Track, role skyegresspb.SessionSource)
+	// the source stopped delivering video, but may resume
+	SourceLost(source Source, reason string)
+	// the source can't deliver any more video
+	SourceFailed(source Source, reason string)
+	// RTCP the source received alongside its tracks' RTP, such as sender reports, for captures of the session
+	ReceivedRTCP(source Source, pkt rtcp.Packet)
+}
+
+// H264 video a source delivers as RTP
+type Track interface {
+	// the next packet, in order as far as the source knows; an error once the track has ended
+	ReadRTP() (*rtp.Packet, error)
+	ClockRate() uint32
+	// who is publishing the video, as passed on to processors and in SEI
+	Publisher() (identity string, metadata string)
+	// asks for a keyframe, so the track can take over from another sooner; tracks that can't ask ignore it
+	RequestKeyframe()
+	// the capture time of the packets with the timestamp, and when the RTCP sender report saying so arrived, zero
+	// if the source knows the capture time itself; false if it isn't known, in which case the time packets are read
+	// is taken instead
+	CaptureTime(timestamp uint32) (time.Time, time.Time, bool)
+}
+
+// builds the source a session names; called once for every session started with a URL of the scheme
+type SourceFactory func(session *skyegresspb.Session) (Source, error)
+
+var sourceFactories = newRegistry(map[string]SourceFactory{
+	"rtsp":            newRTSPSource,
+	"rtsps":           newRTSPSource,
+	sourceTestPattern: newTestPatternSource,
+})
+
+// makes a source available to sessions whose source URL has the scheme
+func RegisterSource(scheme string, factory SourceFactory) {
+	sourceFactories.register(scheme, factory)
+}
+
+// the source for the session's URL, by its scheme; a URL without one is taken to be the scheme alone
+func newSource(session *skyegresspb.Session) (Source, error) {
+	scheme, _, _ := strings.Cut(session.Source, ":")
+
+	factory, ok := sourceFactories.get(scheme)
+	if !ok {
+		return nil, fmt.Errorf("unknown source %s", RedactSource(session.Source))
+	}
+	source, err := factory(session)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create source %s: %w", RedactSource(session.Source), err)
+	}
+	return source, nil
+}
+
+// the source URL without any credentials it carries, as it's shown outside the server
+func RedactSource(source string) string {
+	u, err := url.Parse(source)
+	if err != nil || u.User == nil {
+		return source
+	}
+	u.User = nil
+	return u.String()
+}
+
+// a copy of the session to hand out of the stream, with the credentials of its source removed
+func cloneSession(session *skyegresspb.Session) *skyegresspb.Session {
+	clone := proto.Clone(session).(*skyegresspb.Session)
+	clone.Source = RedactSource(clone.Source)
+	return clone
+}
+
+func (ss *skyEgressStream) AddTrack(source Source, track Track, role skyegresspb.SessionSource) {
+	if !ss.isTarget(source) {
+		return
+	}
+	id, ok := ss.addRelay(track, role)
+	if !ok {
+		// already relaying the track
+		return
+	}
+	go ss.relay(id, source, track)
+}
+
+func (ss *skyEgressStream) SourceLost(source Source, reason string) {
+	ss.sourceLock.Lock()
+	current := source == ss.source
+	ss.sourceLock.Unlock()
+	if current {
+		ss.loseSource(reason)
+	}
+}
+
+func (ss *skyEgressStream) SourceFailed(source Source, reason string) {
+	// a disconnect we asked for is not a failure
+	if ss.ctx.Err() != nil {
+		return
+	}
+
+	ss.sourceLock.Lock()
+	current, pending := source == ss.source, source == ss.pendingSource
+	if pending {
+		ss.pendingSource = nil
+	}
+	ss.sourceLock.Unlock()
+
+	// a source we were switching to is given up on; the current one carries on
+	switch {
+	case pending:
+		fmt.Printf("source being switched to for stream %s failed: %s\n", ss.session.Sid, reason)
+	case current:
+		fmt.Printf("source for stream %s failed: %s\n", ss.session.Sid, reason)
+		ss.setState(skyegresspb.SessionState_SESSION_STATE_FAILED, reason)
+	}
+}
+
+// whether tracks from the source should be relayed: it's the source the stream is being switched to, or the current
+// one if there's no switch underway
+func (ss *skyEgressStream) isTarget(source Source) bool {
+	ss.sourceLock.Lock()
+	defer ss.sourceLock.Unlock()
+	if ss.pendingSource != nil {
+		return source == ss.pendingSource
+	}
+	return source == ss.source
+}
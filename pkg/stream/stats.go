@@ -0,0 +1,224 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+	"github.com/pion/rtp"
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+)
+
+// rates are computed over windows of this length
+const statsWindow = time.Second
+
+// counters for a stream; updated by the relay goroutine, read by anyone
+type relayStats struct {
+	lock sync.Mutex
+
+	packetsReceived    uint64
+	bytesReceived      uint64
+	packetsRelayed     uint64
+	bytesRelayed       uint64
+	packetsLost        uint64
+	packetsReordered   uint64
+	samplebuilderDrops uint64
+	plisSent           uint64
+	lastPacketAt       time.Time
+
+	// sequence tracking for loss and reordering; reset whenever a new track is relayed
+	hasSeq  bool
+	ssrc    uint32
+	nextSeq uint16
+	// sequence numbers counted as lost that are recent enough to still turn up late
+	missing map[uint16]struct{}
+
+	width  uint32
+	height uint32
+	// the source's most recent SPS; nil until one is seen
+	sps              []byte
+	lastKeyframeAt   time.Time
+	keyframeInterval time.Duration
+
+	windowStart  time.Time
+	windowBytes  uint64
+	windowFrames uint64
+	bitrate      uint64
+	frameRate    float64
+
+	slateActive bool
+}
+
+// a new track uses a new sequence, so don't count the jump as loss
+func (rs *relayStats) resetSequence() {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	rs.hasSeq = false
+}
+
+// called for every packet received from LiveKit, in arrival order
+func (rs *relayStats) onReceived(pkt *rtp.Packet) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+
+	now := time.Now()
+	rs.packetsReceived++
+	rs.bytesReceived += uint64(len(pkt.Payload))
+	rs.windowBytes += uint64(len(pkt.Payload))
+	rs.lastPacketAt = now
+
+	// a rebuilt subscription starts a new sequence, which isn't loss either
+	jump := pkt.SequenceNumber - rs.nextSeq
+	if !rs.hasSeq || pkt.SSRC != rs.ssrc || (jump > maxDropout && jump < 1<<16-maxMisorder) {
+		rs.hasSeq = true
+		rs.ssrc = pkt.SSRC
+		rs.nextSeq = pkt.SequenceNumber + 1
+		rs.missing = make(map[uint16]struct{})
+	} else {
+		// sequence numbers wrap, so compare them as a signed distance
+		diff := int16(pkt.SequenceNumber - rs.nextSeq)
+		switch {
+		case diff > 0:
+			rs.packetsLost += uint64(diff)
+			gap := diff
+			if gap > maxMisorder {
+				gap = maxMisorder
+			}
+			for seq := pkt.SequenceNumber - uint16(gap); seq != pkt.SequenceNumber; seq++ {
+				rs.missing[seq] = struct{}{}
+			}
+			rs.nextSeq = pkt.SequenceNumber + 1
+		case diff < 0:
+			// only a packet we counted as lost turning up late is reordered; anything else is a duplicate
+			if _, ok := rs.missing[pkt.SequenceNumber]; ok {
+				delete(rs.missing, pkt.SequenceNumber)
+				rs.packetsReordered++
+				rs.packetsLost--
+			}
+		default:
+			rs.nextSeq = pkt.SequenceNumber + 1
+		}
+		// packets further behind than that start a new sequence rather than turn up late
+		for seq := range rs.missing {
+			if rs.nextSeq-seq > maxMisorder {
+				delete(rs.missing, seq)
+			}
+		}
+	}
+
+	rs.rollWindow(now)
+}
+
+// called for every packet written to RTSP readers
+func (rs *relayStats) onRelayed(pkt *rtp.Packet) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	rs.packetsRelayed++
+	rs.bytesRelayed += uint64(len(pkt.Payload))
+}
+
+// the source's most recent SPS; nil until one is seen
+func (rs *relayStats) lastSPS() []byte {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	if rs.sps == nil {
+		return nil
+	}
+	return append([]byte(nil), rs.sps...)
+}
+
+// called for every complete access unit written to RTSP readers
+func (rs *relayStats) onFrame(au [][]byte) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+
+	now := time.Now()
+	rs.windowFrames++
+
+	for _, nalu := range au {
+		if len(nalu) == 0 {
+			continue
+		}
+		if h264.NALUType(nalu[0]&0x1F) != h264.NALUTypeSPS {
+			continue
+		}
+		sps := h264.SPS{}
+		if err := sps.Unmarshal(nalu); err == nil {
+			rs.width = uint32(sps.Width())
+			rs.height = uint32(sps.Height())
+			rs.sps = append(rs.sps[:0], nalu...)
+		}
+	}
+
+	if h264.IDRPresent(au) {
+		if !rs.lastKeyframeAt.IsZero() {
+			rs.keyframeInterval = now.Sub(rs.lastKeyframeAt)
+		}
+		rs.lastKeyframeAt = now
+	}
+}
+
+// called when the samplebuilder drops packets and a PLI is sent to recover
+func (rs *relayStats) onDropped() {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	rs.samplebuilderDrops++
+	rs.plisSent++
+}
+
+func (rs *relayStats) onSlate(active bool) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	rs.slateActive = active
+}
+
+// must be called with the lock held
+func (rs *relayStats) rollWindow(now time.Time) {
+	if rs.windowStart.IsZero() {
+		rs.windowStart = now
+		return
+	}
+	elapsed := now.Sub(rs.windowStart)
+	if elapsed < statsWindow {
+		return
+	}
+	rs.bitrate = uint64(float64(rs.windowBytes*8) / elapsed.Seconds())
+	rs.frameRate = float64(rs.windowFrames) / elapsed.Seconds()
+	rs.windowStart = now
+	rs.windowBytes = 0
+	rs.windowFrames = 0
+}
+
+func (rs *relayStats) snapshot() *skyegresspb.SessionStats {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+
+	now := time.Now()
+	stats := &skyegresspb.SessionStats{
+		PacketsRelayed:     rs.packetsRelayed,
+		BytesRelayed:       rs.bytesRelayed,
+		PacketsReceived:    rs.packetsReceived,
+		BytesReceived:      rs.bytesReceived,
+		InputBitrate:       rs.bitrate,
+		FrameRate:          rs.frameRate,
+		Width:              rs.width,
+		Height:             rs.height,
+		KeyframeIntervalMs: uint32(rs.keyframeInterval.Milliseconds()),
+		PacketsLost:        rs.packetsLost,
+		PacketsReordered:   rs.packetsReordered,
+		SamplebuilderDrops: rs.samplebuilderDrops,
+		PlisSent:           rs.plisSent,
+		LastPacketAgeMs:    -1,
+		SlateActive:        rs.slateActive,
+	}
+	if !rs.lastPacketAt.IsZero() {
+		stats.LastPacketAgeMs = now.Sub(rs.lastPacketAt).Milliseconds()
+	}
+	// the window only rolls when packets arrive; once they stop, so have the rates
+	if now.Sub(rs.windowStart) > 2*statsWindow {
+		stats.InputBitrate = 0
+		stats.FrameRate = 0
+	}
+
+	return stats
+}
@@ -0,0 +1,35 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+)
+
+func TestRelayStatsLossAndReordering(t *testing.T) {
+	tests := []struct {
+		name      string
+		seqs      []uint16
+		lost      uint64
+		reordered uint64
+	}{
+		{"in order", []uint16{1, 2, 3, 4}, 0, 0},
+		{"a gap", []uint16{1, 2, 5, 6}, 2, 0},
+		{"a late packet fills the gap", []uint16{1, 3, 2, 4}, 0, 1},
+		{"a duplicate doesn't hide loss", []uint16{1, 2, 4, 2, 5}, 1, 0},
+		{"a late packet counts once", []uint16{1, 3, 2, 2, 4}, 0, 1},
+		{"a duplicate with nothing lost", []uint16{1, 2, 3, 3, 2, 4}, 0, 0},
+		{"across the wrap", []uint16{65534, 0, 65535, 65535, 1}, 0, 1},
+		{"too late to fill the gap", []uint16{1, 3, 200, 2}, 197, 0},
+	}
+	for _, test := range tests {
+		rs := &relayStats{}
+		for _, seq := range test.seqs {
+			rs.onReceived(&rtp.Packet{Header: rtp.Header{SSRC: 10, SequenceNumber: seq}})
+		}
+		if rs.packetsLost != test.lost || rs.packetsReordered != test.reordered {
+			t.Errorf("%s: got %d lost, %d reordered, want %d, %d",
+				test.name, rs.packetsLost, rs.packetsReordered, test.lost, test.reordered)
+		}
+	}
+}
@@ -3,37 +3,71 @@ package stream
 import (
 	"context"
 	"fmt"
-	"strings"
+	"sync"
+	"time"
 
 	"github.com/pion/rtp/codecs"
-	"github.com/pion/webrtc/v3"
 	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/aler9/gortsplib/v2"
 	"github.com/aler9/gortsplib/v2/pkg/format"
+	"github.com/aler9/gortsplib/v2/pkg/formatdecenc/rtph264"
 	"github.com/aler9/gortsplib/v2/pkg/media"
-	lksdk "github.com/livekit/server-sdk-go"
 	"github.com/livekit/server-sdk-go/pkg/samplebuilder"
 )
 
 const (
 	maxVideoLate = 500 // ~1s
+	// how often a relay waiting to take over asks for a keyframe
+	keyframeRequestInterval = time.Second
 )
 
 type skyEgressStream struct {
-	ctx        context.Context
-	cancel     context.CancelFunc
-	session    *skyegresspb.Session
-	room       *lksdk.Room
-	rtspStream *gortsplib.ServerStream
+	ctx         context.Context
+	cancel      context.CancelFunc
+	sessionLock sync.RWMutex
+	session     *skyegresspb.Session
+	rtspStream  *gortsplib.ServerStream
+	onEvent     EventListener
+	stats       relayStats
+	rewriter    rtpRewriter
+	processors  *processorChain
+	clock       outputClock
+	// everything sent to readers is fanned out to the sinks, the RTSP stream among them
+	sinks sinkSet
+	// sent to readers while the source is missing; nil if there is none
+	slate *Slate
+	// the packets received from the source, while capturing
+	capture packetCapture
+
+	// the source being relayed from, and the one a retarget is switching to
+	sourceLock    sync.Mutex
+	source        Source
+	pendingSource Source
+	relays        relaySet
+
+	readersLock sync.RWMutex
+	readers     map[*gortsplib.ServerSession]*rtspReader
+	// sessions admitted at SETUP that haven't played yet; they count against the limits like readers
+	reserved map[*gortsplib.ServerSession]struct{}
+	// when the last reader left, or the stream was created; zero while readers are connected
+	idleSince time.Time
 }
 
-func NewSkyEgressStream(session *skyegresspb.Session) skyEgressStream {
+func newSkyEgressStream(session *skyegresspb.Session, onEvent EventListener, slate *Slate, processors *processorChain) skyEgressStream {
 	ctx, cancel := context.WithCancel(context.Background())
 	return skyEgressStream{
-		ctx:     ctx,
-		cancel:  cancel,
-		session: session,
+		ctx:        ctx,
+		cancel:     cancel,
+		session:    session,
+		onEvent:    onEvent,
+		slate:      slate,
+		processors: processors,
+		relays:     newRelaySet(time.Duration(session.FailoverTimeoutMs) * time.Millisecond),
+		readers:    make(map[*gortsplib.ServerSession]*rtspReader),
+		reserved:   make(map[*gortsplib.ServerSession]struct{}),
+		idleSince:  time.Now(),
 	}
 }
 
@@ -41,60 +75,216 @@ func (ss *skyEgressStream) RTSPStream() *gortsplib.ServerStream {
 	return ss.rtspStream
 }
 
-func (ss *skyEgressStream) Start(host string, info lksdk.ConnectInfo) error {
-	wsURL := fmt.Sprintf("wss://%s", host)
-	room, err := lksdk.ConnectToRoom(
-		wsURL,
-		info,
-		&lksdk.RoomCallback{
-			ParticipantCallback: lksdk.ParticipantCallback{
-				OnTrackSubscribed: ss.onTrackSubscribed,
-			},
-		},
-	)
-
-	ss.room = room
+// returns a snapshot of the session and its stats, safe to hand to other goroutines
+func (ss *skyEgressStream) Session() *skyegresspb.Session {
+	ss.sessionLock.RLock()
+	session := cloneSession(ss.session)
+	ss.sessionLock.RUnlock()
+
+	session.Stats = ss.Stats()
+	return session
+}
+
+func (ss *skyEgressStream) Stats() *skyegresspb.SessionStats {
+	stats := ss.stats.snapshot()
+	stats.Readers = ss.Readers()
+	stats.IdleMs = ss.IdleFor().Milliseconds()
+	stats.RtpRebases = ss.rewriter.rebaseCount()
+	stats.Sinks = ss.sinks.stats()
+	stats.Capture = ss.capture.snapshot()
+	return stats
+}
+
+// the wall-clock capture time of the RTP readers are being sent; nil before the first packet
+func (ss *skyEgressStream) Clock() *skyegresspb.ClockMapping {
+	return ss.clock.mapping()
+}
+
+func (ss *skyEgressStream) State() skyegresspb.SessionState {
+	ss.sessionLock.RLock()
+	defer ss.sessionLock.RUnlock()
+	return ss.session.State
+}
+
+// records the track now being relayed, reporting a switch between the primary and backup
+func (ss *skyEgressStream) setSource(identity string, source skyegresspb.SessionSource) {
+	ss.sessionLock.Lock()
+	from := ss.session.ActiveSource
+	ss.session.PublisherIdentity = identity
+	ss.session.ActiveSource = source
+	session := cloneSession(ss.session)
+	ss.sessionLock.Unlock()
+
+	if from == source || ss.onEvent == nil {
+		return
+	}
+	session.Stats = ss.Stats()
+	fmt.Printf("stream %s switched source %s -> %s\n", session.Sid, from, source)
+	switch source {
+	case skyegresspb.SessionSource_SESSION_SOURCE_BACKUP:
+		ss.onEvent(NewSessionEvent(skyegresspb.SessionEventType_SESSION_EVENT_FAILOVER, session, "primary track stopped delivering packets"))
+	default:
+		ss.onEvent(NewSessionEvent(skyegresspb.SessionEventType_SESSION_EVENT_FAILBACK, session, "primary track is delivering packets again"))
+	}
+}
+
+func (ss *skyEgressStream) setState(state skyegresspb.SessionState, reason string) {
+	ss.sessionLock.Lock()
+	from := ss.session.State
+	if from == state {
+		ss.sessionLock.Unlock()
+		return
+	}
+	fmt.Printf("stream %s changed state %s -> %s (%s)\n", ss.session.Sid, from, state, reason)
+	ss.session.State = state
+	session := cloneSession(ss.session)
+	ss.sessionLock.Unlock()
+	session.Stats = ss.Stats()
+
+	if eventType, ok := eventForTransition(from, state); ok && ss.onEvent != nil {
+		ss.onEvent(NewSessionEvent(eventType, session, reason))
+	}
+}
+
+// flags an active stream as having lost its source; a stream that never received packets stays starting
+func (ss *skyEgressStream) loseSource(reason string) {
+	if ss.State() != skyegresspb.SessionState_SESSION_STATE_ACTIVE {
+		return
+	}
+	ss.setState(skyegresspb.SessionState_SESSION_STATE_SOURCE_LOST, reason)
+}
+
+func (ss *skyEgressStream) Start(source Source) error {
+	// tracks can be added before the source's Start returns, so the RTSP stream must exist first
 	ss.rtspStream = gortsplib.NewServerStream(media.Medias{{
 		Type: media.TypeVideo,
 		Formats: []format.Format{&format.H264{
-			PayloadTyp:        125, // TODO: where does this come from? LiveKit uses 125, gortsplib examples use 96
-			PacketizationMode: 1,
+			PayloadTyp:        h264PayloadType, // TODO: where does this come from? LiveKit uses 125, gortsplib examples use 96
+			PacketizationMode: h264PacketizationMode,
 		}},
 	}})
+	err := ss.sinks.add("rtsp", &rtspSink{stream: ss.rtspStream}, SinkOptions{
+		BufferSize: rtspSinkBuffer,
+		Policy:     SinkDropUntilKeyframe,
+	})
+	if err != nil {
+		ss.rtspStream.Close()
+		ss.setState(skyegresspb.SessionState_SESSION_STATE_FAILED, err.Error())
+		return err
+	}
+	go ss.sendSenderReports()
+	if ss.slate != nil {
+		go ss.runSlate()
+	}
 
+	ss.sourceLock.Lock()
+	ss.source = source
+	ss.sourceLock.Unlock()
+
+	err = source.Start(ss)
+	if err != nil {
+		ss.setState(skyegresspb.SessionState_SESSION_STATE_FAILED, err.Error())
+	}
 	return err
 }
 
-func (ss *skyEgressStream) Stop() error {
-	ss.cancel()
-	if ss.rtspStream != nil {
-		err := ss.rtspStream.Close()
-		return err
+// switches the stream to another room and track. The current track keeps being relayed until the new one delivers
+// a keyframe, so readers see one continuous stream.
+func (ss *skyEgressStream) Retarget(source *liveKitSource, trackName string, participantIdentity string) error {
+	ss.sessionLock.Lock()
+	previous := proto.Clone(ss.session).(*skyegresspb.Session)
+	ss.session.RoomName = source.info.RoomName
+	ss.session.TrackName = trackName
+	ss.session.ParticipantIdentity = participantIdentity
+	// the backup belongs to the source being replaced
+	ss.session.BackupTrackName = ""
+	ss.session.BackupParticipantIdentity = ""
+	ss.sessionLock.Unlock()
+	ss.resetRelayTargets(time.Duration(previous.FailoverTimeoutMs) * time.Millisecond)
+
+	ss.sourceLock.Lock()
+	current, pending := ss.source, ss.pendingSource
+	ss.pendingSource = nil
+	ss.sourceLock.Unlock()
+
+	// abandon an earlier retarget to another room that hasn't taken over yet
+	if pending != nil {
+		pending.Stop()
 	}
-	if ss.room != nil {
-		ss.room.Disconnect()
+
+	currentRoom, _ := current.(*liveKitSource)
+	if currentRoom != nil && previous.RoomName == source.info.RoomName {
+		// already in the room; relay the track straight away if it's published
+		currentRoom.relayExisting()
+		return nil
+	}
+
+	ss.sourceLock.Lock()
+	ss.pendingSource = source
+	ss.sourceLock.Unlock()
+
+	err := source.Start(ss)
+	if err != nil {
+		ss.sourceLock.Lock()
+		if ss.pendingSource == source {
+			ss.pendingSource = nil
+		}
+		ss.sourceLock.Unlock()
+		source.Stop()
+
+		// carry on as before
+		ss.sessionLock.Lock()
+		ss.session.RoomName = previous.RoomName
+		ss.session.TrackName = previous.TrackName
+		ss.session.ParticipantIdentity = previous.ParticipantIdentity
+		ss.session.BackupTrackName = previous.BackupTrackName
+		ss.session.BackupParticipantIdentity = previous.BackupParticipantIdentity
+		ss.sessionLock.Unlock()
+		if currentRoom != nil {
+			currentRoom.relayExisting()
+		}
+		return err
 	}
 	return nil
 }
 
-func (ss *skyEgressStream) onTrackSubscribed(
-	track *webrtc.TrackRemote,
-	publication *lksdk.RemoteTrackPublication,
-	rp *lksdk.RemoteParticipant,
-) {
-	switch {
-	case strings.EqualFold(track.Codec().MimeType, "video/h264"):
-		sb := samplebuilder.New(maxVideoLate, &codecs.H264Packet{}, track.Codec().ClockRate, samplebuilder.WithPacketDroppedHandler(func() {
-			rp.WritePLI(track.SSRC())
-		}))
-		go ss.relay(track, sb)
-	default:
-		break
+func (ss *skyEgressStream) Stop(reason string) error {
+	ss.cancel()
+	ss.setState(skyegresspb.SessionState_SESSION_STATE_STOPPED, reason)
+
+	// stop the sources even if closing a sink fails, so no participant is left behind in a room
+	ss.sourceLock.Lock()
+	source, pending := ss.source, ss.pendingSource
+	ss.sourceLock.Unlock()
+	if source != nil {
+		source.Stop()
+	}
+	if pending != nil {
+		pending.Stop()
 	}
+	// fails only if there was no capture to stop
+	ss.capture.stop(reason)
+
+	// the RTSP stream is closed with the rest of the sinks
+	return ss.sinks.close()
 }
 
-func (ss *skyEgressStream) relay(track *webrtc.TrackRemote, sb *samplebuilder.SampleBuilder) {
+func (ss *skyEgressStream) relay(id uint64, source Source, track Track) {
 	fmt.Println("starting relay for stream", ss.session.Sid)
+	defer ss.removeRelay(id, track)
+
+	clockRate := track.ClockRate()
+	sb := samplebuilder.New(maxVideoLate, &codecs.H264Packet{}, clockRate, samplebuilder.WithPacketDroppedHandler(func() {
+		if ss.isActiveRelay(id) {
+			ss.stats.onDropped()
+		}
+		track.RequestKeyframe()
+	}))
+	// depacketizes relayed packets into access units for the stats; the packets themselves are relayed as-is
+	decoder := &rtph264.Decoder{PacketizationMode: 1}
+	decoder.Init()
+	// when a keyframe was last asked for while the relay waits to take over
+	var keyframeRequestedAt time.Time
 
 relayLoop:
 	for {
@@ -102,18 +292,80 @@ relayLoop:
 		case <-ss.ctx.Done():
 			break relayLoop
 		default:
-			pkt, _, err := track.ReadRTP()
+			pkt, err := track.ReadRTP()
 			if err != nil {
-				// TODO: should we continue instead of breaking? If we need to break, we need to let the rest
-				// of the application know, and likely attempt to re-connect
+				// the track went away; the source stays connected so the relay starts again if it comes back
 				fmt.Println("error reading RTP packet, exiting relay loop")
+				if ss.ctx.Err() == nil && ss.isActiveRelay(id) {
+					ss.loseSource(fmt.Sprintf("unable to read from track: %s", err))
+				}
 				break relayLoop
 			}
+			ss.capture.writeRTP(pkt)
+
+			active, target, wanted := ss.onRelayPacket(id)
+			if !active && !wanted {
+				// another relay took over, or the stream was retargeted before this one could
+				break relayLoop
+			}
+			// ask for a keyframe straight away, and again if none comes, so the relay can take over without waiting for
+			// the publisher's next one
+			if target && !active {
+				if time.Since(keyframeRequestedAt) > keyframeRequestInterval {
+					track.RequestKeyframe()
+					keyframeRequestedAt = time.Now()
+				}
+			} else {
+				keyframeRequestedAt = time.Time{}
+			}
+			if active {
+				ss.setState(skyegresspb.SessionState_SESSION_STATE_ACTIVE, "relaying packets")
+				ss.stats.onReceived(pkt)
+			}
 			sb.Push(pkt)
 
 			for _, p := range sb.PopPackets() {
-				for _, medi := range ss.rtspStream.Medias() {
-					ss.rtspStream.WritePacketRTP(medi, p)
+				identity, metadata := track.Publisher()
+				if !active {
+					if !ss.takeOver(id, source, identity, isKeyframeStart(p.Payload)) {
+						continue
+					}
+					active = true
+					ss.setState(skyegresspb.SessionState_SESSION_STATE_ACTIVE, "relaying packets")
+				}
+
+				// decode before rewriting, as the decoder follows this source's sequence numbers
+				au, _, decodeErr := decoder.DecodeUntilMarker(p)
+				// the sender reports map this source's timestamps, so look the capture time up before rewriting
+				wallClock, reportAt, known := track.CaptureTime(p.Timestamp)
+				origin := clockFromSenderReport
+				if !known {
+					wallClock, origin = time.Now(), clockFromArrival
+				} else if reportAt.IsZero() {
+					origin = clockFromSource
+				}
+				seq, ssrc := p.SequenceNumber, p.SSRC
+				processed := ss.processors.process(p, &PacketInfo{
+					SID:               ss.session.Sid,
+					PublisherIdentity: identity,
+					PublisherMetadata: metadata,
+					CaptureTime:       wallClock,
+					ClockRate:         clockRate,
+					Keyframe:          isKeyframeStart(p.Payload),
+				})
+				if !ss.rewriter.rewriteProcessed(seq, ssrc, processed, clockRate, id) {
+					// another relay or the slate took over since the last packet; this one can take over again at a
+					// keyframe while it's still wanted
+					active = false
+					continue
+				}
+				if decodeErr == nil {
+					ss.stats.onFrame(au)
+				}
+				for _, out := range processed {
+					ss.stats.onRelayed(out)
+					ss.clock.onWritten(out, wallClock, origin, reportAt)
+					ss.sinks.writeRTP(out, wallClock)
 				}
 			}
 		}
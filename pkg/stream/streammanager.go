@@ -1,24 +1,103 @@
 package stream
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/aler9/gortsplib/v2"
+	"github.com/aler9/gortsplib/v2/pkg/media"
+	lksdk "github.com/livekit/server-sdk-go"
 	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/config"
 )
 
+// builds the session for a start request; the SID doubles as the RTSP path the session is served on
+func NewSession(req *skyegresspb.StartSessionRequest) *skyegresspb.Session {
+	trackName := req.TrackName
+	if req.Kind != skyegresspb.SessionKind_SESSION_KIND_EGRESS && len(trackName) == 0 {
+		trackName = defaultIngressTrackName
+	}
+	sid := strings.Trim(req.Path, "/")
+	if len(sid) == 0 && len(req.Source) > 0 && req.Kind == skyegresspb.SessionKind_SESSION_KIND_EGRESS {
+		sid = DefaultSourcePath(req.Source)
+	}
+	if len(sid) == 0 {
+		sid = fmt.Sprintf("%s/%s", req.RoomName, trackName)
+	}
+	identity := fmt.Sprintf("skyegress-%s", strings.ReplaceAll(sid, "/", "-"))
+	// ingress and publish sessions publish as the participant the request names
+	if req.Kind != skyegresspb.SessionKind_SESSION_KIND_EGRESS && len(req.ParticipantIdentity) > 0 {
+		identity = req.ParticipantIdentity
+	}
+	failoverTimeout := req.FailoverTimeoutMs
+	if failoverTimeout == 0 {
+		failoverTimeout = uint32(defaultFailoverTimeout.Milliseconds())
+	}
+	return &skyegresspb.Session{
+		Sid:                       sid,
+		RoomName:                  req.RoomName,
+		TrackName:                 trackName,
+		EgressIdentity:            identity,
+		ParticipantIdentity:       req.ParticipantIdentity,
+		WebhookUrls:               req.WebhookUrls,
+		BackupTrackName:           req.BackupTrackName,
+		BackupParticipantIdentity: req.BackupParticipantIdentity,
+		FailoverTimeoutMs:         failoverTimeout,
+		SeiMode:                   req.SeiMode,
+		Processors:                req.Processors,
+		Sinks:                     req.Sinks,
+		Source:                    req.Source,
+		Kind:                      req.Kind,
+	}
+}
+
+// how long a primary track may go without packets before the backup is relayed, unless the request says otherwise
+const defaultFailoverTimeout = 3 * time.Second
+
+// how often streams are checked for readers when an idle timeout is set
+const idleCheckInterval = time.Second
+
 type SkyEgressStreamManager struct {
+	nodeID      string
+	lkCfg       config.LiveKitConfig
+	capacity    config.CapacityConfig
+	capture     config.CaptureConfig
+	slate       *Slate
 	streamsLock sync.RWMutex
 	streams     map[string]*skyEgressStream
+	// ingress sessions share SIDs, capacity and the streams lock with the streams
+	ingresses map[string]*ingressSession
+	// set once the server starts shutting down; no new sessions or readers are admitted
+	draining      bool
+	drainDeadline time.Time
+	drained       chan struct{}
+
+	listenersLock sync.RWMutex
+	listeners     []EventListener
 }
 
-func NewSkyEgressStreamManager() SkyEgressStreamManager {
+func NewSkyEgressStreamManager(nodeID string, lkCfg config.LiveKitConfig, capacity config.CapacityConfig, capture config.CaptureConfig, slate *Slate) SkyEgressStreamManager {
 	return SkyEgressStreamManager{
-		streams: make(map[string]*skyEgressStream),
+		nodeID:    nodeID,
+		lkCfg:     lkCfg,
+		capacity:  capacity,
+		capture:   capture,
+		slate:     slate,
+		streams:   make(map[string]*skyEgressStream),
+		ingresses: make(map[string]*ingressSession),
+		drained:   make(chan struct{}),
 	}
 }
 
+// ID of the node in the cluster the manager runs streams for
+func (sm *SkyEgressStreamManager) NodeID() string {
+	return sm.nodeID
+}
+
 func (sm *SkyEgressStreamManager) GetStream(sid string) (*skyEgressStream, bool) {
 	sm.streamsLock.RLock()
 	defer sm.streamsLock.RUnlock()
@@ -28,28 +107,205 @@ func (sm *SkyEgressStreamManager) GetStream(sid string) (*skyEgressStream, bool)
 	return stream, ok
 }
 
+// whether a stream or ingress session has the SID
+func (sm *SkyEgressStreamManager) HasSession(sid string) bool {
+	sm.streamsLock.RLock()
+	defer sm.streamsLock.RUnlock()
+	return sm.hasSession(sid)
+}
+
+// must be called with the streams lock held
+func (sm *SkyEgressStreamManager) hasSession(sid string) bool {
+	_, isStream := sm.streams[sid]
+	_, isIngress := sm.ingresses[sid]
+	return isStream || isIngress
+}
+
 func (sm *SkyEgressStreamManager) AddStream(session *skyegresspb.Session) (*skyEgressStream, error) {
 	sm.streamsLock.Lock()
-	defer sm.streamsLock.Unlock()
 
-	if _, ok := sm.streams[session.Sid]; ok {
+	if sm.hasSession(session.Sid) {
+		sm.streamsLock.Unlock()
 		msg := fmt.Sprintf("stream with SID %s already exists", session.Sid)
 		return nil, errors.New(msg)
 	}
 
-	stream := NewSkyEgressStream(session)
+	if err := sm.admitSession(); err != nil {
+		sm.streamsLock.Unlock()
+		return nil, err
+	}
+
+	processors, err := newProcessorChain(session)
+	if err != nil {
+		sm.streamsLock.Unlock()
+		return nil, err
+	}
+
+	session.NodeId = sm.nodeID
+	stream := newSkyEgressStream(session, sm.emit, sm.slate, processors)
+	if err := stream.addSessionSinks(); err != nil {
+		sm.streamsLock.Unlock()
+		return nil, err
+	}
 	sm.streams[session.Sid] = &stream
+	sm.streamsLock.Unlock()
+
+	sm.emit(NewSessionEvent(skyegresspb.SessionEventType_SESSION_EVENT_CREATED, stream.Session(), ""))
 	return &stream, nil
 }
 
-func (sm *SkyEgressStreamManager) RemoveStream(sid string) {
+// adds a stream for the session and connects it to its source, removing it again if the connection fails
+func (sm *SkyEgressStreamManager) StartStream(session *skyegresspb.Session) (*skyEgressStream, error) {
+	stream, err := sm.AddStream(session)
+	if err != nil {
+		return nil, err
+	}
+
+	var source Source
+	if len(session.Source) > 0 {
+		source, err = newSource(session)
+		if err != nil {
+			sm.RemoveStream(session.Sid, "unable to create source")
+			return nil, err
+		}
+	} else {
+		source = newLiveKitSource(sm.lkCfg.Host, lksdk.ConnectInfo{
+			APIKey:              sm.lkCfg.ApiKey,
+			APISecret:           sm.lkCfg.ApiSecret,
+			RoomName:            session.RoomName,
+			ParticipantIdentity: session.EgressIdentity,
+		})
+	}
+
+	err = stream.Start(source)
+	if err != nil {
+		fmt.Println("Failed to start stream, cleaning up", err)
+		sm.RemoveStream(session.Sid, "unable to connect to source")
+		return nil, err
+	}
+
+	return stream, nil
+}
+
+// starts an ingress session pulling the session's RTSP source into its room, once the source has been found to
+// have H264 video
+func (sm *SkyEgressStreamManager) StartIngress(session *skyegresspb.Session) (*ingressSession, error) {
+	ingress, err := sm.addIngress(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := ingress.Start(); err != nil {
+		fmt.Println("Failed to start ingress, cleaning up", err)
+		sm.RemoveStream(session.Sid, "unable to connect to source")
+		return nil, err
+	}
+	return ingress, nil
+}
+
+// starts a publish session forwarding the media an RTSP publisher announced into the session's room; the publisher
+// records into it once started
+func (sm *SkyEgressStreamManager) StartPublish(session *skyegresspb.Session, publisher *gortsplib.ServerSession, medias media.Medias) (*ingressSession, error) {
+	ingress, err := sm.addIngress(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := ingress.StartPublish(publisher, medias); err != nil {
+		fmt.Println("Failed to start publish session, cleaning up", err)
+		sm.RemoveStream(session.Sid, "unable to publish into room")
+		return nil, err
+	}
+	return ingress, nil
+}
+
+// the publish session with the SID, if it's still the one the RTSP publisher started
+func (sm *SkyEgressStreamManager) GetPublish(sid string, publisher *gortsplib.ServerSession) (*ingressSession, bool) {
+	sm.streamsLock.RLock()
+	defer sm.streamsLock.RUnlock()
+
+	ingress, ok := sm.ingresses[sid]
+	if !ok || !ingress.PublishedBy(publisher) {
+		return nil, false
+	}
+	return ingress, true
+}
+
+// registers the session, counting it against capacity, ahead of starting it
+func (sm *SkyEgressStreamManager) addIngress(session *skyegresspb.Session) (*ingressSession, error) {
+	sm.streamsLock.Lock()
+	if sm.hasSession(session.Sid) {
+		sm.streamsLock.Unlock()
+		return nil, fmt.Errorf("stream with SID %s already exists", session.Sid)
+	}
+	if err := sm.admitSession(); err != nil {
+		sm.streamsLock.Unlock()
+		return nil, err
+	}
+	session.NodeId = sm.nodeID
+	session.PublisherIdentity = session.EgressIdentity
+	ingress, err := newIngressSession(session, sm.lkCfg, sm.emit)
+	if err != nil {
+		sm.streamsLock.Unlock()
+		return nil, err
+	}
+	sm.ingresses[session.Sid] = ingress
+	sm.streamsLock.Unlock()
+
+	sm.emit(NewSessionEvent(skyegresspb.SessionEventType_SESSION_EVENT_CREATED, ingress.Session(), ""))
+	return ingress, nil
+}
+
+// switches the stream to another room and track; readers stay connected, and see the new track from its first
+// keyframe
+func (sm *SkyEgressStreamManager) RetargetStream(sid string, roomName string, trackName string, participantIdentity string) (*skyEgressStream, error) {
 	stream, ok := sm.GetStream(sid)
+	if !ok && sm.HasSession(sid) {
+		return nil, fmt.Errorf("session with SID %s is an ingress, so can't be retargeted", sid)
+	}
 	if !ok {
-		fmt.Printf("Stream %s did not exist\n", sid)
+		return nil, fmt.Errorf("stream with SID %s does not exist", sid)
+	}
+
+	session := stream.Session()
+	if len(session.Source) > 0 {
+		return nil, fmt.Errorf("stream with SID %s plays %s rather than a LiveKit room, so can't be retargeted", sid, session.Source)
+	}
+	err := stream.Retarget(newLiveKitSource(sm.lkCfg.Host, lksdk.ConnectInfo{
+		APIKey:              sm.lkCfg.ApiKey,
+		APISecret:           sm.lkCfg.ApiSecret,
+		RoomName:            roomName,
+		ParticipantIdentity: session.EgressIdentity,
+	}), trackName, participantIdentity)
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// starts capturing the packets the stream receives to a pcapng file of at most maxBytes, capped at the configured size
+// and defaulting to it if 0, or stops the current capture
+func (sm *SkyEgressStreamManager) CaptureStream(sid string, enabled bool, maxBytes uint64) (*skyegresspb.CaptureStats, error) {
+	stream, ok := sm.GetStream(sid)
+	if !ok {
+		return nil, fmt.Errorf("stream with SID %s does not exist", sid)
+	}
+	if !enabled {
+		return stream.StopCapture()
+	}
+	// the configured size is a ceiling requests can only lower
+	if maxBytes == 0 || maxBytes > sm.capture.MaxSize {
+		maxBytes = sm.capture.MaxSize
+	}
+	return stream.StartCapture(sm.capture.Dir, maxBytes)
+}
+
+func (sm *SkyEgressStreamManager) RemoveStream(sid string, reason string) {
+	stream, ok := sm.GetStream(sid)
+	if !ok {
+		sm.removeIngress(sid, reason)
 		return
 	}
 
-	err := stream.Stop()
+	err := stream.Stop(reason)
 	if err != nil {
 		// TODO(trey): how can we handle this better?
 		fmt.Println("Unable to stop stream successfully; still removing session")
@@ -60,14 +316,126 @@ func (sm *SkyEgressStreamManager) RemoveStream(sid string) {
 	sm.streamsLock.Unlock()
 }
 
+func (sm *SkyEgressStreamManager) removeIngress(sid string, reason string) {
+	sm.streamsLock.Lock()
+	ingress, ok := sm.ingresses[sid]
+	delete(sm.ingresses, sid)
+	sm.streamsLock.Unlock()
+	if !ok {
+		fmt.Printf("Stream %s did not exist\n", sid)
+		return
+	}
+	ingress.Stop(reason)
+}
+
+// removes every stream whose session matches, returning the SIDs that were removed
+func (sm *SkyEgressStreamManager) RemoveStreams(match func(*skyegresspb.Session) bool, reason string) []string {
+	removed := []string{}
+	for _, session := range sm.Sessions() {
+		if !match(session) {
+			continue
+		}
+		sm.RemoveStream(session.Sid, reason)
+		removed = append(removed, session.Sid)
+	}
+	return removed
+}
+
 func (sm *SkyEgressStreamManager) Sessions() []*skyegresspb.Session {
 	sm.streamsLock.RLock()
 	defer sm.streamsLock.RUnlock()
 
-	sessions := make([]*skyegresspb.Session, 0, len(sm.streams))
+	sessions := make([]*skyegresspb.Session, 0, len(sm.streams)+len(sm.ingresses))
 	for _, stream := range sm.streams {
-		sessions = append(sessions, stream.session)
+		sessions = append(sessions, stream.Session())
+	}
+	for _, ingress := range sm.ingresses {
+		sessions = append(sessions, ingress.Session())
 	}
 
 	return sessions
 }
+
+// emits a stats event for every stream each interval, until the context is cancelled
+func (sm *SkyEgressStreamManager) EmitStats(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, session := range sm.Sessions() {
+				sm.emit(NewSessionEvent(skyegresspb.SessionEventType_SESSION_EVENT_STATS, session, ""))
+			}
+		}
+	}
+}
+
+// stops every stream at once, returning the SIDs that were stopped before the context was done; the rest keep
+// stopping in the background
+func (sm *SkyEgressStreamManager) StopAll(ctx context.Context, reason string) []string {
+	sessions := sm.Sessions()
+	done := make(chan string, len(sessions))
+	for _, session := range sessions {
+		go func(sid string) {
+			sm.RemoveStream(sid, reason)
+			done <- sid
+		}(session.Sid)
+	}
+
+	stopped := []string{}
+	for range sessions {
+		select {
+		case sid := <-done:
+			stopped = append(stopped, sid)
+		case <-ctx.Done():
+			fmt.Printf("%d sessions didn't stop in time, abandoning them\n", len(sessions)-len(stopped))
+			return stopped
+		}
+	}
+	return stopped
+}
+
+// stops every stream that has gone without RTSP readers for longer than the timeout, until the context is
+// cancelled
+func (sm *SkyEgressStreamManager) StopIdle(ctx context.Context, timeout time.Duration) {
+	ticker := time.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sm.streamsLock.RLock()
+			idle := []string{}
+			for sid, stream := range sm.streams {
+				if stream.IdleFor() > timeout {
+					idle = append(idle, sid)
+				}
+			}
+			sm.streamsLock.RUnlock()
+
+			for _, sid := range idle {
+				sm.RemoveStream(sid, fmt.Sprintf("no RTSP readers for %s", timeout))
+			}
+		}
+	}
+}
+
+// registers a listener for the lifecycle events of every stream
+func (sm *SkyEgressStreamManager) AddListener(listener EventListener) {
+	sm.listenersLock.Lock()
+	defer sm.listenersLock.Unlock()
+	sm.listeners = append(sm.listeners, listener)
+}
+
+func (sm *SkyEgressStreamManager) emit(event *skyegresspb.SessionEvent) {
+	sm.listenersLock.RLock()
+	defer sm.listenersLock.RUnlock()
+	for _, listener := range sm.listeners {
+		listener(event)
+	}
+}
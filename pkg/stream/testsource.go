@@ -0,0 +1,155 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/treyhaknson/skyegress/gen/pbtypes/skyegresspb"
+	"github.com/treyhaknson/skyegress/pkg/nalu"
+	"github.com/treyhaknson/skyegress/pkg/testpattern"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	sourceTestPattern = "testpattern"
+
+	defaultTestPatternVariant = "720p30"
+)
+
+// identifies the TestPatternFrame carried in SEI NAL units: 9c3e8f1a-5d27-4b61-a0f4-7e2b6c91d350
+var testPatternFrameUUID = [16]byte{0x9c, 0x3e, 0x8f, 0x1a, 0x5d, 0x27, 0x4b, 0x61, 0xa0, 0xf4, 0x7e, 0x2b, 0x6c, 0x91, 0xd3, 0x50}
+
+// a size and frame rate the test pattern can be played at
+type testPatternVariant struct {
+	width  int
+	height int
+	fps    int
+}
+
+// variants the test pattern can be asked for by name, such as testpattern:1080p30
+var testPatternVariants = map[string]testPatternVariant{
+	"240p15":  {width: 320, height: 240, fps: 15},
+	"360p30":  {width: 640, height: 360, fps: 30},
+	"480p30":  {width: 854, height: 480, fps: 30},
+	"720p30":  {width: 1280, height: 720, fps: 30},
+	"720p60":  {width: 1280, height: 720, fps: 60},
+	"1080p25": {width: 1920, height: 1080, fps: 25},
+	"1080p30": {width: 1920, height: 1080, fps: 30},
+	"1080p60": {width: 1920, height: 1080, fps: 60},
+	"2160p30": {width: 3840, height: 2160, fps: 30},
+}
+
+// names of the variants the test pattern can be played at
+func testPatternVariantNames() []string {
+	names := make([]string, 0, len(testPatternVariants))
+	for name := range testPatternVariants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// the name of the variant, or its size and frame rate if it isn't one of the named ones
+func (v testPatternVariant) String() string {
+	for name, named := range testPatternVariants {
+		if named == v {
+			return name
+		}
+	}
+	return fmt.Sprintf("%dx%dp%d", v.width, v.height, v.fps)
+}
+
+// the variant a testpattern URL asks for: a named variant, 720p30 by default, with any of its size and frame rate
+// replaced by width=, height= and fps=
+func parseTestPattern(source string) (testPatternVariant, error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return testPatternVariant{}, err
+	}
+	name := u.Opaque
+	if len(name) == 0 {
+		name = defaultTestPatternVariant
+	}
+	variant, ok := testPatternVariants[name]
+	if !ok {
+		return testPatternVariant{}, fmt.Errorf("unknown test pattern variant %s; use one of %s", name,
+			strings.Join(testPatternVariantNames(), ", "))
+	}
+
+	query := u.Query()
+	if variant.width, err = queryInt(query, "width", variant.width); err != nil {
+		return testPatternVariant{}, err
+	}
+	if variant.height, err = queryInt(query, "height", variant.height); err != nil {
+		return testPatternVariant{}, err
+	}
+	if variant.fps, err = queryInt(query, "fps", variant.fps); err != nil {
+		return testPatternVariant{}, err
+	}
+	return variant, nil
+}
+
+// the path a session playing the source is served on when the request doesn't give one, or empty if it must
+func DefaultSourcePath(source string) string {
+	if scheme, _, _ := strings.Cut(source, ":"); scheme != sourceTestPattern {
+		return ""
+	}
+	variant, err := parseTestPattern(source)
+	if err != nil {
+		// the session fails to start, saying why
+		return sourceTestPattern
+	}
+	return fmt.Sprintf("%s/%s", sourceTestPattern, variant)
+}
+
+// plays colour bars, for testing without a publisher: testpattern, testpattern:1080p30, or
+// testpattern:720p30?fps=25. Every frame carries a TestPatternFrame in SEI, counting frames from the first, and is
+// played at the time it's due like a live camera, with a keyframe every second.
+func newTestPatternSource(session *skyegresspb.Session) (Source, error) {
+	variant, err := parseTestPattern(session.Source)
+	if err != nil {
+		return nil, err
+	}
+	clip, err := testpattern.ColourBars(variant.width, variant.height, variant.fps)
+	if err != nil {
+		return nil, err
+	}
+	frames, duration := steadyFrames(clip, variant.fps)
+
+	name := variant.String()
+	sei := func(frame uint64, captured time.Time) []byte {
+		info, err := proto.Marshal(&skyegresspb.TestPatternFrame{
+			FrameNumber:   frame,
+			CaptureTimeNs: captured.UnixNano(),
+			Variant:       name,
+			Width:         uint32(variant.width),
+			Height:        uint32(variant.height),
+			FrameRate:     uint32(variant.fps),
+		})
+		if err != nil {
+			return nil
+		}
+		return nalu.UserDataSEI(testPatternFrameUUID, info)
+	}
+	return newReplaySource(session.Source, func(ctx context.Context, track *queuedTrack) error {
+		return playFrames(ctx, track, frames, duration, true, sei)
+	}), nil
+}
+
+// the positive integer in the query, or the default if there's none
+func queryInt(query url.Values, name string, defaultValue int) (int, error) {
+	value := query.Get(name)
+	if len(value) == 0 {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s %s", name, value)
+	}
+	return n, nil
+}
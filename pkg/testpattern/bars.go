@@ -0,0 +1,184 @@
+// Package testpattern produces H264 video without an encoder: colour bars generated straight into the bitstream
+// and pre-encoded clips loaded from Annex B files.
+package testpattern
+
+import "fmt"
+
+const (
+	headerSPS       = 0x67 // nal_ref_idc 3, SPS
+	headerPPS       = 0x68 // nal_ref_idc 3, PPS
+	headerIDR       = 0x65 // nal_ref_idc 3, IDR slice
+	headerNonIDR    = 0x41 // nal_ref_idc 2, non-IDR slice
+	profileBaseline = 66
+	// frame_num is coded in log2_max_frame_num_minus4 + 4 bits
+	log2MaxFrameNumMinus4 = 4
+	maxFrameNum           = 1 << (log2MaxFrameNumMinus4 + 4)
+
+	mbTypeI16x16Vertical = 1 // Intra 16x16, vertical prediction, no coded luma or chroma
+	mbTypeIPCM           = 25
+	chromaPredVertical   = 2
+	sliceTypeP           = 5 // all slices of the picture are P
+	sliceTypeI           = 7 // all slices of the picture are I
+)
+
+// Y, Cb, Cr of the 75% SMPTE colour bars, left to right
+var bars = [][3]byte{
+	{235, 128, 128}, // white
+	{162, 44, 142},  // yellow
+	{131, 156, 44},  // cyan
+	{112, 72, 58},   // green
+	{84, 184, 198},  // magenta
+	{65, 100, 212},  // red
+	{35, 212, 114},  // blue
+	{16, 128, 128},  // black
+}
+
+// one second of colour bars at the size and frame rate, as access units of H264 NAL units. The first access unit is
+// an IDR carrying the SPS and PPS, the rest repeat it, so the clip can be looped.
+func ColourBars(width int, height int, frameRate int) ([][][]byte, error) {
+	if width <= 0 || height <= 0 || width%2 != 0 || height%2 != 0 {
+		return nil, fmt.Errorf("invalid test pattern size %dx%d; both must be even", width, height)
+	}
+	if frameRate <= 0 || frameRate >= maxFrameNum {
+		return nil, fmt.Errorf("invalid test pattern frame rate %d", frameRate)
+	}
+
+	mbWidth := (width + 15) / 16
+	mbHeight := (height + 15) / 16
+	sps := spsNALU(width, height, mbWidth, mbHeight)
+	pps := ppsNALU()
+	idr := idrNALU(mbWidth, mbHeight)
+
+	aus := [][][]byte{{sps, pps, idr}}
+	// back to back IDRs would need differing idr_pic_ids, so there is always at least one P frame
+	frames := frameRate
+	if frames < 2 {
+		frames = 2
+	}
+	for i := 1; i < frames; i++ {
+		aus = append(aus, [][]byte{skipNALU(mbWidth*mbHeight, i)})
+	}
+	return aus, nil
+}
+
+func spsNALU(width int, height int, mbWidth int, mbHeight int) []byte {
+	w := &bitWriter{}
+	w.bits(profileBaseline, 8)
+	w.bits(0xC0, 8) // constraint_set0 and constraint_set1: constrained baseline
+	level := uint64(40)
+	if mbWidth*mbHeight > 8192 {
+		level = 51
+	}
+	w.bits(level, 8)
+	w.ue(0) // seq_parameter_set_id
+	w.ue(log2MaxFrameNumMinus4)
+	w.ue(2) // pic_order_cnt_type: output order is decode order
+	w.ue(1) // max_num_ref_frames
+	w.bit(0)
+	w.ue(uint32(mbWidth - 1))
+	w.ue(uint32(mbHeight - 1))
+	w.bit(1) // frame_mbs_only_flag
+	w.bit(1) // direct_8x8_inference_flag
+
+	// crop the macroblock padding off the right and bottom, in units of two pixels
+	cropRight := (mbWidth*16 - width) / 2
+	cropBottom := (mbHeight*16 - height) / 2
+	if cropRight > 0 || cropBottom > 0 {
+		w.bit(1)
+		w.ue(0)
+		w.ue(uint32(cropRight))
+		w.ue(0)
+		w.ue(uint32(cropBottom))
+	} else {
+		w.bit(0)
+	}
+
+	w.bit(0) // vui_parameters_present_flag
+	w.trailing()
+	return w.nalu(headerSPS)
+}
+
+func ppsNALU() []byte {
+	w := &bitWriter{}
+	w.ue(0)  // pic_parameter_set_id
+	w.ue(0)  // seq_parameter_set_id
+	w.bit(0) // entropy_coding_mode_flag: CAVLC
+	w.bit(0) // bottom_field_pic_order_in_frame_present_flag
+	w.ue(0)  // num_slice_groups_minus1
+	w.ue(0)  // num_ref_idx_l0_default_active_minus1
+	w.ue(0)  // num_ref_idx_l1_default_active_minus1
+	w.bit(0) // weighted_pred_flag
+	w.bits(0, 2)
+	w.se(0)  // pic_init_qp_minus26
+	w.se(0)  // pic_init_qs_minus26
+	w.se(0)  // chroma_qp_index_offset
+	w.bit(1) // deblocking_filter_control_present_flag
+	w.bit(0) // constrained_intra_pred_flag
+	w.bit(0) // redundant_pic_cnt_present_flag
+	w.trailing()
+	return w.nalu(headerPPS)
+}
+
+// the top row of macroblocks carries the bars as raw samples, and every row below predicts itself from the one above
+func idrNALU(mbWidth int, mbHeight int) []byte {
+	w := &bitWriter{}
+	w.ue(0) // first_mb_in_slice
+	w.ue(sliceTypeI)
+	w.ue(0) // pic_parameter_set_id
+	w.bits(0, log2MaxFrameNumMinus4+4)
+	w.ue(0)  // idr_pic_id
+	w.bit(0) // no_output_of_prior_pics_flag
+	w.bit(0) // long_term_reference_flag
+	w.se(0)  // slice_qp_delta
+	w.ue(1)  // disable_deblocking_filter_idc: the bars stay sharp
+
+	for x := 0; x < mbWidth; x++ {
+		colour := bars[x*len(bars)/mbWidth]
+		w.ue(mbTypeIPCM)
+		w.align()
+		for i := 0; i < 256; i++ {
+			w.bits(uint64(colour[0]), 8)
+		}
+		for i := 0; i < 64; i++ {
+			w.bits(uint64(colour[1]), 8)
+		}
+		for i := 0; i < 64; i++ {
+			w.bits(uint64(colour[2]), 8)
+		}
+	}
+
+	for y := 1; y < mbHeight; y++ {
+		for x := 0; x < mbWidth; x++ {
+			w.ue(mbTypeI16x16Vertical)
+			w.ue(chromaPredVertical)
+			w.se(0) // mb_qp_delta
+			// coeff_token of the empty luma DC block. Its table depends on the coefficients of the neighbours above and
+			// to the left, and a raw macroblock counts as 16 of them.
+			if y == 1 {
+				w.bits(0x3, 6)
+			} else {
+				w.bit(1)
+			}
+		}
+	}
+
+	w.trailing()
+	return w.nalu(headerIDR)
+}
+
+// a P frame that skips every macroblock, repeating the previous frame
+func skipNALU(macroblocks int, frameNum int) []byte {
+	w := &bitWriter{}
+	w.ue(0) // first_mb_in_slice
+	w.ue(sliceTypeP)
+	w.ue(0) // pic_parameter_set_id
+	w.bits(uint64(frameNum%maxFrameNum), log2MaxFrameNumMinus4+4)
+	w.bit(0) // num_ref_idx_active_override_flag
+	w.bit(0) // ref_pic_list_modification_flag_l0
+	w.bit(0) // adaptive_ref_pic_marking_mode_flag
+	w.se(0)  // slice_qp_delta
+	w.ue(1)  // disable_deblocking_filter_idc
+	w.ue(uint32(macroblocks))
+	w.trailing()
+	return w.nalu(headerNonIDR)
+}
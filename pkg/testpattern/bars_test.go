@@ -0,0 +1,266 @@
+package testpattern
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aler9/gortsplib/v2/pkg/bits"
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+)
+
+// reads the bitstream of a NAL unit, failing the test on its first error
+type bitReader struct {
+	t   *testing.T
+	buf []byte
+	pos int
+}
+
+func newBitReader(t *testing.T, nalu []byte) *bitReader {
+	return &bitReader{t: t, buf: h264.EmulationPreventionRemove(nalu[1:])}
+}
+
+func (r *bitReader) bits(n int) uint64 {
+	r.t.Helper()
+	v, err := bits.ReadBits(r.buf, &r.pos, n)
+	if err != nil {
+		r.t.Fatalf("reading %d bits at %d: %s", n, r.pos, err)
+	}
+	return v
+}
+
+func (r *bitReader) ue() uint32 {
+	r.t.Helper()
+	v, err := bits.ReadGolombUnsigned(r.buf, &r.pos)
+	if err != nil {
+		r.t.Fatalf("reading ue(v) at %d: %s", r.pos, err)
+	}
+	return v
+}
+
+func (r *bitReader) se() int32 {
+	r.t.Helper()
+	v, err := bits.ReadGolombSigned(r.buf, &r.pos)
+	if err != nil {
+		r.t.Fatalf("reading se(v) at %d: %s", r.pos, err)
+	}
+	return v
+}
+
+func (r *bitReader) expect(name string, got uint64, want uint64) {
+	r.t.Helper()
+	if got != want {
+		r.t.Fatalf("%s: got %d, want %d", name, got, want)
+	}
+}
+
+// checks the rbsp_stop_one_bit and that nothing but alignment follows it
+func (r *bitReader) trailing() {
+	r.t.Helper()
+	r.expect("rbsp_stop_one_bit", r.bits(1), 1)
+	for r.pos%8 != 0 {
+		r.expect("rbsp_alignment_zero_bit", r.bits(1), 0)
+	}
+	if r.pos/8 != len(r.buf) {
+		r.t.Fatalf("%d bytes after the end of the slice", len(r.buf)-r.pos/8)
+	}
+}
+
+// decodes the IDR slice, checking the top row carries the bars and every row below predicts from it
+func checkIDR(t *testing.T, nalu []byte, mbWidth int, mbHeight int) {
+	r := newBitReader(t, nalu)
+	r.expect("first_mb_in_slice", uint64(r.ue()), 0)
+	r.expect("slice_type", uint64(r.ue()), sliceTypeI)
+	r.expect("pic_parameter_set_id", uint64(r.ue()), 0)
+	r.expect("frame_num", r.bits(log2MaxFrameNumMinus4+4), 0)
+	r.expect("idr_pic_id", uint64(r.ue()), 0)
+	r.bits(2) // no_output_of_prior_pics_flag, long_term_reference_flag
+	r.expect("slice_qp_delta", uint64(r.se()), 0)
+	r.expect("disable_deblocking_filter_idc", uint64(r.ue()), 1)
+
+	for x := 0; x < mbWidth; x++ {
+		r.expect("mb_type", uint64(r.ue()), mbTypeIPCM)
+		for r.pos%8 != 0 {
+			r.expect("pcm_alignment_zero_bit", r.bits(1), 0)
+		}
+		colour := bars[x*len(bars)/mbWidth]
+		for i := 0; i < 384; i++ {
+			plane := 0
+			if i >= 256 {
+				plane = 1 + (i-256)/64
+			}
+			if sample := r.bits(8); sample != uint64(colour[plane]) {
+				t.Fatalf("macroblock %d: sample %d is %d, want %d", x, i, sample, colour[plane])
+			}
+		}
+	}
+
+	for y := 1; y < mbHeight; y++ {
+		for x := 0; x < mbWidth; x++ {
+			r.expect("mb_type", uint64(r.ue()), mbTypeI16x16Vertical)
+			r.expect("intra_chroma_pred_mode", uint64(r.ue()), chromaPredVertical)
+			r.expect("mb_qp_delta", uint64(r.se()), 0)
+			if y == 1 {
+				r.expect("coeff_token", r.bits(6), 0x3)
+			} else {
+				r.expect("coeff_token", r.bits(1), 1)
+			}
+		}
+	}
+	r.trailing()
+}
+
+// decodes a P slice, checking it skips every macroblock
+func checkSkip(t *testing.T, nalu []byte, macroblocks int, frameNum int) {
+	r := newBitReader(t, nalu)
+	r.expect("first_mb_in_slice", uint64(r.ue()), 0)
+	r.expect("slice_type", uint64(r.ue()), sliceTypeP)
+	r.expect("pic_parameter_set_id", uint64(r.ue()), 0)
+	r.expect("frame_num", r.bits(log2MaxFrameNumMinus4+4), uint64(frameNum%maxFrameNum))
+	r.bits(3) // num_ref_idx_active_override_flag, ref_pic_list_modification_flag_l0, adaptive_ref_pic_marking_mode_flag
+	r.expect("slice_qp_delta", uint64(r.se()), 0)
+	r.expect("disable_deblocking_filter_idc", uint64(r.ue()), 1)
+	r.expect("mb_skip_run", uint64(r.ue()), uint64(macroblocks))
+	r.trailing()
+}
+
+func TestColourBars(t *testing.T) {
+	tests := []struct {
+		width     int
+		height    int
+		frameRate int
+	}{
+		{16, 16, 1},
+		// sizes that aren't whole macroblocks, cropped by an odd number of pixel pairs
+		{18, 10, 2},
+		{34, 18, 5},
+		{322, 242, 30},
+		{1280, 720, 30},
+		{1920, 1080, 60},
+		{4096, 2160, 30},
+	}
+	for _, test := range tests {
+		aus, err := ColourBars(test.width, test.height, test.frameRate)
+		if err != nil {
+			t.Fatalf("%dx%d: %s", test.width, test.height, err)
+		}
+		mbWidth := (test.width + 15) / 16
+		mbHeight := (test.height + 15) / 16
+
+		frames := test.frameRate
+		if frames < 2 {
+			frames = 2
+		}
+		if len(aus) != frames {
+			t.Fatalf("%dx%d: got %d access units, want %d", test.width, test.height, len(aus), frames)
+		}
+		if len(aus[0]) != 3 {
+			t.Fatalf("%dx%d: got %d NAL units in the first access unit, want SPS, PPS and IDR", test.width, test.height, len(aus[0]))
+		}
+		for i, typ := range []h264.NALUType{h264.NALUTypeSPS, h264.NALUTypePPS, h264.NALUTypeIDR} {
+			if got := h264.NALUType(aus[0][i][0] & 0x1F); got != typ {
+				t.Fatalf("%dx%d: NAL unit %d is %v, want %v", test.width, test.height, i, got, typ)
+			}
+		}
+
+		var sps h264.SPS
+		if err := sps.Unmarshal(aus[0][0]); err != nil {
+			t.Fatalf("%dx%d: %s", test.width, test.height, err)
+		}
+		if sps.Width() != test.width || sps.Height() != test.height {
+			t.Errorf("%dx%d: SPS describes %dx%d", test.width, test.height, sps.Width(), sps.Height())
+		}
+		if sps.ProfileIdc != profileBaseline || sps.VUI != nil {
+			t.Errorf("%dx%d: got profile %d, VUI %t", test.width, test.height, sps.ProfileIdc, sps.VUI != nil)
+		}
+
+		checkIDR(t, aus[0][2], mbWidth, mbHeight)
+		for i, au := range aus[1:] {
+			if len(au) != 1 || h264.NALUType(au[0][0]&0x1F) != h264.NALUTypeNonIDR {
+				t.Fatalf("%dx%d: access unit %d isn't a single non-IDR slice", test.width, test.height, i+1)
+			}
+			checkSkip(t, au[0], mbWidth*mbHeight, i+1)
+		}
+
+		// the clip loads back as the same access units
+		var nalus [][]byte
+		for _, au := range aus {
+			nalus = append(nalus, au...)
+		}
+		annexB, err := h264.AnnexBMarshal(nalus)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "bars.264")
+		if err := os.WriteFile(path, annexB, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadClip(path)
+		if err != nil {
+			t.Fatalf("%dx%d: %s", test.width, test.height, err)
+		}
+		if len(loaded) != len(aus) {
+			t.Fatalf("%dx%d: loaded %d access units, want %d", test.width, test.height, len(loaded), len(aus))
+		}
+		for i := range aus {
+			if len(loaded[i]) != len(aus[i]) {
+				t.Fatalf("%dx%d: loaded access unit %d has %d NAL units, want %d", test.width, test.height, i, len(loaded[i]), len(aus[i]))
+			}
+			for j := range aus[i] {
+				if !bytes.Equal(loaded[i][j], aus[i][j]) {
+					t.Fatalf("%dx%d: loaded access unit %d differs", test.width, test.height, i)
+				}
+			}
+		}
+	}
+}
+
+func TestColourBarsGolden(t *testing.T) {
+	aus, err := ColourBars(34, 18, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{"SPS", aus[0][0], "6742c02895a35e2221"},
+		{"PPS", aus[0][1], "68ce3c80"},
+		{"P slice", aus[1][0], "419a0228f0"},
+	}
+	for _, g := range golden {
+		if got := hex.EncodeToString(g.got); got != g.want {
+			t.Errorf("%s: got %s, want %s", g.name, got, g.want)
+		}
+	}
+	// the bars are too long to spell out
+	sum := sha256.Sum256(aus[0][2])
+	if got := hex.EncodeToString(sum[:]); got != "ae41cb264dea0e495754bdea8156d7a30a4da271f40c21096d05b0b23904463e" {
+		t.Errorf("IDR slice: got sha256 %s", got)
+	}
+}
+
+func TestColourBarsInvalid(t *testing.T) {
+	tests := []struct {
+		width     int
+		height    int
+		frameRate int
+	}{
+		{321, 241, 30},
+		{17, 9, 30},
+		{320, 241, 30},
+		{0, 240, 30},
+		{-2, 240, 30},
+		{320, 240, 0},
+		{320, 240, maxFrameNum},
+	}
+	for _, test := range tests {
+		if _, err := ColourBars(test.width, test.height, test.frameRate); err == nil {
+			t.Errorf("%dx%d at %d fps: expected an error", test.width, test.height, test.frameRate)
+		}
+	}
+}
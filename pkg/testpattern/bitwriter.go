@@ -0,0 +1,65 @@
+package testpattern
+
+import (
+	"math/bits"
+
+	"github.com/treyhaknson/skyegress/pkg/nalu"
+)
+
+// writes the bit-level syntax of H264 NAL units, most significant bit first
+type bitWriter struct {
+	buf []byte
+	cur byte
+	n   uint
+}
+
+func (w *bitWriter) bit(b uint) {
+	w.cur = w.cur<<1 | byte(b&1)
+	w.n++
+	if w.n == 8 {
+		w.buf = append(w.buf, w.cur)
+		w.cur = 0
+		w.n = 0
+	}
+}
+
+func (w *bitWriter) bits(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.bit(uint(v>>uint(i)) & 1)
+	}
+}
+
+// unsigned Exp-Golomb code
+func (w *bitWriter) ue(v uint32) {
+	x := uint64(v) + 1
+	size := bits.Len64(x)
+	w.bits(0, size-1)
+	w.bits(x, size)
+}
+
+// signed Exp-Golomb code
+func (w *bitWriter) se(v int32) {
+	if v > 0 {
+		w.ue(uint32(2*v - 1))
+	} else {
+		w.ue(uint32(-2 * v))
+	}
+}
+
+// pads with zero bits up to the next byte
+func (w *bitWriter) align() {
+	for w.n != 0 {
+		w.bit(0)
+	}
+}
+
+// rbsp_trailing_bits: a stop bit, then zeros up to the next byte
+func (w *bitWriter) trailing() {
+	w.bit(1)
+	w.align()
+}
+
+// the NAL unit with the header byte
+func (w *bitWriter) nalu(header byte) []byte {
+	return append([]byte{header}, nalu.EmulationPrevention(w.buf)...)
+}
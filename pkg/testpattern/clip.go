@@ -0,0 +1,92 @@
+package testpattern
+
+import (
+	"bytes"
+	"errors"
+	"os"
+
+	"github.com/aler9/gortsplib/v2/pkg/codecs/h264"
+)
+
+// loads a pre-encoded H264 clip from an Annex B file, as access units. The clip must start with an IDR, so it can be
+// looped.
+func LoadClip(path string) ([][][]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	nalus, err := annexBNALUs(data)
+	if err != nil {
+		return nil, err
+	}
+
+	aus := AccessUnits(nalus)
+	if len(aus) == 0 || !h264.IDRPresent(aus[0]) {
+		return nil, errors.New("clip must start with an IDR access unit")
+	}
+	return aus, nil
+}
+
+// splits an Annex B byte stream at its start codes. Unlike h264.AnnexBUnmarshal, which takes a single access unit, it
+// isn't limited in the number of NAL units.
+func annexBNALUs(data []byte) ([][]byte, error) {
+	start := bytes.Index(data, []byte{0, 0, 1})
+	if start < 0 || bytes.IndexFunc(data[:start], func(r rune) bool { return r != 0 }) >= 0 {
+		return nil, errors.New("clip isn't an Annex B byte stream")
+	}
+
+	nalus := [][]byte{}
+	rest := data[start+3:]
+	for len(rest) > 0 {
+		nalu := rest
+		end := bytes.Index(rest, []byte{0, 0, 1})
+		if end >= 0 {
+			nalu, rest = rest[:end], rest[end+3:]
+		} else {
+			rest = nil
+		}
+		// zeros before a start code belong to it, or trail the stream
+		if nalu = bytes.TrimRight(nalu, "\x00"); len(nalu) > 0 {
+			nalus = append(nalus, nalu)
+		}
+	}
+	return nalus, nil
+}
+
+// groups a sequence of NAL units into access units
+func AccessUnits(nalus [][]byte) [][][]byte {
+	aus := [][][]byte{}
+	var au [][]byte
+	hasSlice := false
+	for _, nalu := range nalus {
+		if len(nalu) == 0 {
+			continue
+		}
+
+		// an access unit ends before the non-VCL NAL units leading the next, or before the first slice of the next
+		// picture
+		newAU := false
+		switch typ := h264.NALUType(nalu[0] & 0x1F); typ {
+		case h264.NALUTypeAccessUnitDelimiter, h264.NALUTypeSPS, h264.NALUTypePPS, h264.NALUTypeSEI:
+			newAU = hasSlice
+		case h264.NALUTypeNonIDR, h264.NALUTypeIDR:
+			// first_mb_in_slice is 0, coded as a single 1 bit, in the first slice of a picture
+			newAU = hasSlice && len(nalu) > 1 && nalu[1]&0x80 != 0
+		}
+		if newAU {
+			aus = append(aus, au)
+			au = nil
+			hasSlice = false
+		}
+
+		au = append(au, nalu)
+		switch h264.NALUType(nalu[0] & 0x1F) {
+		case h264.NALUTypeNonIDR, h264.NALUTypeIDR:
+			hasSlice = true
+		}
+	}
+	if hasSlice {
+		aus = append(aus, au)
+	}
+	return aus
+}
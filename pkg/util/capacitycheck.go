@@ -0,0 +1,24 @@
+package util
+
+import (
+	"context"
+
+	"github.com/treyhaknson/skyegress/pkg/stream"
+)
+
+type capacityCheck struct {
+	manager *stream.SkyEgressStreamManager
+}
+
+// fails readiness while the server is at capacity, so load balancers stop sending it new work
+func NewCapacityCheck(manager *stream.SkyEgressStreamManager) *capacityCheck {
+	return &capacityCheck{manager: manager}
+}
+
+func (cc *capacityCheck) Name() string {
+	return "skyegress.capacity"
+}
+
+func (cc *capacityCheck) Execute(ctx context.Context) (details interface{}, err error) {
+	return cc.manager.Usage(), cc.manager.AtCapacity()
+}
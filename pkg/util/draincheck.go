@@ -0,0 +1,34 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/treyhaknson/skyegress/pkg/stream"
+)
+
+type drainCheck struct {
+	manager *stream.SkyEgressStreamManager
+}
+
+// fails readiness while the node is draining, so load balancers stop routing to it
+func NewDrainCheck(manager *stream.SkyEgressStreamManager) *drainCheck {
+	return &drainCheck{manager: manager}
+}
+
+func (dc *drainCheck) Name() string {
+	return "skyegress.drain"
+}
+
+func (dc *drainCheck) Execute(ctx context.Context) (details interface{}, err error) {
+	draining, deadline := dc.manager.DrainStatus()
+	if !draining {
+		return "accepting sessions", nil
+	}
+	sessions := dc.manager.Usage().Sessions
+	if deadline.IsZero() {
+		return nil, fmt.Errorf("draining, %d sessions left", sessions)
+	}
+	return nil, fmt.Errorf("draining, %d sessions left until %s", sessions, deadline.Format(time.RFC3339))
+}
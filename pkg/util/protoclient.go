@@ -47,29 +47,43 @@ func (pc *ProtoClient) Request(
 	protoReq proto.Message,
 	protoRes proto.Message,
 ) error {
+	_, err := pc.RequestStatus(method, path, protoReq, protoRes)
+	return err
+}
+
+// make an HTTP request to a protobuf service, returning the status it replied with
+func (pc *ProtoClient) RequestStatus(
+	method Method,
+	path string,
+	protoReq proto.Message,
+	protoRes proto.Message,
+) (int, error) {
 	// serialize request
 	out, err := proto.Marshal(protoReq)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	// make request
 	endpoint := fmt.Sprintf("%s%s", pc.url, path)
 	req, err := http.NewRequest(string(method), endpoint, bytes.NewReader(out))
 	if err != nil {
-		return err
+		return 0, err
 	}
 	req.Header.Add("Content-Type", "application/x-protobuf")
+	for key, value := range pc.headers {
+		req.Header.Add(key, value)
+	}
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer resp.Body.Close()
 
 	// deserialize response
 	out, err = io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return proto.Unmarshal(out, protoRes)
+	return resp.StatusCode, proto.Unmarshal(out, protoRes)
 }